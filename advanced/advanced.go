@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/KateGF/Http-Server-Project-SO/core"
+	"github.com/KateGF/Http-Server-Project-SO/websocket"
 )
 
 var (
@@ -80,32 +81,42 @@ func SleepHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
 	return core.Ok().Text(fmt.Sprintf("slept %d seconds", seconds)), nil
 }
 
-// LoadTestHandler simula 'tasks' goroutines durmiendo 'sleep' segundos cada una.
-// URL: /loadtest?tasks=n&sleep=x
-func LoadTestHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
+// Valida los parámetros tasks y sleep de /loadtest.
+func parseLoadTest(req *core.HttpRequest) (int, int, *core.HttpResponse) {
 	q := req.Target.Query()
 	tasksStr := q.Get("tasks")
 	if tasksStr == "" {
-		return core.BadRequest().Text("tasks is required"), nil
+		return 0, 0, core.BadRequest().Text("tasks is required")
 	}
 	n, err := strconv.Atoi(tasksStr)
 	if err != nil {
-		return core.BadRequest().Text("tasks must be a number"), nil
+		return 0, 0, core.BadRequest().Text("tasks must be a number")
 	}
 	if n < 1 {
-		return core.BadRequest().Text("tasks must be >= 1"), nil
+		return 0, 0, core.BadRequest().Text("tasks must be >= 1")
 	}
 
 	sleepStr := q.Get("sleep")
 	if sleepStr == "" {
-		return core.BadRequest().Text("sleep is required"), nil
+		return 0, 0, core.BadRequest().Text("sleep is required")
 	}
 	x, err := strconv.Atoi(sleepStr)
 	if err != nil {
-		return core.BadRequest().Text("sleep must be a number"), nil
+		return 0, 0, core.BadRequest().Text("sleep must be a number")
 	}
 	if x < 0 {
-		return core.BadRequest().Text("sleep must be >= 0"), nil
+		return 0, 0, core.BadRequest().Text("sleep must be >= 0")
+	}
+
+	return n, x, nil
+}
+
+// LoadTestHandler simula 'tasks' goroutines durmiendo 'sleep' segundos cada una.
+// URL: /loadtest?tasks=n&sleep=x
+func LoadTestHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
+	n, x, errResp := parseLoadTest(req)
+	if errResp != nil {
+		return errResp, nil
 	}
 
 	// Lanzar n goroutines y medir tiempo
@@ -131,21 +142,136 @@ func LoadTestHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
 	return core.Ok().JsonObj(resp), nil
 }
 
-// StatusHandler
-func StatusHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
-	uptime := time.Since(startTime).Seconds()
-	resp := struct {
-		Uptime     float64 `json:"uptime_s"`
-		TotalConns int64   `json:"total_connections"`
-		PID        int     `json:"pid"`
-		Goroutines int     `json:"goroutines"`
-	}{
-		uptime,
+// Flujo de eventos de /loadtest/stream.
+var loadTestStream = core.NewEventStream(streamLoadTest)
+
+// LoadTestStreamHandler es la variante de /loadtest con Server-Sent Events:
+// envía un evento "task" por cada goroutine terminada y un evento "done" al final.
+// El id de cada evento es el número de tareas terminadas; al reconectar con
+// Last-Event-ID solo se ejecutan las tareas restantes.
+// URL: /loadtest/stream?tasks=n&sleep=x
+func LoadTestStreamHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
+	if _, _, errResp := parseLoadTest(req); errResp != nil {
+		return errResp, nil
+	}
+	return loadTestStream.Response(req), nil
+}
+
+func streamLoadTest(req *core.HttpRequest, events *core.EventWriter) {
+	n, x, _ := parseLoadTest(req)
+
+	finished, err := strconv.Atoi(events.LastEventID)
+	if err != nil || finished < 0 || finished > n {
+		finished = 0
+	}
+
+	type task struct {
+		Finished   int   `json:"finished"`
+		Tasks      int   `json:"tasks"`
+		DurationMS int64 `json:"duration_ms"`
+	}
+
+	start := time.Now()
+	results := make(chan int64)
+	for i := finished; i < n; i++ {
+		go func() {
+			taskStart := time.Now()
+			select {
+			case <-time.After(time.Duration(x) * time.Second):
+			case <-events.Done():
+				return
+			}
+			select {
+			case results <- time.Since(taskStart).Milliseconds():
+			case <-events.Done():
+			}
+		}()
+	}
+
+	for finished < n {
+		select {
+		case <-events.Done():
+			// El cliente se desconectó: las goroutines pendientes terminan solas
+			return
+		case duration := <-results:
+			finished++
+			id := strconv.Itoa(finished)
+			if events.SendJSON(id, "task", task{finished, n, duration}) != nil {
+				return
+			}
+		}
+	}
+
+	events.SendJSON(strconv.Itoa(n), "done", struct {
+		Tasks      int   `json:"tasks"`
+		Sleep      int   `json:"sleep"`
+		DurationMS int64 `json:"duration_ms"`
+	}{n, x, time.Since(start).Milliseconds()})
+}
+
+// Estado del servidor que devuelven /status y /ws/status.
+type statusSnapshot struct {
+	Uptime     float64 `json:"uptime_s"`
+	TotalConns int64   `json:"total_connections"`
+	PID        int     `json:"pid"`
+	Goroutines int     `json:"goroutines"`
+}
+
+func snapshot() statusSnapshot {
+	return statusSnapshot{
+		time.Since(startTime).Seconds(),
 		atomic.LoadInt64(&totalConns),
 		os.Getpid(),
 		runtime.NumGoroutine(),
 	}
-	return core.Ok().JsonObj(resp), nil
+}
+
+// StatusHandler
+func StatusHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
+	return core.Ok().JsonObj(snapshot()), nil
+}
+
+// Upgrader de /ws/status; acepta cualquier origen.
+var statusUpgrader = websocket.NewUpgrader()
+
+// StatusSocketHandler: /ws/status?interval=s
+// Abre un WebSocket y envía el estado del servidor cada 'interval' segundos (1 por defecto).
+func StatusSocketHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
+	interval := time.Second
+	if raw := req.Target.Query().Get("interval"); raw != "" {
+		seconds, err := strconv.ParseFloat(raw, 64)
+		if err != nil || seconds < 0.1 || seconds > 60 {
+			return core.BadRequest().Text("interval must be between 0.1 and 60 seconds"), nil
+		}
+		interval = time.Duration(seconds * float64(time.Second))
+	}
+
+	return statusUpgrader.Upgrade(req, func(conn *websocket.Conn) {
+		// Lee en otra goroutine para responder a los ping y detectar el cierre
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := conn.WriteJSON(snapshot()); err != nil {
+				return
+			}
+			select {
+			case <-closed:
+				return
+			case <-ticker.C:
+			}
+		}
+	}), nil
 }
 
 // HelpHandler (/help)
@@ -154,6 +280,7 @@ func HelpHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
 		"GET  /fibonacci?num=",
 		"POST /createfile?name=&content=&repeat=",
 		"DELETE /deletefile?name=",
+		"GET  /static/{path}",
 		"GET  /reverse?text=",
 		"GET  /toupper?text=",
 		"GET  /hash?text=",
@@ -162,7 +289,9 @@ func HelpHandler(req *core.HttpRequest) (*core.HttpResponse, error) {
 		"GET  /simulate?seconds=&task=",
 		"GET  /sleep?seconds=",
 		"GET  /loadtest?tasks=&sleep=",
+		"GET  /loadtest/stream?tasks=&sleep=",
 		"GET  /status",
+		"GET  /ws/status?interval=",
 		"GET  /help",
 	}
 	return core.Ok().JsonObj(struct {
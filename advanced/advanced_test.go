@@ -3,7 +3,9 @@ package advanced
 import (
 	"encoding/json"
 	"github.com/KateGF/Http-Server-Project-SO/core"
+	"io"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -205,6 +207,37 @@ func TestLoadTestHandler_Errors(t *testing.T) {
 	}
 }
 
+func TestLoadTestStreamHandler(t *testing.T) {
+	req := makeReq("/loadtest/stream?tasks=3&sleep=0")
+	req.Headers["Last-Event-ID"] = "1"
+	res, _ := LoadTestStreamHandler(req)
+	if res.StatusCode != 200 || res.Headers["Content-Type"] != "text/event-stream; charset=utf-8" {
+		t.Fatalf("loadtest/stream: want 200 event stream; got %d %q", res.StatusCode, res.Headers["Content-Type"])
+	}
+
+	// Leer el cuerpo ejecuta el flujo hasta el evento final
+	data, err := io.ReadAll(res.BodyReader)
+	if err != nil {
+		t.Fatalf("loadtest/stream body: %v", err)
+	}
+	body := string(data)
+
+	// Reanuda desde Last-Event-ID: 1, así que solo quedan 2 tareas
+	if strings.Count(body, "event: task\n") != 2 {
+		t.Errorf("want 2 task events; got %q", body)
+	}
+	for _, want := range []string{"id: 2\n", "id: 3\nevent: task\n", "id: 3\nevent: done\n", `"tasks":3`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("loadtest/stream missing %q in %q", want, body)
+		}
+	}
+
+	res, _ = LoadTestStreamHandler(makeReq("/loadtest/stream?tasks=0&sleep=0"))
+	if res.StatusCode != 400 || res.Body != "tasks must be >= 1" {
+		t.Errorf("loadtest/stream?tasks=0: want 400; got %d %q", res.StatusCode, res.Body)
+	}
+}
+
 func TestStatusHandler(t *testing.T) {
 	req := makeReq("/status")
 	for i := 0; i < 3; i++ {
@@ -247,6 +280,27 @@ func TestStatusHandler(t *testing.T) {
 	}
 }
 
+func TestStatusSocketHandler_Errors(t *testing.T) {
+	cases := []struct {
+		query      string
+		wantStatus int
+	}{
+		{"interval=abc", 400},
+		{"interval=0", 400},
+		{"interval=61", 400},
+		// Intervalo válido, pero sin cabeceras de handshake
+		{"interval=2", 426},
+	}
+	for _, tc := range cases {
+		req := makeReq("/ws/status?" + tc.query)
+		req.Version = "HTTP/1.1"
+		res, _ := StatusSocketHandler(req)
+		if res.StatusCode != tc.wantStatus {
+			t.Errorf("ws/status?%s: want status %d; got %d", tc.query, tc.wantStatus, res.StatusCode)
+		}
+	}
+}
+
 func TestHelpHandler(t *testing.T) {
 	req := makeReq("/help")
 	res, _ := HelpHandler(req)
@@ -0,0 +1,242 @@
+// Package app construye el servidor de la aplicación: configuración a partir
+// de variables de entorno, middlewares y rutas.
+package app
+
+import (
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/advanced"
+	"github.com/KateGF/Http-Server-Project-SO/core"
+	"github.com/KateGF/Http-Server-Project-SO/h2c"
+	"github.com/KateGF/Http-Server-Project-SO/handlers"
+	"github.com/KateGF/Http-Server-Project-SO/middleware"
+	"github.com/KateGF/Http-Server-Project-SO/proxy"
+	"github.com/KateGF/Http-Server-Project-SO/service"
+)
+
+// Crea el servidor con la configuración y las rutas de la aplicación, sin
+// iniciarlo. Lo usan main.go y las pruebas, que lo atienden en un puerto
+// efímero con coretest.
+func NewServer() *core.HttpServer {
+	// Crea una nueva instancia del servidor HTTP.
+	server := core.NewHttpServer()
+
+	// Comprime con gzip/deflate las respuestas de texto y JSON según Accept-Encoding.
+	server.Compression = core.DefaultCompression()
+
+	// Procesa a la vez hasta 8 solicitudes en pipeline por conexión (las respuestas salen en orden).
+	server.Pipelining = 8
+
+	// Cuerpos de hasta 64 MiB; con Content-Length mayor se responde 413 sin esperar el cuerpo.
+	server.MaxBodySize = 64 << 20
+
+	// Parseo estricto (RFC 9112): rechaza con 400 las solicitudes ambiguas que permiten request smuggling.
+	server.StrictParsing = true
+
+	// CORS para paneles en otros orígenes, p. ej. CORS_ORIGINS="https://panel.example.com,https://*.example.org"
+	if origins := os.Getenv("CORS_ORIGINS"); origins != "" {
+		cors := middleware.NewCORS(strings.Split(origins, ",")...)
+		cors.AllowedMethods = []string{"GET", "HEAD", "POST", "DELETE"}
+		cors.AllowedHeaders = []string{"Authorization", "Content-Type", "If-Match", "If-None-Match"}
+		cors.ExposedHeaders = []string{"ETag"}
+		cors.MaxAge = 10 * time.Minute
+		server.Use(cors.Middleware())
+	}
+
+	// Proxies de confianza (TRUSTED_PROXIES): de ellos se aceptan Forwarded y
+	// X-Forwarded-* y, con PROXY_PROTOCOL=1, la cabecera del protocolo PROXY.
+	server.TrustedProxies = parseNetworks("TRUSTED_PROXIES", nil)
+	server.ProxyProtocol = os.Getenv("PROXY_PROTOCOL") == "1"
+
+	// Filtros por IP: BLOCKED_NETWORKS rechaza redes en todas las rutas e
+	// INTERNAL_NETWORKS limita las rutas destructivas (por defecto, redes privadas).
+	if blocked := parseNetworks("BLOCKED_NETWORKS", nil); len(blocked) > 0 {
+		server.Use((&middleware.IPFilter{Deny: blocked}).Middleware())
+	}
+	internal := &middleware.IPFilter{Allow: parseNetworks("INTERNAL_NETWORKS", middleware.InternalNetworks)}
+
+	// Registra un manejador para la ruta GET "/fibonacci".
+	server.Get("/fibonacci", service.FibonacciHandler)
+
+	// Rutas destructivas: solo desde la red interna y, si la autenticación está
+	// activa, borrar archivos y /loadtest quedan reservados al rol "admin"
+	protect := []core.Middleware{internal.Middleware()}
+	admin := make([]string, 0)
+	if auth := newAuth(); auth != nil {
+		protect = append(protect, auth.Middleware())
+		if policy := newPolicy(); policy != nil {
+			protect = append(protect, policy.Middleware())
+		}
+		admin = append(admin, "admin")
+	}
+
+	// Registra un manejador para POST "/createfile"
+	server.Post("/createfile", service.CreateFileHandler).Use(protect...)
+	// También exponer "/createfile" por GET para pruebas manuales sin body.
+	server.Get("/createfile", service.CreateFileHandler).Use(protect...)
+
+	// Registra un manejador para DELETE "/deletefile"
+	server.Delete("/deletefile", service.DeleteFileHandler).Use(protect...).RequireRoles(admin...)
+	// También exponer "/deletefile" por GET para pruebas manuales sin body.
+	server.Get("/deletefile", service.DeleteFileHandler).Use(protect...).RequireRoles(admin...)
+
+	// Archivos estáticos de un directorio propio, no del directorio de trabajo
+	// (que contiene el código y los archivos de AUTH_HTPASSWD/AUTH_POLICY):
+	// STATIC_DIR (por defecto "static"; /createfile?name=static/... escribe ahí).
+	// Los listados de directorios solo se activan con STATIC_LISTING=1.
+	staticDir := os.Getenv("STATIC_DIR")
+	if staticDir == "" {
+		staticDir = "static"
+	}
+	static := handlers.NewFileServer(staticDir)
+	static.Prefix = "/static"
+	static.Listing = os.Getenv("STATIC_LISTING") == "1"
+	server.Get("/static", static.Handle)
+	server.Head("/static", static.Handle)
+
+	// Proxy inverso opcional: API_UPSTREAMS="http://10.0.0.2:9000,http://10.0.0.3:9000"
+	// reenvía /api/... a esos servidores (API_BALANCING=least para menos conexiones).
+	if upstreams := os.Getenv("API_UPSTREAMS"); upstreams != "" {
+		api, err := proxy.New(strings.Split(upstreams, ",")...)
+		if err != nil {
+			slog.Error("Error configuring reverse proxy", "error", err)
+			os.Exit(1)
+		}
+		api.StripPrefix = "/api"
+		if os.Getenv("API_BALANCING") == "least" {
+			api.Balancing = proxy.LeastConnections
+		}
+		for _, method := range []string{"GET", "HEAD", "POST", "PUT", "DELETE", "PATCH"} {
+			server.AddHandler(method, "/api", api.Handle).StreamBody()
+		}
+	}
+
+	// Proxy de reenvío opcional para la red interna:
+	// FORWARD_PROXY_ALLOW="*.example.com:443,10.0.0.0/8:*" lista los destinos permitidos
+	// de CONNECT y de las solicitudes con target absoluto.
+	if allow := os.Getenv("FORWARD_PROXY_ALLOW"); allow != "" {
+		forward := proxy.NewForwardProxy(strings.Split(allow, ",")...)
+		server.Proxy = core.Chain(forward.Handle, internal.Middleware())
+	}
+
+	// Endpoints de cadenas
+	server.Get("/reverse", handlers.ReverseHandler)
+	server.Get("/toupper", handlers.ToUpperHandler)
+	server.Get("/hash", handlers.HashHandler)
+	server.Get("/", handlers.RootHandler)
+
+	// Endpoints avanzados
+	server.Get("/random", advanced.RandomHandler)
+	server.Get("/timestamp", advanced.TimestampHandler)
+	// Límite por cliente y por ruta para los endpoints que consumen CPU
+	server.Get("/simulate", advanced.SimulateHandler).Use(heavyLimit())
+	server.Get("/sleep", advanced.SleepHandler)
+	server.Get("/loadtest", advanced.LoadTestHandler).Use(protect...).Use(heavyLimit()).RequireRoles(admin...)
+	server.Get("/loadtest/stream", advanced.LoadTestStreamHandler).Use(protect...).Use(heavyLimit()).RequireRoles(admin...)
+	server.Get("/status", advanced.StatusHandler)
+	server.Get("/ws/status", advanced.StatusSocketHandler)
+	server.Get("/help", advanced.HelpHandler)
+
+	// HTTP/2 sin TLS en el mismo puerto (prior knowledge o "Upgrade: h2c")
+	h2c.Enable(server)
+
+	return server
+}
+
+// Crea el límite de 10 solicitudes por minuto por cliente (principal o IP)
+// de una ruta costosa. Cada llamada crea buckets propios, así que cada ruta
+// tiene su propio límite.
+func heavyLimit() core.Middleware {
+	limit, err := middleware.NewRateLimit(10, time.Minute)
+	if err != nil {
+		slog.Error("Error configuring rate limit", "error", err)
+		os.Exit(1)
+	}
+	limit.Key = middleware.KeyByPrincipal
+	return limit.Middleware()
+}
+
+// Configura la autenticación a partir de variables de entorno:
+//   - AUTH_HTPASSWD: archivo de usuarios para Basic (usuario:sha256$sal$hash)
+//   - AUTH_TOKENS: tokens Bearer estáticos, "token=nombre" separados por comas
+//   - AUTH_JWT_SECRET / AUTH_JWT_AUDIENCE: validación de JWT HS256
+//
+// Devuelve nil si no se configuró ningún mecanismo.
+func newAuth() *middleware.Auth {
+	authenticators := make([]middleware.Authenticator, 0)
+
+	if path := os.Getenv("AUTH_HTPASSWD"); path != "" {
+		basic, err := middleware.LoadHtpasswd(path)
+		if err != nil {
+			slog.Error("Error loading htpasswd file", "path", path, "error", err)
+			os.Exit(1)
+		}
+		authenticators = append(authenticators, basic)
+	}
+
+	if list := os.Getenv("AUTH_TOKENS"); list != "" {
+		tokens := make(map[string]string)
+		for _, entry := range strings.Split(list, ",") {
+			token, name, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			if token == "" {
+				// Entrada vacía ("abc=ops,") o sin token ("=ops")
+				continue
+			}
+			if name == "" {
+				name = "token"
+			}
+			tokens[token] = name
+		}
+		authenticators = append(authenticators, middleware.NewBearerTokens(tokens))
+	}
+
+	if secret := os.Getenv("AUTH_JWT_SECRET"); secret != "" {
+		jwt := middleware.NewJWTAuth([]byte(secret))
+		jwt.Audience = os.Getenv("AUTH_JWT_AUDIENCE")
+		authenticators = append(authenticators, jwt)
+	}
+
+	if len(authenticators) == 0 {
+		return nil
+	}
+
+	return middleware.NewAuth("files", authenticators...)
+}
+
+// Carga el archivo de roles indicado en AUTH_POLICY (líneas "esquema:principal: rol1, rol2").
+// Devuelve nil si no se configuró.
+func newPolicy() *middleware.Policy {
+	path := os.Getenv("AUTH_POLICY")
+	if path == "" {
+		return nil
+	}
+
+	policy, err := middleware.LoadPolicy(path)
+	if err != nil {
+		slog.Error("Error loading policy file", "path", path, "error", err)
+		os.Exit(1)
+	}
+
+	return policy
+}
+
+// Lee una lista de redes separadas por comas de la variable de entorno dada,
+// o usa las redes por defecto si no está definida.
+func parseNetworks(name string, defaults []string) []*net.IPNet {
+	cidrs := defaults
+	if value := os.Getenv(name); value != "" {
+		cidrs = strings.Split(value, ",")
+	}
+
+	networks, err := middleware.ParseCIDRs(cidrs...)
+	if err != nil {
+		slog.Error("Error parsing networks", "variable", name, "error", err)
+		os.Exit(1)
+	}
+
+	return networks
+}
@@ -0,0 +1,273 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Máximo de bytes sin leer del cuerpo que el servidor descarta para reutilizar la conexión.
+// Si quedan más, la conexión se cierra en lugar de leerlos.
+const maxDiscardBytes = 256 << 10
+
+// Error devuelto al descartar un cuerpo que supera maxDiscardBytes.
+var errBodyTooLargeToDiscard = errors.New("unread body too large to discard")
+
+// Lector del cuerpo limitado por Content-Length.
+// A diferencia de io.LimitReader, falla si la conexión termina antes de tiempo.
+type lengthReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (lr *lengthReader) Read(p []byte) (int, error) {
+	if lr.remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > lr.remaining {
+		p = p[:lr.remaining]
+	}
+
+	n, err := lr.reader.Read(p)
+	lr.remaining -= int64(n)
+
+	if errors.Is(err, io.EOF) {
+		if lr.remaining > 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		return n, nil
+	}
+
+	return n, err
+}
+
+// Lector que decodifica un cuerpo con Transfer-Encoding: chunked.
+// Devuelve io.EOF al leer el fragmento final y sus trailers.
+type chunkedReader struct {
+	reader    *bufio.Reader
+	remaining int64 // Bytes pendientes del fragmento actual
+	started   bool  // Si ya se leyó algún fragmento (hay que consumir su CRLF final)
+	err       error // Error persistente (io.EOF al terminar)
+}
+
+func newChunkedReader(reader *bufio.Reader) *chunkedReader {
+	return &chunkedReader{reader: reader}
+}
+
+func (cr *chunkedReader) Read(p []byte) (int, error) {
+	if cr.err != nil {
+		return 0, cr.err
+	}
+
+	if cr.remaining == 0 {
+		cr.err = cr.nextChunk()
+		if cr.err != nil {
+			return 0, cr.err
+		}
+	}
+
+	if int64(len(p)) > cr.remaining {
+		p = p[:cr.remaining]
+	}
+
+	n, err := cr.reader.Read(p)
+	cr.remaining -= int64(n)
+
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		cr.err = err
+	}
+
+	return n, err
+}
+
+// Lee la cabecera del siguiente fragmento.
+// Si es el fragmento final consume los trailers y devuelve io.EOF.
+func (cr *chunkedReader) nextChunk() error {
+	// Cada fragmento de datos termina en CRLF
+	if cr.started {
+		line, err := cr.readLine()
+		if err != nil {
+			return err
+		}
+		if len(line) != 0 {
+			return fmt.Errorf("malformed chunk terminator")
+		}
+	}
+	cr.started = true
+
+	line, err := cr.readLine()
+	if err != nil {
+		return err
+	}
+
+	// Ignora las extensiones del fragmento (";nombre=valor")
+	if i := bytes.IndexByte(line, ';'); i >= 0 {
+		line = line[:i]
+	}
+
+	// Solo dígitos hexadecimales: ParseInt también aceptaría un signo
+	sizeStr := strings.TrimSpace(string(line))
+	if sizeStr == "" || strings.Trim(sizeStr, "0123456789abcdefABCDEF") != "" {
+		return fmt.Errorf("bad chunk size: %q", line)
+	}
+	size, err := strconv.ParseInt(sizeStr, 16, 64)
+	if err != nil || size < 0 {
+		return fmt.Errorf("bad chunk size: %q", line)
+	}
+
+	if size > 0 {
+		cr.remaining = size
+		return nil
+	}
+
+	// Fragmento final: descarta los trailers hasta la línea vacía
+	for {
+		line, err := cr.readLine()
+		if err != nil {
+			return err
+		}
+		if len(line) == 0 {
+			return io.EOF
+		}
+	}
+}
+
+// Lee una línea sin el CRLF final.
+func (cr *chunkedReader) readLine() ([]byte, error) {
+	line, err := cr.reader.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("chunk line too long")
+	}
+	if errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+
+	return line, nil
+}
+
+// Indica si la solicitud usa Transfer-Encoding: chunked.
+func isChunked(request *HttpRequest) bool {
+	return isChunkedEncoding(request.Header("Transfer-Encoding"))
+}
+
+// Indica si la última codificación de Transfer-Encoding es chunked.
+func isChunkedEncoding(encoding string) bool {
+	if encoding == "" {
+		return false
+	}
+
+	codings := strings.Split(encoding, ",")
+	last := strings.TrimSpace(codings[len(codings)-1])
+
+	return strings.EqualFold(last, "chunked")
+}
+
+// Crea el lector del cuerpo de la solicitud según Transfer-Encoding o Content-Length.
+// Devuelve nil si la solicitud no tiene cuerpo. Si el final del cuerpo no se
+// puede determinar devuelve error: leerlo como la siguiente solicitud de la
+// conexión permitiría el request smuggling.
+func newBodyReader(request *HttpRequest, reader *bufio.Reader) (io.Reader, error) {
+	if isChunked(request) {
+		return newChunkedReader(reader), nil
+	}
+
+	// Sin chunked como última codificación el cuerpo llega hasta el cierre de
+	// la conexión (RFC 9112, sección 6.3), lo que no se admite en solicitudes.
+	if _, ok := FindHeader(request.Headers, "Transfer-Encoding"); ok {
+		return nil, fmt.Errorf("unsupported transfer encoding %q", request.Header("Transfer-Encoding"))
+	}
+
+	// Comprueba si existe la cabecera Content-Length para leer el cuerpo
+	if _, ok := FindHeader(request.Headers, "Content-Length"); !ok {
+		return nil, nil
+	}
+
+	contentLength, err := parseContentLength(request.Header("Content-Length"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Si no hay longitud de contenido, no hay cuerpo
+	if contentLength == 0 {
+		return nil, nil
+	}
+
+	return &lengthReader{reader: reader, remaining: contentLength}, nil
+}
+
+// Convierte el valor de Content-Length a entero. Solo admite dígitos: sin
+// signo, espacios ni texto a continuación.
+func parseContentLength(value string) (int64, error) {
+	if value == "" || strings.Trim(value, "0123456789") != "" {
+		return 0, fmt.Errorf("bad content length format %q", value)
+	}
+
+	length, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad content length format %q", value)
+	}
+
+	return length, nil
+}
+
+// Descarta lo que quede sin leer del cuerpo para que la conexión pueda
+// reutilizarse. Devuelve error si no se pudo leer todo.
+func discardBody(body io.Reader) error {
+	if body == nil {
+		return nil
+	}
+
+	n, err := io.CopyN(io.Discard, body, maxDiscardBytes+1)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if n > maxDiscardBytes {
+		return errBodyTooLargeToDiscard
+	}
+
+	return nil
+}
+
+// Escritor que codifica el cuerpo de una respuesta con Transfer-Encoding: chunked.
+// Close escribe el fragmento final.
+type chunkedWriter struct {
+	writer io.Writer
+}
+
+func (cw *chunkedWriter) Write(p []byte) (int, error) {
+	// Un fragmento vacío marcaría el final del cuerpo
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	header := strconv.FormatInt(int64(len(p)), 16) + "\r\n"
+	buffers := net.Buffers{[]byte(header), p, []byte("\r\n")}
+	if _, err := buffers.WriteTo(cw.writer); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (cw *chunkedWriter) Close() error {
+	_, err := io.WriteString(cw.writer, "0\r\n\r\n")
+	return err
+}
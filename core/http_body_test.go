@@ -0,0 +1,245 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestChunkedReader(t *testing.T) {
+	// Arrange
+	input := "4\r\nWiki\r\n6;ext=1\r\npedia \r\nE\r\nin \r\n\r\nchunks.\r\n0\r\nTrailer: x\r\n\r\nNEXT"
+	reader := bufio.NewReader(strings.NewReader(input))
+
+	// Act
+	data, err := io.ReadAll(newChunkedReader(reader))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	if string(data) != "Wikipedia in \r\n\r\nchunks." {
+		t.Errorf("Expected decoded body, not %q", data)
+	}
+
+	rest, _ := io.ReadAll(reader)
+	if string(rest) != "NEXT" {
+		t.Errorf("Expected reader to stop after trailers, not %q", rest)
+	}
+}
+
+var RejectChunkedTests = []string{
+	// bad chunk size
+	"Z\r\nabc\r\n0\r\n\r\n",
+	// missing chunk terminator
+	"3\r\nabcd\r\n0\r\n\r\n",
+	// truncated chunk
+	"a\r\nabc",
+	// missing last chunk
+	"3\r\nabc\r\n",
+}
+
+func TestChunkedReaderReject(t *testing.T) {
+	for i, input := range RejectChunkedTests {
+		t.Run(fmt.Sprintf("TestChunkedReaderReject %d", i), func(t *testing.T) {
+			// Act
+			_, err := io.ReadAll(newChunkedReader(bufio.NewReader(strings.NewReader(input))))
+
+			// Assert
+			if err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+	}
+}
+
+func TestReadRequestChunkedBody(t *testing.T) {
+	// Arrange
+	conn1, conn2 := net.Pipe()
+
+	defer conn2.Close()
+
+	go func() {
+		conn1.Write([]byte("POST /upload HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n2\r\nde\r\n0\r\n\r\n"))
+		conn1.Close()
+	}()
+
+	// Act
+	request, err := ReadRequest(conn2)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	if request.Body != "abcde" {
+		t.Errorf("Expected body to be abcde, not %q", request.Body)
+	}
+}
+
+// readResponses lee n respuestas de la conexión y devuelve sus cuerpos.
+func readResponses(t *testing.T, reader *bufio.Reader, n int) []string {
+	t.Helper()
+
+	bodies := make([]string, 0, n)
+	for range n {
+		length := 0
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Expected no error, %v", err)
+			}
+			if line == "\r\n" {
+				break
+			}
+			fmt.Sscanf(line, "Content-Length: %d", &length)
+		}
+
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			t.Fatalf("Expected no error, %v", err)
+		}
+		bodies = append(bodies, string(body))
+	}
+
+	return bodies
+}
+
+func TestHandleStreamBody(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+
+	server.Post("/upload", func(request *HttpRequest) (*HttpResponse, error) {
+		if request.Body != "" {
+			return BadRequest().Text("body should not be buffered"), nil
+		}
+
+		// Solo lee los primeros 4 bytes; el resto lo descarta el servidor
+		prefix := make([]byte, 4)
+		if _, err := io.ReadFull(request.BodyReader, prefix); err != nil {
+			return nil, err
+		}
+
+		return Ok().Text(string(prefix)), nil
+	}).StreamBody()
+
+	server.Get("/next", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text("next"), nil
+	})
+
+	conn1, conn2 := net.Pipe()
+
+	defer conn1.Close()
+
+	go server.Handle(conn2)
+
+	// Act
+	go func() {
+		fmt.Fprint(conn1, "POST /upload HTTP/1.1\r\nHost: x\r\nContent-Length: 10\r\n\r\n0123456789")
+		fmt.Fprint(conn1, "POST /upload HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nabcde\r\n3\r\nfgh\r\n0\r\n\r\n")
+		fmt.Fprint(conn1, "GET /next HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n")
+	}()
+
+	bodies := readResponses(t, bufio.NewReader(conn1), 3)
+
+	// Assert
+	expected := []string{"0123", "abcd", "next"}
+	for i := range expected {
+		if bodies[i] != expected[i] {
+			t.Errorf("Expected response %d to be %q, not %q", i, expected[i], bodies[i])
+		}
+	}
+}
+
+func TestHandleKeepAliveVersions(t *testing.T) {
+	tests := []struct {
+		request   string
+		keepAlive bool
+		status    string
+	}{
+		{"GET /ok HTTP/1.0\r\n\r\n", false, "HTTP/1.0 200 OK"},
+		{"GET /ok HTTP/1.0\r\nConnection: keep-alive\r\n\r\n", true, "HTTP/1.0 200 OK"},
+		{"GET /ok HTTP/1.1\r\nHost: x\r\n\r\n", true, "HTTP/1.1 200 OK"},
+		{"GET /ok HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n", false, "HTTP/1.1 200 OK"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("TestHandleKeepAliveVersions %d", i), func(t *testing.T) {
+			// Arrange
+			server := NewHttpServer()
+			server.Get("/ok", func(request *HttpRequest) (*HttpResponse, error) {
+				return Ok(), nil
+			})
+
+			conn1, conn2 := net.Pipe()
+			defer conn1.Close()
+
+			done := make(chan struct{})
+			go func() {
+				server.Handle(conn2)
+				close(done)
+			}()
+
+			// Act
+			go fmt.Fprint(conn1, test.request)
+
+			message, _ := bufio.NewReader(conn1).ReadString('\n')
+
+			// Assert
+			if strings.TrimSpace(message) != test.status {
+				t.Errorf("Expected status line %q, not %q", test.status, message)
+			}
+
+			if !test.keepAlive {
+				<-done
+				return
+			}
+
+			select {
+			case <-done:
+				t.Errorf("Expected connection to stay open")
+			default:
+			}
+		})
+	}
+}
+
+var SmugglingTests = []string{
+	"POST /n HTTP/1.1\r\nHost: x\r\nContent-Length: -1\r\n\r\n",
+	"POST /n HTTP/1.1\r\nHost: x\r\nContent-Length: 1abc\r\n\r\n",
+	"POST /n HTTP/1.1\r\nHost: x\r\nContent-Length: +33\r\n\r\n",
+	"GET /n HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: gzip\r\n\r\n",
+}
+
+func TestLenientFramingSmuggling(t *testing.T) {
+	for i, head := range SmugglingTests {
+		t.Run(fmt.Sprintf("TestLenientFramingSmuggling %d", i), func(t *testing.T) {
+			// Arrange: parseo tolerante y un cuerpo que parece otra solicitud
+			server := NewHttpServer()
+			server.Post("/n", func(request *HttpRequest) (*HttpResponse, error) {
+				return Ok().Text("post"), nil
+			})
+			server.Get("/secret", func(request *HttpRequest) (*HttpResponse, error) {
+				return Ok().Text("secret"), nil
+			})
+			conn := dialPipeline(t, server)
+
+			// Act
+			fmt.Fprint(conn, head+"GET /secret HTTP/1.1\r\nHost: x\r\n\r\n")
+			reader := bufio.NewReader(conn)
+
+			// Assert: 400 y la conexión se cierra sin atender el cuerpo como solicitud
+			if status, _ := reader.ReadString('\n'); status != "HTTP/1.1 400 Bad Request\r\n" {
+				t.Fatalf("Expected 400, not %q", status)
+			}
+			readPipelinedBody(t, reader)
+			if rest, _ := io.ReadAll(reader); len(rest) != 0 {
+				t.Errorf("Expected connection closed, not %q", rest)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Lee una respuesta HTTP/1.x (por ejemplo, de un servidor upstream).
+// El cuerpo queda sin leer en BodyReader, delimitado por Content-Length,
+// chunked o el cierre de la conexión (ContentLength -1). method es el de la
+// solicitud: las respuestas a HEAD no tienen cuerpo, pero conservan su
+// Content-Length. Las respuestas intermedias (1xx salvo 101, p. ej.
+// 100 Continue) se descartan y se devuelve la final. Las cabeceras Set-Cookie
+// quedan en SetCookies, una por valor.
+func ReadResponse(reader *bufio.Reader, method string) (*HttpResponse, error) {
+	for {
+		response, err := readResponseHead(reader)
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode >= 200 || response.StatusCode == 101 {
+			return readResponseFraming(reader, method, response)
+		}
+	}
+}
+
+// Lee la línea de estado y las cabeceras de una respuesta.
+func readResponseHead(reader *bufio.Reader) (*HttpResponse, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	// Línea de estado: "HTTP/1.1 200 OK"
+	parts := strings.SplitN(strings.TrimRight(line, "\r\n"), " ", 3)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "HTTP/1.") {
+		return nil, fmt.Errorf("malformed status line: %q", line)
+	}
+	code, err := strconv.Atoi(parts[1])
+	if err != nil || code < 100 || code > 999 {
+		return nil, fmt.Errorf("malformed status code: %q", parts[1])
+	}
+
+	response := NewHttpResponse(code, "", "")
+	response.Version = parts[0]
+	if len(parts) == 3 {
+		response.StatusText = parts[2]
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("malformed header line: %q", line)
+		}
+		value = strings.TrimSpace(value)
+
+		// Set-Cookie no admite combinarse: sus valores pueden contener comas
+		// (Expires) y cada uno debe enviarse en su propia línea.
+		if strings.EqualFold(key, "Set-Cookie") {
+			response.SetCookies = append(response.SetCookies, value)
+			continue
+		}
+
+		// Las cabeceras repetidas se combinan en una lista separada por comas
+		if previous, ok := response.Headers[key]; ok {
+			value = previous + ", " + value
+		}
+		response.Headers[key] = value
+	}
+
+	return response, nil
+}
+
+// Deja el cuerpo de la respuesta en BodyReader según su delimitación.
+func readResponseFraming(reader *bufio.Reader, method string, response *HttpResponse) (*HttpResponse, error) {
+	length := int64(-1)
+	if key, ok := FindHeader(response.Headers, "Content-Length"); ok {
+		var err error
+		if length, err = parseContentLength(response.Headers[key]); err != nil {
+			return nil, err
+		}
+	}
+	chunked := false
+	if key, ok := FindHeader(response.Headers, "Transfer-Encoding"); ok {
+		chunked = isChunkedEncoding(response.Headers[key])
+	}
+
+	switch {
+	case method == "HEAD" || !response.allowsBody():
+		if length >= 0 {
+			response.SetBodyReader(strings.NewReader(""), length)
+		}
+	case chunked:
+		response.SetBodyReader(newChunkedReader(reader), -1)
+	case length >= 0:
+		response.SetBodyReader(&lengthReader{reader: reader, remaining: length}, length)
+	default:
+		// Sin longitud: el cuerpo termina al cerrarse la conexión
+		response.SetBodyReader(reader, -1)
+	}
+	// La longitud la vuelve a calcular WriteTo a partir de BodyReader
+	DeleteHeader(response.Headers, "Transfer-Encoding")
+	DeleteHeader(response.Headers, "Content-Length")
+
+	return response, nil
+}
+
+// Crea un escritor que codifica lo escrito con Transfer-Encoding: chunked.
+// Close escribe el fragmento final, pero no cierra w.
+func NewChunkedWriter(w io.Writer) io.WriteCloser {
+	return &chunkedWriter{writer: w}
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadResponse(t *testing.T) {
+	// Arrange
+	cases := []struct {
+		raw    string
+		method string
+		code   int
+		length int64
+		body   string
+	}{
+		{"HTTP/1.1 200 OK\r\nContent-Length: 5\r\nX-A: 1\r\n\r\nhelloEXTRA", "GET", 200, 5, "hello"},
+		{"HTTP/1.1 201 Created\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n2\r\nde\r\n0\r\n\r\n", "POST", 201, -1, "abcde"},
+		{"HTTP/1.0 200 OK\r\n\r\nuntil close", "GET", 200, -1, "until close"},
+		{"HTTP/1.1 200 OK\r\nContent-Length: 42\r\n\r\n", "HEAD", 200, 42, ""},
+		{"HTTP/1.1 204 No Content\r\n\r\n", "DELETE", 204, 0, ""},
+		// Las respuestas intermedias se descartan, salvo 101
+		{"HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok", "POST", 200, 2, "ok"},
+		{"HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 103 Early Hints\r\nLink: </a.css>\r\n\r\nHTTP/1.1 201 Created\r\nContent-Length: 0\r\n\r\n", "PUT", 201, 0, ""},
+		{"HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n\r\n", "GET", 101, 0, ""},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("TestReadResponse %d", i), func(t *testing.T) {
+			// Act
+			response, err := ReadResponse(bufio.NewReader(strings.NewReader(c.raw)), c.method)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, not %v", err)
+			}
+			if response.StatusCode != c.code || response.BodyLength() != c.length {
+				t.Errorf("Expected %d with length %d, not %d with %d", c.code, c.length, response.StatusCode, response.BodyLength())
+			}
+			body := ""
+			if response.BodyReader != nil && c.method != "HEAD" {
+				data, _ := io.ReadAll(response.BodyReader)
+				body = string(data)
+			}
+			if body != c.body {
+				t.Errorf("Expected body %q, not %q", c.body, body)
+			}
+		})
+	}
+}
+
+func TestReadResponseMalformed(t *testing.T) {
+	for i, raw := range []string{"garbage\r\n\r\n", "HTTP/1.1 abc OK\r\n\r\n", "HTTP/1.1 200 OK\r\nno colon\r\n\r\n", "HTTP/1.1 200 OK\r\nContent-Length: -1\r\n\r\n"} {
+		t.Run(fmt.Sprintf("TestReadResponseMalformed %d", i), func(t *testing.T) {
+			if _, err := ReadResponse(bufio.NewReader(strings.NewReader(raw)), "GET"); err == nil {
+				t.Errorf("Expected error for %q", raw)
+			}
+		})
+	}
+}
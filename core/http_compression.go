@@ -0,0 +1,209 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Configura la compresión de respuestas según Accept-Encoding.
+type Compression struct {
+	MinSize      int64    // Tamaño mínimo del cuerpo para comprimir (los cuerpos de longitud desconocida siempre se comprimen)
+	ContentTypes []string // Tipos comprimibles ("text/*" admite cualquier subtipo)
+	Level        int      // Nivel de compresión (gzip.DefaultCompression, gzip.BestSpeed, ...)
+}
+
+// Crea una configuración con valores razonables: 1 KiB como mínimo y
+// los tipos de texto, JSON, JavaScript, XML y SVG.
+func DefaultCompression() *Compression {
+	return &Compression{
+		MinSize: 1024,
+		ContentTypes: []string{
+			"text/*",
+			"application/json",
+			"application/javascript",
+			"application/xml",
+			"image/svg+xml",
+		},
+		Level: gzip.DefaultCompression,
+	}
+}
+
+// Codificaciones soportadas en orden de preferencia ante empates de q.
+var supportedEncodings = []string{"gzip", "deflate"}
+
+// Elige la codificación a partir de Accept-Encoding teniendo en cuenta los valores q.
+// Devuelve "" si el cliente no acepta ninguna codificación soportada.
+func NegotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(item, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || parsed < 0 || parsed > 1 {
+					parsed = 0
+				}
+				q = parsed
+			}
+		}
+
+		// "x-gzip" es un alias de "gzip"
+		if coding == "x-gzip" {
+			coding = "gzip"
+		}
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supportedEncodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+// Indica si el tipo de contenido está en la lista de tipos comprimibles.
+func (compression *Compression) allows(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	// Los eventos se envían uno a uno y el compresor los retendría en su búfer
+	if mediaType == "" || mediaType == "text/event-stream" {
+		return false
+	}
+
+	for _, allowed := range compression.ContentTypes {
+		allowed = strings.ToLower(allowed)
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Crea el compresor para la codificación elegida.
+func (compression *Compression) newWriter(w io.Writer, encoding string) (io.WriteCloser, error) {
+	if encoding == "deflate" {
+		return zlib.NewWriterLevel(w, compression.Level)
+	}
+	return gzip.NewWriterLevel(w, compression.Level)
+}
+
+// Comprime la respuesta si el cliente lo acepta y la respuesta es elegible
+// (200 OK, tipo comprimible, tamaño mínimo, sin Content-Encoding previo).
+// Las respuestas en memoria se comprimen de inmediato y Content-Length se
+// recalcula al serializarlas; las de streaming se comprimen al enviarse y su
+// longitud pasa a ser desconocida (chunked en HTTP/1.1).
+func (compression *Compression) Apply(request *HttpRequest, response *HttpResponse) *HttpResponse {
+	if response.StatusCode != 200 {
+		return response
+	}
+	if _, ok := response.Headers["Content-Encoding"]; ok {
+		return response
+	}
+	// Los rangos se refieren a la representación sin comprimir
+	if _, ok := response.Headers["Content-Range"]; ok || request.Header("Range") != "" {
+		return response
+	}
+	if !compression.allows(response.Headers["Content-Type"]) {
+		return response
+	}
+	if length := response.BodyLength(); length >= 0 && length < compression.MinSize {
+		return response
+	}
+
+	// La respuesta depende de Accept-Encoding aunque este cliente no comprima
+	response.AddVary("Accept-Encoding")
+
+	encoding := NegotiateEncoding(request.Header("Accept-Encoding"))
+	if encoding == "" {
+		return response
+	}
+
+	if !response.IsStreamed() {
+		var buffer bytes.Buffer
+		writer, err := compression.newWriter(&buffer, encoding)
+		if err != nil {
+			return response
+		}
+		writer.Write(response.BodyBytes())
+		if err := writer.Close(); err != nil {
+			return response
+		}
+
+		// Si comprimir no reduce el tamaño se envía sin comprimir
+		if int64(buffer.Len()) >= response.BodyLength() {
+			return response
+		}
+
+		weakenETag(response)
+		response.SetBodyBytes(buffer.Bytes())
+		response.SetHeader("Content-Encoding", encoding)
+		return response
+	}
+
+	source := response.BodyReader
+	if response.ContentLength >= 0 {
+		source = io.LimitReader(source, response.ContentLength)
+	}
+
+	weakenETag(response)
+
+	response.SetBodyReader(compression.compressReader(response.BodyReader, source, encoding), -1)
+	response.SetHeader("Content-Encoding", encoding)
+	return response
+}
+
+// Convierte en débil un ETag fuerte ya asignado, porque identificaba la
+// representación sin comprimir. Los ETag automáticos se calculan después
+// sobre el cuerpo comprimido.
+func weakenETag(response *HttpResponse) {
+	if etag, ok := response.Headers["ETag"]; ok && !strings.HasPrefix(etag, "W/") {
+		response.SetHeader("ETag", "W/"+etag)
+	}
+}
+
+// Devuelve un lector con el contenido de source comprimido. La compresión se
+// hace en una goroutine a medida que se lee; Close libera el original.
+func (compression *Compression) compressReader(original io.Reader, source io.Reader, encoding string) io.ReadCloser {
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		writer, err := compression.newWriter(pipeWriter, encoding)
+		if err == nil {
+			_, err = io.Copy(writer, source)
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		closeContent(original)
+		pipeWriter.CloseWithError(err)
+	}()
+
+	return pipeReader
+}
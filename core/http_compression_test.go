@@ -0,0 +1,173 @@
+package core
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+var NegotiateEncodingTests = []struct {
+	acceptEncoding string
+	expected       string
+}{
+	{"", ""},
+	{"gzip", "gzip"},
+	{"deflate", "deflate"},
+	{"gzip, deflate", "gzip"},
+	{"gzip;q=0.5, deflate", "deflate"},
+	{"deflate;q=0.5, gzip;q=0.5", "gzip"},
+	{"gzip;q=0, deflate;q=0", ""},
+	{"*", "gzip"},
+	{"*;q=0.1, gzip;q=0", "deflate"},
+	{"br, identity", ""},
+	{"x-gzip", "gzip"},
+	{"GZIP; Q=0.8", "gzip"},
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	for i, test := range NegotiateEncodingTests {
+		t.Run(fmt.Sprintf("TestNegotiateEncoding %d", i), func(t *testing.T) {
+			// Act
+			encoding := NegotiateEncoding(test.acceptEncoding)
+
+			// Assert
+			if encoding != test.expected {
+				t.Errorf("Expected %q for %q, not %q", test.expected, test.acceptEncoding, encoding)
+			}
+		})
+	}
+}
+
+// makeCompressionRequest construye una solicitud GET con Accept-Encoding.
+func makeCompressionRequest(acceptEncoding string) *HttpRequest {
+	return makeRangeRequest(map[string]string{"Accept-Encoding": acceptEncoding})
+}
+
+func TestCompressionBuffered(t *testing.T) {
+	// Arrange
+	compression := DefaultCompression()
+	text := strings.Repeat("compress me ", 200)
+
+	// Act
+	response := compression.Apply(makeCompressionRequest("gzip"), Ok().Text(text))
+
+	// Assert
+	if response.Headers["Content-Encoding"] != "gzip" || response.Headers["Vary"] != "Accept-Encoding" {
+		t.Fatalf("Expected gzip with Vary, not %v", response.Headers)
+	}
+
+	message := response.String()
+	expected := fmt.Sprintf("Content-Length: %d\r\n", len(response.RawBody))
+	if !strings.Contains(message, expected) {
+		t.Errorf("Expected %q in %q", expected, message[:100])
+	}
+
+	reader, err := gzip.NewReader(strings.NewReader(string(response.RawBody)))
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	if string(data) != text {
+		t.Errorf("Expected decompressed body to match")
+	}
+}
+
+func TestCompressionSkipped(t *testing.T) {
+	compression := DefaultCompression()
+	long := strings.Repeat("x", 2048)
+
+	tests := []struct {
+		request  *HttpRequest
+		response *HttpResponse
+		vary     bool
+	}{
+		// Demasiado pequeño
+		{makeCompressionRequest("gzip"), Ok().Text("short"), false},
+		// Tipo no comprimible
+		{makeCompressionRequest("gzip"), Ok().SetContentType("image/png").SetBody(long), false},
+		// Cliente sin compresión: solo Vary
+		{makeCompressionRequest(""), Ok().Text(long), true},
+		// Ya codificado
+		{makeCompressionRequest("gzip"), Ok().Text(long).SetHeader("Content-Encoding", "br"), false},
+		// No es 200
+		{makeCompressionRequest("gzip"), NotFound().Text(long), false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("TestCompressionSkipped %d", i), func(t *testing.T) {
+			encoding := test.response.Headers["Content-Encoding"]
+
+			// Act
+			response := compression.Apply(test.request, test.response)
+
+			// Assert
+			if response.Headers["Content-Encoding"] != encoding {
+				t.Errorf("Expected no compression, not %q", response.Headers["Content-Encoding"])
+			}
+
+			if _, ok := response.Headers["Vary"]; ok != test.vary {
+				t.Errorf("Expected Vary present %v, not %v", test.vary, ok)
+			}
+		})
+	}
+}
+
+func TestCompressionStreamedDeflate(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	server.Compression = DefaultCompression()
+
+	text := strings.Repeat("stream me ", 500)
+	server.Get("/stream", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().SetContentType("text/plain").SetBodyReader(strings.NewReader(text), int64(len(text))).SetHeader("ETag", `"file"`), nil
+	})
+
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+
+	go server.Handle(conn2)
+
+	// Act
+	go fmt.Fprint(conn1, "GET /stream HTTP/1.1\r\nHost: x\r\nAccept-Encoding: deflate\r\nConnection: close\r\n\r\n")
+
+	reader := bufio.NewReader(conn1)
+	headers := map[string]string{}
+	for {
+		line, _ := reader.ReadString('\n')
+		if line == "\r\n" || line == "" {
+			break
+		}
+		key, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+		headers[key] = value
+	}
+
+	// Assert
+	if headers["Transfer-Encoding"] != "chunked" || headers["Content-Encoding"] != "deflate" {
+		t.Fatalf("Expected chunked deflate response, not %v", headers)
+	}
+
+	if _, ok := headers["Content-Length"]; ok {
+		t.Errorf("Expected no Content-Length, not %v", headers)
+	}
+
+	if headers["ETag"] != `W/"file"` {
+		t.Errorf("Expected weak ETag, not %q", headers["ETag"])
+	}
+
+	zreader, err := zlib.NewReader(newChunkedReader(reader))
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	data, err := io.ReadAll(zreader)
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	if string(data) != text {
+		t.Errorf("Expected decompressed body to match")
+	}
+}
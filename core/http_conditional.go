@@ -0,0 +1,175 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Genera un ETag fuerte a partir del contenido del cuerpo.
+// Si weak es true genera un ETag débil (W/"...").
+func ContentETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := "\"" + hex.EncodeToString(sum[:12]) + "\""
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// Genera un ETag a partir de la fecha de modificación y el tamaño de un archivo.
+func FileETag(modTime time.Time, size int64) string {
+	return "\"" + strconv.FormatInt(modTime.UnixNano(), 16) + "-" + strconv.FormatInt(size, 16) + "\""
+}
+
+// Separa una lista de entity-tags ("a", W/"b", ...) respetando las comillas.
+// Devuelve nil si la lista está mal formada.
+func parseETagList(value string) []string {
+	tags := make([]string, 0)
+
+	for {
+		value = strings.TrimLeft(value, " \t,")
+		if value == "" {
+			return tags
+		}
+
+		if value[0] == '*' {
+			tags = append(tags, "*")
+			value = value[1:]
+			continue
+		}
+
+		start := 0
+		if strings.HasPrefix(value, "W/") {
+			start = 2
+		}
+		if len(value) <= start || value[start] != '"' {
+			return nil
+		}
+
+		end := strings.IndexByte(value[start+1:], '"')
+		if end < 0 {
+			return nil
+		}
+		end += start + 2
+
+		tags = append(tags, value[:end])
+		value = value[end:]
+	}
+}
+
+// Compara dos ETag. La comparación fuerte exige que ninguno sea débil;
+// la débil ignora el prefijo W/.
+func etagMatches(a, b string, strong bool) bool {
+	if strong {
+		return !strings.HasPrefix(a, "W/") && !strings.HasPrefix(b, "W/") && a == b
+	}
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// Indica si algún elemento de la lista coincide con etag ("*" coincide si el recurso existe).
+func etagListMatches(list string, etag string, strong bool) bool {
+	for _, tag := range parseETagList(list) {
+		if tag == "*" {
+			if etag != "" {
+				return true
+			}
+			continue
+		}
+		if etag != "" && etagMatches(tag, etag, strong) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evalúa las precondiciones de la solicitud (RFC 9110, sección 13.2.2) para un
+// recurso con el ETag y la fecha de modificación dados (vacíos si se desconocen
+// o si el recurso no existe).
+// Devuelve nil si la solicitud puede continuar, o la respuesta 304 Not Modified
+// o 412 Precondition Failed que debe enviarse en su lugar.
+func CheckPreconditions(request *HttpRequest, etag string, modTime time.Time) *HttpResponse {
+	modTime = modTime.UTC().Truncate(time.Second)
+	safe := request.Method == "GET" || request.Method == "HEAD"
+
+	// 1. If-Match (comparación fuerte); si no está, If-Unmodified-Since
+	if ifMatch := request.Header("If-Match"); ifMatch != "" {
+		if !etagListMatches(ifMatch, etag, true) {
+			return preconditionFailed()
+		}
+	} else if since := request.Header("If-Unmodified-Since"); since != "" && !modTime.IsZero() {
+		if t, err := ParseTime(since); err == nil && modTime.After(t) {
+			return preconditionFailed()
+		}
+	}
+
+	// 2. If-None-Match (comparación débil); si no está, If-Modified-Since solo para GET y HEAD
+	if ifNoneMatch := request.Header("If-None-Match"); ifNoneMatch != "" {
+		if etagListMatches(ifNoneMatch, etag, false) {
+			if safe {
+				return notModified(etag, modTime)
+			}
+			return preconditionFailed()
+		}
+	} else if since := request.Header("If-Modified-Since"); since != "" && safe && !modTime.IsZero() {
+		if t, err := ParseTime(since); err == nil && !modTime.After(t) {
+			return notModified(etag, modTime)
+		}
+	}
+
+	return nil
+}
+
+// Crea una respuesta 304 Not Modified con los validadores del recurso.
+func notModified(etag string, modTime time.Time) *HttpResponse {
+	response := NewHttpResponse(304, "Not Modified", "")
+	if etag != "" {
+		response.SetHeader("ETag", etag)
+	}
+	if !modTime.IsZero() {
+		response.SetHeader("Last-Modified", modTime.Format(TimeFormat))
+	}
+	return response
+}
+
+// Crea una respuesta 412 Precondition Failed.
+func preconditionFailed() *HttpResponse {
+	return NewHttpResponse(412, "Precondition Failed", "").Text("precondition failed")
+}
+
+// Añade un ETag a las respuestas en memoria y aplica las precondiciones de la
+// solicitud. Las respuestas en streaming deben gestionar sus propios validadores
+// (ver ServeContent).
+func applyConditional(request *HttpRequest, response *HttpResponse, weak bool) *HttpResponse {
+	if response.StatusCode != 200 || response.IsStreamed() {
+		return response
+	}
+	if request.Method != "GET" && request.Method != "HEAD" {
+		return response
+	}
+
+	etag, ok := response.Headers["ETag"]
+	if !ok {
+		etag = ContentETag(response.BodyBytes(), weak)
+		response.SetHeader("ETag", etag)
+	}
+
+	var modTime time.Time
+	if lastModified, ok := response.Headers["Last-Modified"]; ok {
+		modTime, _ = ParseTime(lastModified)
+	}
+
+	if conditional := CheckPreconditions(request, etag, modTime); conditional != nil {
+		// Conserva las cabeceras de caché de la respuesta original
+		for _, key := range []string{"Cache-Control", "Expires", "Vary", "Content-Location"} {
+			if value, ok := response.Headers[key]; ok {
+				conditional.SetHeader(key, value)
+			}
+		}
+		return conditional
+	}
+
+	return response
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseETagList(t *testing.T) {
+	// Act
+	tags := parseETagList(`"a", W/"b,c" ,*,"d"`)
+
+	// Assert
+	expected := []string{`"a"`, `W/"b,c"`, "*", `"d"`}
+
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected %v, not %v", expected, tags)
+	}
+
+	if parseETagList(`"unterminated`) != nil {
+		t.Errorf("Expected malformed list to be rejected")
+	}
+}
+
+var CheckPreconditionsTests = []struct {
+	method  string
+	headers map[string]string
+	status  int // 0 si la solicitud debe continuar
+}{
+	{"GET", map[string]string{}, 0},
+	{"GET", map[string]string{"If-None-Match": `"v1"`}, 304},
+	{"GET", map[string]string{"If-None-Match": `W/"v1"`}, 304},
+	{"GET", map[string]string{"If-None-Match": `"v2", "v3"`}, 0},
+	{"GET", map[string]string{"If-None-Match": `*`}, 304},
+	{"PUT", map[string]string{"If-None-Match": `*`}, 412},
+	{"GET", map[string]string{"If-Match": `"v1"`}, 0},
+	{"GET", map[string]string{"If-Match": `W/"v1"`}, 412},
+	{"DELETE", map[string]string{"If-Match": `"v2"`}, 412},
+	{"DELETE", map[string]string{"If-Match": `*`}, 0},
+	{"GET", map[string]string{"If-Modified-Since": "Sun, 08 Jun 2025 10:00:00 GMT"}, 304},
+	{"GET", map[string]string{"If-Modified-Since": "Sun, 08 Jun 2025 09:59:59 GMT"}, 0},
+	{"GET", map[string]string{"If-None-Match": `"v2"`, "If-Modified-Since": "Sun, 08 Jun 2025 10:00:00 GMT"}, 0},
+	{"POST", map[string]string{"If-Modified-Since": "Sun, 08 Jun 2025 10:00:00 GMT"}, 0},
+	{"DELETE", map[string]string{"If-Unmodified-Since": "Sun, 08 Jun 2025 09:00:00 GMT"}, 412},
+	{"DELETE", map[string]string{"If-Unmodified-Since": "Sun, 08 Jun 2025 11:00:00 GMT"}, 0},
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	modTime := time.Date(2025, 6, 8, 10, 0, 0, 500, time.UTC)
+
+	for i, test := range CheckPreconditionsTests {
+		t.Run(fmt.Sprintf("TestCheckPreconditions %d", i), func(t *testing.T) {
+			// Arrange
+			request := makeRangeRequest(test.headers)
+			request.Method = test.method
+
+			// Act
+			response := CheckPreconditions(request, `"v1"`, modTime)
+
+			// Assert
+			status := 0
+			if response != nil {
+				status = response.StatusCode
+			}
+
+			if status != test.status {
+				t.Errorf("Expected status %d, not %d", test.status, status)
+			}
+		})
+	}
+}
+
+func TestCheckPreconditionsMissingResource(t *testing.T) {
+	// Arrange
+	request := makeRangeRequest(map[string]string{"If-Match": "*"})
+	request.Method = "DELETE"
+
+	// Act
+	response := CheckPreconditions(request, "", time.Time{})
+
+	// Assert
+	if response == nil || response.StatusCode != 412 {
+		t.Errorf("Expected 412 for If-Match: * on a missing resource, not %v", response)
+	}
+}
+
+func TestServeContentNotModified(t *testing.T) {
+	// Arrange
+	modTime := time.Date(2025, 6, 8, 10, 0, 0, 0, time.UTC)
+	etag := FileETag(modTime, 10)
+
+	// Act
+	response := ServeContent(makeRangeRequest(map[string]string{"If-None-Match": etag}), "text/plain", modTime, strings.NewReader("0123456789"), 10)
+
+	// Assert
+	if response.StatusCode != 304 {
+		t.Fatalf("Expected status code to be 304, not %d", response.StatusCode)
+	}
+
+	expected := "HTTP/1.0 304 Not Modified\r\nETag: " + etag + "\r\nLast-Modified: Sun, 08 Jun 2025 10:00:00 GMT\r\n\r\n"
+
+	if response.String() != expected {
+		t.Errorf("Expected message to be %q, not %q", expected, response.String())
+	}
+}
+
+func TestHandleAutoETag(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	server.Get("/text", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text("Text"), nil
+	})
+
+	etag := ContentETag([]byte("Text"), false)
+
+	tests := []struct {
+		request string
+		status  string
+	}{
+		{"GET /text HTTP/1.0\r\n\r\n", "HTTP/1.0 200 OK"},
+		{"GET /text HTTP/1.0\r\nIf-None-Match: " + etag + "\r\n\r\n", "HTTP/1.0 304 Not Modified"},
+		{"GET /text HTTP/1.0\r\nIf-Match: \"other\"\r\n\r\n", "HTTP/1.0 412 Precondition Failed"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("TestHandleAutoETag %d", i), func(t *testing.T) {
+			conn1, conn2 := net.Pipe()
+			defer conn1.Close()
+
+			go server.Handle(conn2)
+
+			// Act
+			go fmt.Fprint(conn1, test.request)
+
+			reader := bufio.NewReader(conn1)
+			status, _ := reader.ReadString('\n')
+			head := ""
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == "\r\n" {
+					break
+				}
+				head += line
+			}
+
+			// Assert
+			if strings.TrimSpace(status) != test.status {
+				t.Errorf("Expected status line %q, not %q", test.status, status)
+			}
+
+			if !strings.Contains(head, "ETag: "+etag) && test.status != "HTTP/1.0 412 Precondition Failed" {
+				t.Errorf("Expected ETag %s, not %q", etag, head)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Servidor configurado como en main.go, con rutas mínimas para las pruebas de conformidad.
+func conformanceServer(t *testing.T) string {
+	t.Helper()
+	server := NewHttpServer()
+	server.StrictParsing = true
+	server.Pipelining = 4
+	hello := func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text("hello"), nil
+	}
+	server.Get("/hello", hello)
+	server.Head("/hello", hello)
+	server.Post("/echo", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(request.Body), nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+	return ln.Addr().String()
+}
+
+// Respuesta leída de la conexión.
+type conformanceResponse struct {
+	version string
+	status  int
+	headers map[string]string
+	body    string
+}
+
+// Lee una respuesta; el cuerpo se delimita con Content-Length salvo en HEAD.
+func readConformanceResponse(reader *bufio.Reader, head bool) (*conformanceResponse, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	start := strings.SplitN(strings.TrimSuffix(line, "\r\n"), " ", 3)
+	if len(start) < 2 {
+		return nil, fmt.Errorf("bad status line %q", line)
+	}
+	status, err := strconv.Atoi(start[1])
+	if err != nil {
+		return nil, err
+	}
+
+	response := &conformanceResponse{version: start[0], status: status, headers: make(map[string]string)}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if line == "\r\n" {
+			break
+		}
+		name, value, _ := strings.Cut(strings.TrimSuffix(line, "\r\n"), ": ")
+		response.headers[name] = value
+	}
+
+	if head || response.headers["Content-Length"] == "" {
+		return response, nil
+	}
+	length, err := strconv.Atoi(response.headers["Content-Length"])
+	if err != nil {
+		return nil, err
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, err
+	}
+	response.body = string(body)
+	return response, nil
+}
+
+var ConformanceTests = []struct {
+	name    string
+	request string
+	status  int
+	headers map[string]string // Un valor vacío indica que la cabecera no debe estar
+	body    string
+}{
+	// Solicitudes válidas
+	{"get", "GET /hello HTTP/1.1\r\nHost: x\r\n\r\n", 200, map[string]string{"Content-Length": "5", "Connection": ""}, "hello"},
+	{"http/1.0 without host", "GET /hello HTTP/1.0\r\n\r\n", 200, map[string]string{"Connection": ""}, "hello"},
+	{"http/1.0 keep-alive", "GET /hello HTTP/1.0\r\nConnection: keep-alive\r\n\r\n", 200, map[string]string{"Connection": "keep-alive"}, "hello"},
+	{"connection close", "GET /hello HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n", 200, map[string]string{"Connection": "close"}, "hello"},
+	{"head", "HEAD /hello HTTP/1.1\r\nHost: x\r\n\r\n", 200, map[string]string{"Content-Length": "5"}, ""},
+	{"absolute form", "GET http://x/hello HTTP/1.1\r\nHost: x\r\n\r\n", 200, nil, "hello"},
+	{"leading empty line", "\r\nGET /hello HTTP/1.1\r\nHost: x\r\n\r\n", 200, nil, "hello"},
+	{"case-insensitive header names", "GET /hello HTTP/1.1\r\nhOST: x\r\n\r\n", 200, nil, "hello"},
+	{"content length", "POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 4\r\n\r\nWiki", 200, map[string]string{"Content-Length": "4"}, "Wiki"},
+	{"chunked with extensions and trailers", "POST /echo HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n4;ext=1\r\nWiki\r\n5\r\npedia\r\n0\r\nX-Trailer: 1\r\n\r\n", 200, nil, "Wikipedia"},
+	// Rutas y métodos
+	{"unknown route", "GET /missing HTTP/1.1\r\nHost: x\r\n\r\n", 404, nil, "404 Not Found"},
+	{"method not routed", "DELETE /hello HTTP/1.1\r\nHost: x\r\n\r\n", 400, nil, "Bad method"},
+	// Solicitudes mal formadas
+	{"http/1.1 without host", "GET /hello HTTP/1.1\r\n\r\n", 400, nil, "missing host header"},
+	{"duplicate host", "GET /hello HTTP/1.1\r\nHost: a\r\nHost: b\r\n\r\n", 400, nil, ""},
+	{"unknown method", "BREW /hello HTTP/1.1\r\nHost: x\r\n\r\n", 400, nil, ""},
+	{"lowercase method", "get /hello HTTP/1.1\r\nHost: x\r\n\r\n", 400, nil, ""},
+	{"unsupported version", "GET /hello HTTP/2.0\r\nHost: x\r\n\r\n", 400, nil, ""},
+	{"bare lf", "GET /hello HTTP/1.1\nHost: x\n\n", 400, nil, ""},
+	{"space before colon", "GET /hello HTTP/1.1\r\nHost : x\r\n\r\n", 400, nil, ""},
+	{"obs-fold", "GET /hello HTTP/1.1\r\nHost: x\r\nX-Folded: a\r\n b\r\n\r\n", 400, nil, ""},
+	{"post without length", "POST /echo HTTP/1.1\r\nHost: x\r\n\r\n", 400, nil, ""},
+	{"content length and chunked", "POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 4\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n", 400, nil, ""},
+	{"unsupported transfer coding", "POST /echo HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: gzip\r\n\r\n", 400, nil, ""},
+	{"bad chunk size", "POST /echo HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n", 400, map[string]string{"Connection": "close"}, ""},
+}
+
+func TestConformance(t *testing.T) {
+	address := conformanceServer(t)
+
+	for i, test := range ConformanceTests {
+		t.Run(fmt.Sprintf("TestConformance %d %s", i, test.name), func(t *testing.T) {
+			// Arrange
+			conn, err := net.Dial("tcp", address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			// Act
+			fmt.Fprint(conn, test.request)
+			response, err := readConformanceResponse(bufio.NewReader(conn), strings.HasPrefix(test.request, "HEAD"))
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected a response, not %v", err)
+			}
+			if response.status != test.status {
+				t.Errorf("Expected status %d, not %d", test.status, response.status)
+			}
+			version := "HTTP/1.1"
+			if strings.Contains(test.request, " HTTP/1.0\r\n") {
+				version = "HTTP/1.0"
+			}
+			if test.status < 400 && response.version != version {
+				t.Errorf("Expected %s, not %s", version, response.version)
+			}
+			for name, expected := range test.headers {
+				if actual := response.headers[name]; actual != expected {
+					t.Errorf("Expected %s to be %q, not %q", name, expected, actual)
+				}
+			}
+			if test.body != "" && !strings.Contains(response.body, test.body) {
+				t.Errorf("Expected body to contain %q, not %q", test.body, response.body)
+			}
+			if strings.HasPrefix(test.request, "HEAD") && response.body != "" {
+				t.Errorf("Expected no body in HEAD response, not %q", response.body)
+			}
+		})
+	}
+}
+
+func TestConformancePersistentConnection(t *testing.T) {
+	// Arrange
+	conn, err := net.Dial("tcp", conformanceServer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	// Act: en pipeline y con un CRLF de más tras el cuerpo del POST
+	fmt.Fprint(conn, "POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 2\r\n\r\nab\r\n"+
+		"HEAD /hello HTTP/1.1\r\nHost: x\r\n\r\n"+
+		"GET /hello HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n")
+
+	// Assert
+	for _, expected := range []string{"ab", "", "hello"} {
+		response, err := readConformanceResponse(reader, expected == "")
+		if err != nil {
+			t.Fatalf("Expected a response, not %v", err)
+		}
+		if response.status != 200 || response.body != expected {
+			t.Errorf("Expected 200 with %q, not %d with %q", expected, response.status, response.body)
+		}
+	}
+	if rest, _ := io.ReadAll(reader); len(rest) != 0 {
+		t.Errorf("Expected connection closed, not %q", rest)
+	}
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Respuesta intermedia con la que el servidor pide el cuerpo (RFC 9110, sección 10.1.1).
+const continueResponse = "HTTP/1.1 100 Continue\r\n\r\n"
+
+// Lector del cuerpo de una solicitud con "Expect: 100-continue". Envía
+// 100 Continue la primera vez que se lee, así el cliente no manda el cuerpo
+// si la solicitud se rechaza antes (401, 403, 404, ...).
+type continueReader struct {
+	reader io.Reader
+	writer io.Writer
+	sent   bool
+	err    error
+}
+
+func (cr *continueReader) Read(p []byte) (int, error) {
+	if !cr.sent {
+		cr.sent = true
+		_, cr.err = io.WriteString(cr.writer, continueResponse)
+	}
+	if cr.err != nil {
+		return 0, cr.err
+	}
+	return cr.reader.Read(p)
+}
+
+// Devuelve el lector del cuerpo de la solicitud, limitado a MaxBodySize.
+// Si el cuerpo no puede aceptarse por sus cabeceras devuelve la respuesta
+// de error: 400 si Content-Length no es válido, 413 si supera el límite y
+// 417 si Expect pide algo distinto de 100-continue.
+func (server *HttpServer) requestBody(request *HttpRequest, reader *bufio.Reader) (io.Reader, *HttpResponse) {
+	// HTTP/1.0 no conoce Expect y la cabecera se ignora.
+	if expect := request.Header("Expect"); expect != "" && request.Version == "HTTP/1.1" && !strings.EqualFold(expect, "100-continue") {
+		return nil, NewHttpResponse(417, "Expectation Failed", "").Text(fmt.Sprintf("unsupported expectation: %s", expect))
+	}
+
+	body, err := newBodyReader(request, reader)
+	if err != nil {
+		return nil, BadRequest().Text(err.Error())
+	}
+	if body == nil || server.MaxBodySize <= 0 {
+		return body, nil
+	}
+
+	// Con Content-Length el exceso se detecta antes de leer el cuerpo.
+	if length, ok := body.(*lengthReader); ok && length.remaining > server.MaxBodySize {
+		return nil, NewHttpResponse(413, "Payload Too Large", "").Text(ErrBodyTooLarge.Error())
+	}
+	return &capReader{reader: body, remaining: server.MaxBodySize}, nil
+}
+
+// Indica si la solicitud espera 100 Continue antes de enviar el cuerpo.
+func expectsContinue(request *HttpRequest) bool {
+	return request.Version == "HTTP/1.1" && strings.EqualFold(request.Header("Expect"), "100-continue")
+}
+
+// Lee el cuerpo que quedó pendiente hasta que el manejador lo necesita.
+// Devuelve la respuesta de error si no es válido.
+func (request *HttpRequest) loadBody() *HttpResponse {
+	if request.pendingBody == nil {
+		return nil
+	}
+	load := request.pendingBody
+	request.pendingBody = nil
+	return load()
+}
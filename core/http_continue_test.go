@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Servidor con un POST /upload que devuelve el cuerpo y otro protegido que responde 401.
+func continueServer(t *testing.T) (*bufio.Reader, net.Conn) {
+	t.Helper()
+	server := NewHttpServer()
+	server.MaxBodySize = 16
+	server.Post("/upload", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(strings.ToUpper(request.Body)), nil
+	})
+	server.Post("/private", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(request.Body), nil
+	}).Use(func(next Handle) Handle {
+		return func(request *HttpRequest) (*HttpResponse, error) {
+			return Unauthorized().Text("unauthorized"), nil
+		}
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return bufio.NewReader(conn), conn
+}
+
+func TestExpectContinue(t *testing.T) {
+	// Arrange
+	reader, conn := continueServer(t)
+
+	// Act: el cliente envía solo la cabecera y espera 100 Continue
+	fmt.Fprint(conn, "POST /upload HTTP/1.1\r\nHost: x\r\nExpect: 100-continue\r\nContent-Length: 4\r\n\r\n")
+	interim, _ := reader.ReadString('\n')
+	reader.ReadString('\n')
+	fmt.Fprint(conn, "data")
+
+	// Assert
+	if interim != "HTTP/1.1 100 Continue\r\n" {
+		t.Fatalf("Expected 100 Continue, not %q", interim)
+	}
+	status, _ := reader.ReadString('\n')
+	if status != "HTTP/1.1 200 OK\r\n" {
+		t.Fatalf("Expected 200 after the body, not %q", status)
+	}
+	if body := readPipelinedBody(t, reader); body != "DATA" {
+		t.Errorf("Expected DATA, not %q", body)
+	}
+
+	// La conexión sigue abierta
+	fmt.Fprint(conn, "POST /upload HTTP/1.1\r\nHost: x\r\nContent-Length: 2\r\n\r\nok")
+	reader.ReadString('\n')
+	if body := readPipelinedBody(t, reader); body != "OK" {
+		t.Errorf("Expected OK on the same connection, not %q", body)
+	}
+}
+
+var RejectExpectContinueTests = []struct {
+	request string
+	status  string
+}{
+	// El middleware rechaza antes de que el manejador lea el cuerpo
+	{"POST /private HTTP/1.1\r\nHost: x\r\nExpect: 100-continue\r\nContent-Length: 4\r\n\r\n", "HTTP/1.1 401 Unauthorized\r\n"},
+	// Ruta inexistente
+	{"POST /missing HTTP/1.1\r\nHost: x\r\nExpect: 100-continue\r\nContent-Length: 4\r\n\r\n", "HTTP/1.1 404 Not Found\r\n"},
+	// Content-Length mayor que MaxBodySize
+	{"POST /upload HTTP/1.1\r\nHost: x\r\nExpect: 100-continue\r\nContent-Length: 100\r\n\r\n", "HTTP/1.1 413 Payload Too Large\r\n"},
+	// Expectativa desconocida
+	{"POST /upload HTTP/1.1\r\nHost: x\r\nExpect: something\r\nContent-Length: 4\r\n\r\n", "HTTP/1.1 417 Expectation Failed\r\n"},
+}
+
+func TestExpectContinueRejected(t *testing.T) {
+	for i, test := range RejectExpectContinueTests {
+		t.Run(fmt.Sprintf("TestExpectContinueRejected %d", i), func(t *testing.T) {
+			// Arrange
+			reader, conn := continueServer(t)
+
+			// Act
+			fmt.Fprint(conn, test.request)
+
+			// Assert: la respuesta final llega sin 100 Continue y cierra la conexión
+			status, _ := reader.ReadString('\n')
+			if status != test.status {
+				t.Fatalf("Expected %q, not %q", test.status, status)
+			}
+			rest, _ := io.ReadAll(reader)
+			if !strings.Contains(string(rest), "Connection: close") {
+				t.Errorf("Expected Connection: close, not %q", rest)
+			}
+		})
+	}
+}
+
+func TestMaxBodySizeChunked(t *testing.T) {
+	// Arrange
+	reader, conn := continueServer(t)
+
+	// Act: sin Content-Length el límite se comprueba al leer
+	fmt.Fprint(conn, "POST /upload HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n14\r\n01234567890123456789\r\n0\r\n\r\n")
+
+	// Assert
+	if status, _ := reader.ReadString('\n'); status != "HTTP/1.1 413 Payload Too Large\r\n" {
+		t.Errorf("Expected 413, not %q", status)
+	}
+}
+
+func TestExpectIgnoredInHTTP10(t *testing.T) {
+	// Arrange
+	reader, conn := continueServer(t)
+
+	// Act
+	fmt.Fprint(conn, "POST /upload HTTP/1.0\r\nExpect: 100-continue\r\nContent-Length: 4\r\n\r\ndata")
+
+	// Assert
+	if status, _ := reader.ReadString('\n'); status != "HTTP/1.0 200 OK\r\n" {
+		t.Fatalf("Expected 200 without 100 Continue, not %q", status)
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Límite por defecto del tamaño de un cuerpo descomprimido (10 MiB).
+const DefaultMaxDecompressedSize = 10 << 20
+
+// Error devuelto cuando la solicitud usa un Content-Encoding no soportado.
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// Error devuelto cuando el cuerpo descomprimido supera el límite permitido.
+var ErrBodyTooLarge = errors.New("body too large")
+
+// Devuelve las codificaciones de Content-Encoding en el orden en que se aplicaron,
+// sin "identity". Falla si alguna no está soportada.
+func contentEncodings(request *HttpRequest) ([]string, error) {
+	header := request.Header("Content-Encoding")
+	if header == "" {
+		return nil, nil
+	}
+
+	encodings := make([]string, 0)
+	for _, encoding := range strings.Split(header, ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		switch encoding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip", "deflate":
+			encodings = append(encodings, encoding)
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+		}
+	}
+
+	return encodings, nil
+}
+
+// Envuelve el cuerpo para descomprimirlo según Content-Encoding, sin superar
+// limit bytes descomprimidos. Los descompresores se crean en la primera
+// lectura, de modo que no se lee nada de la conexión hasta que se pide.
+// Si el cuerpo está codificado, elimina Content-Encoding y Content-Length de
+// las cabeceras, que ya no describen el cuerpo que verá el manejador.
+func decodeBody(request *HttpRequest, body io.Reader, limit int64) (io.Reader, error) {
+	encodings, err := contentEncodings(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if body == nil || len(encodings) == 0 {
+		return body, nil
+	}
+
+	DeleteHeader(request.Headers, "Content-Encoding")
+	DeleteHeader(request.Headers, "Content-Length")
+
+	decoded := &lazyReader{init: func() (io.Reader, error) {
+		reader := body
+		// Las codificaciones se deshacen en orden inverso al que se aplicaron
+		for i := len(encodings) - 1; i >= 0; i-- {
+			var err error
+			if encodings[i] == "deflate" {
+				reader, err = zlib.NewReader(reader)
+			} else {
+				reader, err = gzip.NewReader(reader)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("bad %s body: %w", encodings[i], err)
+			}
+		}
+		return reader, nil
+	}}
+
+	return &capReader{reader: decoded, remaining: limit}, nil
+}
+
+// Lector que se inicializa en la primera lectura.
+type lazyReader struct {
+	init   func() (io.Reader, error)
+	reader io.Reader
+	err    error
+}
+
+func (lr *lazyReader) Read(p []byte) (int, error) {
+	if lr.reader == nil && lr.err == nil {
+		lr.reader, lr.err = lr.init()
+	}
+	if lr.err != nil {
+		return 0, lr.err
+	}
+	return lr.reader.Read(p)
+}
+
+// Lector que falla con ErrBodyTooLarge si se leen más de remaining bytes.
+type capReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (cr *capReader) Read(p []byte) (int, error) {
+	if cr.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+
+	// Lee un byte más del límite para detectar si se supera
+	if int64(len(p)) > cr.remaining+1 {
+		p = p[:cr.remaining+1]
+	}
+
+	n, err := cr.reader.Read(p)
+	cr.remaining -= int64(n)
+	if cr.remaining < 0 {
+		return n + int(cr.remaining), ErrBodyTooLarge
+	}
+
+	return n, err
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// gzipBytes comprime data con gzip.
+func gzipBytes(data []byte) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	writer.Write(data)
+	writer.Close()
+	return buffer.Bytes()
+}
+
+// zlibBytes comprime data con zlib (Content-Encoding: deflate).
+func zlibBytes(data []byte) []byte {
+	var buffer bytes.Buffer
+	writer := zlib.NewWriter(&buffer)
+	writer.Write(data)
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func TestReadRequestDecompressesBody(t *testing.T) {
+	tests := []struct {
+		encoding string
+		body     []byte
+	}{
+		{"gzip", gzipBytes([]byte("hello gzip"))},
+		{"deflate", zlibBytes([]byte("hello gzip"))},
+		{"identity", []byte("hello gzip")},
+		{"deflate, gzip", gzipBytes(zlibBytes([]byte("hello gzip")))},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("TestReadRequestDecompressesBody %d", i), func(t *testing.T) {
+			// Arrange
+			conn1, conn2 := net.Pipe()
+			defer conn2.Close()
+
+			go func() {
+				fmt.Fprintf(conn1, "POST / HTTP/1.0\r\nContent-Encoding: %s\r\nContent-Length: %d\r\n\r\n", test.encoding, len(test.body))
+				conn1.Write(test.body)
+				conn1.Close()
+			}()
+
+			// Act
+			request, err := ReadRequest(conn2)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, %v", err)
+			}
+
+			if request.Body != "hello gzip" {
+				t.Errorf("Expected decompressed body, not %q", request.Body)
+			}
+		})
+	}
+}
+
+func TestReadRequestDecompressionLowercaseHeaders(t *testing.T) {
+	// Arrange
+	body := gzipBytes([]byte("hello gzip"))
+	raw := fmt.Sprintf("POST / HTTP/1.0\r\ncontent-encoding: gzip\r\ncontent-length: %d\r\n\r\n%s", len(body), body)
+
+	// Act
+	request, err := NewRequestReader(strings.NewReader(raw)).ReadRequest()
+
+	// Assert: las cabeceras ya no describen el cuerpo descomprimido
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	if request.Body != "hello gzip" {
+		t.Errorf("Expected decompressed body, not %q", request.Body)
+	}
+	if request.Header("Content-Encoding") != "" || request.Header("Content-Length") != "" {
+		t.Errorf("Expected Content-Encoding and Content-Length removed, not %v", request.Headers)
+	}
+}
+
+func TestDecodeBodyLimit(t *testing.T) {
+	// Arrange
+	bomb := gzipBytes(bytes.Repeat([]byte{0}, 1<<20))
+	request := makeRangeRequest(map[string]string{"Content-Encoding": "gzip"})
+
+	// Act
+	body, err := decodeBody(request, bytes.NewReader(bomb), 1000)
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	data, err := io.ReadAll(body)
+
+	// Assert
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge, not %v", err)
+	}
+
+	if len(data) != 1000 {
+		t.Errorf("Expected to read exactly the limit, not %d bytes", len(data))
+	}
+}
+
+func TestDecodeBodyUnsupported(t *testing.T) {
+	// Arrange
+	request := makeRangeRequest(map[string]string{"Content-Encoding": "br"})
+
+	// Act
+	_, err := decodeBody(request, strings.NewReader("x"), 1000)
+
+	// Assert
+	if !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("Expected ErrUnsupportedEncoding, not %v", err)
+	}
+}
+
+func TestHandleDecompression(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	server.MaxDecompressedSize = 64
+
+	server.Post("/echo", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(request.Body), nil
+	})
+
+	tests := []struct {
+		encoding string
+		body     []byte
+		status   string
+	}{
+		{"gzip", gzipBytes([]byte("hola")), "HTTP/1.0 200 OK"},
+		{"br", []byte("hola"), "HTTP/1.0 415 Unsupported Media Type"},
+		{"gzip", gzipBytes(bytes.Repeat([]byte("a"), 65)), "HTTP/1.0 413 Payload Too Large"},
+		{"gzip", []byte("not gzip"), "HTTP/1.0 400 Bad Request"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("TestHandleDecompression %d", i), func(t *testing.T) {
+			conn1, conn2 := net.Pipe()
+			defer conn1.Close()
+
+			go server.Handle(conn2)
+
+			// Act
+			go func() {
+				fmt.Fprintf(conn1, "POST /echo HTTP/1.0\r\nContent-Encoding: %s\r\nContent-Length: %d\r\n\r\n", test.encoding, len(test.body))
+				conn1.Write(test.body)
+			}()
+
+			status, _ := bufio.NewReader(conn1).ReadString('\n')
+
+			// Assert
+			if strings.TrimSpace(status) != test.status {
+				t.Errorf("Expected status line %q, not %q", test.status, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Firma de la cabecera binaria del protocolo PROXY v2.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+var errProxyHeader = errors.New("invalid proxy protocol header")
+
+// Longitud máxima de una cabecera PROXY v1, incluido el CRLF.
+const maxProxyV1Length = 107
+
+// Indica si la dirección ("ip:puerto" o IP) pertenece a un proxy de confianza.
+func (server *HttpServer) trustedProxy(addr string) bool {
+	ip := parseHop(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range server.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rellena RealIP, Scheme y Host de la solicitud. Las cabeceras Forwarded y
+// X-Forwarded-* solo se tienen en cuenta si la conexión viene de un proxy
+// de confianza; se recorren de derecha a izquierda saltando los proxies de
+// confianza y el primer salto que no lo es se toma como el cliente.
+func (server *HttpServer) applyForwarded(request *HttpRequest) {
+	request.Scheme = "http"
+	request.Host = request.Header("Host")
+
+	if !server.trustedProxy(request.RemoteAddr) {
+		return
+	}
+
+	hops := parseForwarded(request.Header("Forwarded"))
+	if len(hops) == 0 {
+		hops = parseXForwarded(request)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := hops[i]
+		ip := parseHop(hop.For)
+		if ip == nil {
+			// Salto desconocido u ofuscado: no se puede seguir la cadena
+			return
+		}
+
+		request.RealIP = ip.String()
+		if hop.Proto == "http" || hop.Proto == "https" {
+			request.Scheme = hop.Proto
+		}
+		if hop.Host != "" {
+			request.Host = hop.Host
+		}
+
+		if !server.trustedProxy(hop.For) {
+			return
+		}
+	}
+}
+
+// Un salto de la cadena de proxies.
+type forwardedHop struct {
+	For   string // Dirección del cliente del salto
+	Proto string // Esquema usado por el cliente ("http" o "https")
+	Host  string // Host solicitado por el cliente
+}
+
+// Parsea la cabecera Forwarded (RFC 7239):
+//
+//	Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8::1]:4711"
+func parseForwarded(header string) []forwardedHop {
+	hops := make([]forwardedHop, 0)
+	if header == "" {
+		return hops
+	}
+
+	for _, element := range strings.Split(header, ",") {
+		hop := forwardedHop{}
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				continue
+			}
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+
+			switch strings.ToLower(key) {
+			case "for":
+				hop.For = value
+			case "proto":
+				hop.Proto = strings.ToLower(value)
+			case "host":
+				hop.Host = value
+			}
+		}
+		hops = append(hops, hop)
+	}
+
+	return hops
+}
+
+// Construye los saltos a partir de X-Forwarded-For, X-Forwarded-Proto y
+// X-Forwarded-Host. Proto y Host se asocian por posición si las listas tienen
+// la misma longitud; si no, se usa el último valor (añadido por el proxy más cercano).
+func parseXForwarded(request *HttpRequest) []forwardedHop {
+	hops := make([]forwardedHop, 0)
+	for _, addr := range strings.Split(request.Header("X-Forwarded-For"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			hops = append(hops, forwardedHop{For: addr})
+		}
+	}
+
+	assign := func(header string, set func(hop *forwardedHop, value string)) {
+		values := make([]string, 0)
+		for _, value := range strings.Split(header, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		for i := range hops {
+			switch {
+			case len(values) == len(hops):
+				set(&hops[i], values[i])
+			case len(values) > 0:
+				set(&hops[i], values[len(values)-1])
+			}
+		}
+	}
+	assign(request.Header("X-Forwarded-Proto"), func(hop *forwardedHop, value string) { hop.Proto = strings.ToLower(value) })
+	assign(request.Header("X-Forwarded-Host"), func(hop *forwardedHop, value string) { hop.Host = value })
+
+	return hops
+}
+
+// Extrae la IP de un salto: "1.2.3.4", "1.2.3.4:80", "[::1]:80" o "::1".
+func parseHop(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
+
+// Lee la cabecera del protocolo PROXY (v1 de texto o v2 binaria) al inicio de
+// la conexión. Devuelve la dirección de origen ("ip:puerto"), o una cadena
+// vacía si el proxy envió una conexión propia (LOCAL/UNKNOWN).
+func readProxyHeader(reader *bufio.Reader) (string, error) {
+	if signature, err := reader.Peek(len(proxyV2Signature)); err == nil && bytes.Equal(signature, proxyV2Signature) {
+		return readProxyV2(reader)
+	}
+
+	prefix, err := reader.Peek(6)
+	if err != nil || string(prefix) != "PROXY " {
+		return "", errProxyHeader
+	}
+
+	return readProxyV1(reader)
+}
+
+// Parsea "PROXY TCP4 <origen> <destino> <puerto origen> <puerto destino>\r\n".
+func readProxyV1(reader *bufio.Reader) (string, error) {
+	line := make([]byte, 0, maxProxyV1Length)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+		if len(line) >= maxProxyV1Length {
+			return "", errProxyHeader
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return "", errProxyHeader
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return "", nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return "", errProxyHeader
+	}
+
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return "", errProxyHeader
+	}
+
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port))), nil
+}
+
+// Parsea la cabecera binaria v2: firma, versión/comando, familia, longitud y direcciones.
+func readProxyV2(reader *bufio.Reader) (string, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return "", err
+	}
+
+	versionCommand, family := header[12], header[13]
+	length := int(binary.BigEndian.Uint16(header[14:16]))
+	if versionCommand>>4 != 2 {
+		return "", errProxyHeader
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return "", err
+	}
+
+	// LOCAL: conexión del propio proxy (ej. comprobaciones de salud)
+	if versionCommand&0x0F == 0 {
+		return "", nil
+	}
+	if versionCommand&0x0F != 1 {
+		return "", errProxyHeader
+	}
+
+	var ip net.IP
+	var port uint16
+	switch family >> 4 {
+	case 1: // AF_INET
+		if length < 12 {
+			return "", errProxyHeader
+		}
+		ip = net.IP(payload[0:4])
+		port = binary.BigEndian.Uint16(payload[8:10])
+	case 2: // AF_INET6
+		if length < 36 {
+			return "", errProxyHeader
+		}
+		ip = net.IP(payload[0:16])
+		port = binary.BigEndian.Uint16(payload[32:34])
+	default:
+		// AF_UNSPEC o AF_UNIX: no hay dirección IP de origen
+		return "", nil
+	}
+
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port))), nil
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Conexión con una dirección remota fija, para simular un balanceador.
+type addrConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (conn addrConn) RemoteAddr() net.Addr {
+	return conn.remote
+}
+
+func makeForwardedServer() *HttpServer {
+	server := NewHttpServer()
+	_, lb, _ := net.ParseCIDR("10.0.0.0/24")
+	server.TrustedProxies = []*net.IPNet{lb}
+	return server
+}
+
+func TestApplyForwarded(t *testing.T) {
+	// Arrange
+	server := makeForwardedServer()
+	cases := []struct {
+		remote  string
+		headers map[string]string
+		ip      string
+		scheme  string
+		host    string
+	}{
+		// Conexión directa: se ignoran las cabeceras
+		{"8.8.8.8:1000", map[string]string{"Host": "a.test", "X-Forwarded-For": "1.1.1.1", "X-Forwarded-Proto": "https"}, "8.8.8.8", "http", "a.test"},
+		// Proxy de confianza con X-Forwarded-*
+		{"10.0.0.5:1000", map[string]string{"Host": "internal", "X-Forwarded-For": "1.1.1.1", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "a.test"}, "1.1.1.1", "https", "a.test"},
+		// Cadena de proxies: el cliente no puede falsificar la parte izquierda
+		{"10.0.0.5:1000", map[string]string{"X-Forwarded-For": "6.6.6.6, 2.2.2.2, 10.0.0.7"}, "2.2.2.2", "http", ""},
+		// Forwarded (RFC 7239) tiene prioridad
+		{"10.0.0.5:1000", map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https;host=b.test, for=10.0.0.9`, "X-Forwarded-For": "3.3.3.3"}, "2001:db8::1", "https", "b.test"},
+		// Salto desconocido: se queda el último conocido
+		{"10.0.0.5:1000", map[string]string{"Forwarded": "for=unknown"}, "10.0.0.5", "http", ""},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("TestApplyForwarded %d", i), func(t *testing.T) {
+			target, _ := url.Parse("/")
+			request := NewHttpRequest("GET", target, c.headers, "")
+			request.RemoteAddr = c.remote
+
+			// Act
+			server.applyForwarded(request)
+
+			// Assert
+			if request.ClientIP() != c.ip || request.Scheme != c.scheme || request.Host != c.host {
+				t.Errorf("Expected %s %s %s, not %s %s %s", c.ip, c.scheme, c.host, request.ClientIP(), request.Scheme, request.Host)
+			}
+		})
+	}
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	// Arrange
+	v2 := func(command, family byte, addresses []byte) string {
+		header := append([]byte{}, proxyV2Signature...)
+		header = append(header, 0x20|command, family, 0, 0)
+		binary.BigEndian.PutUint16(header[14:], uint16(len(addresses)))
+		return string(append(header, addresses...))
+	}
+	ipv4 := []byte{192, 0, 2, 1, 10, 0, 0, 1, 0x30, 0x39, 0x00, 0x50}
+
+	cases := []struct {
+		input  string
+		source string
+		fails  bool
+	}{
+		{"PROXY TCP4 192.0.2.1 10.0.0.1 12345 80\r\nGET / HTTP/1.0\r\n", "192.0.2.1:12345", false},
+		{"PROXY TCP6 2001:db8::1 ::1 4711 80\r\n", "[2001:db8::1]:4711", false},
+		{"PROXY UNKNOWN\r\n", "", false},
+		{"PROXY TCP4 2001:db8::1 ::1 4711 80\r\n", "", true},
+		{"PROXY TCP4 192.0.2.1 10.0.0.1 99999 80\r\n", "", true},
+		{"PROXY TCP4 " + strings.Repeat("1", 200) + "\r\n", "", true},
+		{"GET / HTTP/1.0\r\n\r\n", "", true},
+		{v2(1, 0x11, ipv4), "192.0.2.1:12345", false},
+		{v2(0, 0x00, nil), "", false},
+		{v2(1, 0x11, ipv4[:4]), "", true},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("TestReadProxyHeader %d", i), func(t *testing.T) {
+			// Act
+			source, err := readProxyHeader(bufio.NewReader(strings.NewReader(c.input)))
+
+			// Assert
+			if (err != nil) != c.fails {
+				t.Fatalf("Expected failure %v, not %v", c.fails, err)
+			}
+			if source != c.source {
+				t.Errorf("Expected %q, not %q", c.source, source)
+			}
+		})
+	}
+}
+
+func TestProxyProtocolThroughServer(t *testing.T) {
+	// Arrange
+	server := makeForwardedServer()
+	server.ProxyProtocol = true
+	server.Get("/ip", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(request.ClientIP() + " " + request.Scheme), nil
+	})
+
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+	lb := &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 40000}
+	go server.Handle(addrConn{conn2, lb})
+
+	// Act
+	go conn1.Write([]byte("PROXY TCP4 192.0.2.1 10.0.0.1 12345 80\r\nGET /ip HTTP/1.0\r\n\r\n"))
+	var response bytes.Buffer
+	response.ReadFrom(conn1)
+
+	// Assert
+	if !strings.HasSuffix(response.String(), "192.0.2.1 http") {
+		t.Errorf("Expected client address from PROXY header, not %q", response.String())
+	}
+}
@@ -0,0 +1,193 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Solicitudes reales y maliciosas con las que empiezan los fuzzers.
+// "go test" las ejecuta siempre; "go test -fuzz=FuzzParseRequest ./core"
+// genera variaciones a partir de ellas.
+var fuzzRequestSeeds = []string{
+	// Clientes habituales
+	"GET / HTTP/1.1\r\nHost: localhost:8080\r\nUser-Agent: curl/8.5.0\r\nAccept: */*\r\n\r\n",
+	"GET /search?q=go+http&page=2 HTTP/1.1\r\nHost: example.com\r\nUser-Agent: Mozilla/5.0 (X11; Linux x86_64)\r\n" +
+		"Accept: text/html,application/xhtml+xml;q=0.9,*/*;q=0.8\r\nAccept-Language: es-CR,es;q=0.9\r\n" +
+		"Accept-Encoding: gzip, deflate, br\r\nCookie: session=abc; theme=dark\r\nConnection: keep-alive\r\n\r\n",
+	"POST /login HTTP/1.1\r\nHost: x\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 27\r\n\r\nuser=admin&password=secret",
+	"POST /upload HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n4;name=value\r\nWiki\r\n5\r\npedia\r\n0\r\nExpires: never\r\n\r\n",
+	"PUT /files/a.txt HTTP/1.1\r\nHost: x\r\nContent-Length: 5\r\nExpect: 100-continue\r\n\r\nhello",
+	"GET /ws HTTP/1.1\r\nHost: x\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n",
+	"CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n",
+	"GET http://example.com/path?x=1 HTTP/1.1\r\nHost: example.com\r\n\r\n",
+	"OPTIONS * HTTP/1.1\r\nHost: x\r\n\r\n",
+	"HEAD /status HTTP/1.0\r\n\r\n",
+	"GET /a HTTP/1.1\r\nHost: x\r\n\r\nGET /b HTTP/1.1\r\nHost: x\r\n\r\n",
+	"POST /gzip HTTP/1.1\r\nHost: x\r\nContent-Encoding: gzip\r\nContent-Length: 4\r\n\r\n\x1f\x8b\x08\x00",
+	// Request smuggling
+	"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 6\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\nX",
+	"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 3\r\nContent-Length: 4\r\n\r\nabcd",
+	"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\nTransfer-Encoding: identity\r\n\r\n0\r\n\r\n",
+	"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding : chunked\r\n\r\n0\r\n\r\n",
+	"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding:\tchunked\r\nX: a\r\n chunked\r\n\r\n0\r\n\r\n",
+	"GET / HTTP/1.1\nHost: x\n\n",
+	// Números fuera de rango
+	"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: -1\r\n\r\n",
+	"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 99999999999999999999\r\n\r\n",
+	"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\nffffffffffffffffff\r\nx\r\n",
+	"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n-1\r\n\r\n",
+	// Líneas de inicio rotas
+	"",
+	"\r\n\r\n",
+	"GET\r\n\r\n",
+	"GET /\r\n\r\n",
+	"GET / HTTP/1.1 extra\r\n\r\n",
+	"get / HTTP/1.1\r\n\r\n",
+	"GET /%zz HTTP/1.1\r\nHost: x\r\n\r\n",
+	"GET http://[::1 HTTP/1.1\r\nHost: x\r\n\r\n",
+	"CONNECT /path HTTP/1.1\r\n\r\n",
+	"GET /\x00 HTTP/1.1\r\nHost: \x7f\r\n\r\n",
+	"GET /ñ HTTP/1.1\r\nHost: x\r\nX: \xff\xfe\r\n\r\n",
+}
+
+func FuzzParseRequest(f *testing.F) {
+	for _, seed := range fuzzRequestSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		request, err := ParseRequest(input)
+		if err == nil {
+			checkFuzzedRequest(t, request)
+		}
+
+		// Lo que acepta el modo estricto también lo acepta el tolerante.
+		strict, strictErr := ParseRequestStrict(input)
+		if strictErr != nil {
+			return
+		}
+		checkFuzzedRequest(t, strict)
+		if err != nil {
+			t.Fatalf("Expected lenient parser to accept %q, not %v", input, err)
+		}
+		if strict.Method != request.Method || strict.Version != request.Version {
+			t.Errorf("Expected same start line in both modes, not %s %s and %s %s", strict.Method, strict.Version, request.Method, request.Version)
+		}
+	})
+}
+
+func FuzzReadRequest(f *testing.F) {
+	for _, seed := range fuzzRequestSeeds {
+		f.Add([]byte(seed), false)
+		f.Add([]byte(seed), true)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, strict bool) {
+		requestReader := NewRequestReader(bytes.NewReader(data))
+		requestReader.Strict = strict
+
+		// Cada solicitud consume al menos una línea, así que el bucle termina.
+		for i := 0; i <= bytes.Count(data, []byte("\n")); i++ {
+			request, err := requestReader.ReadRequest()
+			if err != nil {
+				return
+			}
+			checkFuzzedRequest(t, request)
+			if len(request.RawBody) > len(data) && request.Header("Content-Encoding") == "" {
+				t.Fatalf("Expected body no longer than the input, not %d bytes", len(request.RawBody))
+			}
+		}
+		t.Fatalf("Expected reader to stop after %d requests", bytes.Count(data, []byte("\n")))
+	})
+}
+
+// Comprueba los invariantes de una solicitud aceptada por el parser.
+func checkFuzzedRequest(t *testing.T, request *HttpRequest) {
+	t.Helper()
+	if request == nil || request.Target == nil || request.Headers == nil {
+		t.Fatalf("Expected a complete request, not %+v", request)
+	}
+	if !HTTP_METHODS[request.Method] {
+		t.Errorf("Expected a known method, not %q", request.Method)
+	}
+	if request.Version != "HTTP/1.0" && request.Version != "HTTP/1.1" {
+		t.Errorf("Expected HTTP/1.0 or HTTP/1.1, not %q", request.Version)
+	}
+}
+
+func FuzzResponseSerializer(f *testing.F) {
+	f.Add(200, "OK", "Content-Type", "text/plain", "hello")
+	f.Add(204, "No Content", "X-Empty", "", "ignored")
+	f.Add(304, "Not Modified", "ETag", `"v1"`, "")
+	f.Add(101, "Switching Protocols", "Upgrade", "websocket", "")
+	f.Add(404, "Not Found", "Set-Cookie", "a=1\r\nSet-Cookie: injected=1", "missing")
+	f.Add(500, "Error\r\nX-Injected: 1", "Bad Key:", "value", "\r\n\r\nHTTP/1.1 200 OK\r\n")
+	f.Add(200, "OK", "content-length", "99", "body")
+	f.Add(200, "OK", "transfer-encoding", "chunked", "body")
+	f.Add(-1, "", "", "\x00", "\xff")
+
+	f.Fuzz(func(t *testing.T, code int, text, key, value, body string) {
+		response := NewHttpResponse(code, text, body).SetHeader(key, value)
+		response.Version = "HTTP/1.1"
+
+		var buffer bytes.Buffer
+		if _, err := response.WriteTo(&buffer); err != nil {
+			t.Fatalf("Expected no error, %v", err)
+		}
+		if buffer.String() != response.String() {
+			t.Fatalf("Expected WriteTo and String to match, not %q and %q", buffer.String(), response.String())
+		}
+
+		// La salida debe poder leerse de vuelta: una línea de estado, una
+		// cabecera por entrada de Headers y el cuerpo indicado por Content-Length.
+		reader := bufio.NewReader(&buffer)
+		status, err := reader.ReadString('\n')
+		if err != nil || !strings.HasPrefix(status, "HTTP/1.1 "+strconv.Itoa(code)+" ") {
+			t.Fatalf("Expected status line for %d, not %q", code, status)
+		}
+		lines := 0
+		length := -1
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || !strings.HasSuffix(line, "\r\n") {
+				t.Fatalf("Expected header line ending in CRLF, not %q", line)
+			}
+			if line == "\r\n" {
+				break
+			}
+			lines++
+			name, field, ok := strings.Cut(strings.TrimSuffix(line, "\r\n"), ": ")
+			if !ok || !isToken(name) || !validFieldValue(field) {
+				t.Fatalf("Expected a valid header line, not %q", line)
+			}
+			if strings.EqualFold(name, "Transfer-Encoding") && response.BodyLength() >= 0 {
+				t.Fatalf("Expected no Transfer-Encoding with a known length, not %q", line)
+			}
+			if strings.EqualFold(name, "Content-Length") {
+				if length != -1 {
+					t.Fatalf("Expected a single Content-Length, not %q", line)
+				}
+				length, _ = strconv.Atoi(field)
+			}
+		}
+		if lines > len(response.Headers) {
+			t.Errorf("Expected at most %d header lines, not %d", len(response.Headers), lines)
+		}
+
+		data, _ := io.ReadAll(reader)
+		rest := string(data)
+		if !response.allowsBody() {
+			if length != -1 || rest != "" {
+				t.Errorf("Expected no body for %d, not %q", code, rest)
+			}
+			return
+		}
+		if length != len(body) || rest != body {
+			t.Errorf("Expected body %q with Content-Length %d, not %q with %d", body, len(body), rest, length)
+		}
+	})
+}
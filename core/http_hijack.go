@@ -0,0 +1,178 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+)
+
+// Indica a Handle que la conexión ya no le pertenece y no debe cerrarla.
+var errHijacked = errors.New("connection hijacked")
+
+// Crea una respuesta que toma el control de la conexión. El servidor no
+// escribe nada: handler recibe la conexión y el reader con los bytes ya
+// leídos, habla el protocolo que quiera y debe cerrar la conexión al terminar.
+// La conexión sigue contándose en el servidor y Stop la cierra.
+//
+//	server.Get("/tunnel", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+//		return core.Hijack(func(conn net.Conn, reader *bufio.Reader) {
+//			defer conn.Close()
+//			conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+//			io.Copy(conn, reader)
+//		}), nil
+//	})
+func Hijack(handler func(conn net.Conn, reader *bufio.Reader)) *HttpResponse {
+	response := Ok()
+	response.Hijack = handler
+	return response
+}
+
+// Registro de las conexiones abiertas del servidor.
+type connTracker struct {
+	mu      sync.Mutex
+	conns   map[net.Conn]*connState
+	closing bool          // Si es true, el servidor se está deteniendo
+	stopped chan struct{} // Se cierra al activar closing
+}
+
+// Estado de una conexión abierta.
+type connState struct {
+	active   int  // Solicitudes leídas cuya respuesta aún no se escribió
+	hijacked bool // Si es true, la conexión fue tomada por un manejador
+}
+
+// Registra una conexión nueva.
+func (tracker *connTracker) add(conn net.Conn) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if tracker.conns == nil {
+		tracker.conns = make(map[net.Conn]*connState)
+	}
+	tracker.conns[conn] = &connState{}
+}
+
+// Marca el inicio de una solicitud en la conexión: deja de estar inactiva.
+func (tracker *connTracker) begin(conn net.Conn) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if state, ok := tracker.conns[conn]; ok {
+		state.active++
+	}
+}
+
+// Marca que la respuesta a una solicitud ya se escribió. Si el servidor se
+// está deteniendo y no quedan solicitudes en curso, cierra la conexión, que
+// si no esperaría la siguiente solicitud hasta IdleTimeout.
+func (tracker *connTracker) end(conn net.Conn) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	state, ok := tracker.conns[conn]
+	if !ok || state.hijacked {
+		return
+	}
+	state.active--
+	if tracker.closing && state.active <= 0 {
+		conn.Close()
+		delete(tracker.conns, conn)
+	}
+}
+
+// Marca una conexión como tomada por un manejador.
+func (tracker *connTracker) hijack(conn net.Conn) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if state, ok := tracker.conns[conn]; ok {
+		state.hijacked = true
+	}
+}
+
+// Olvida una conexión cerrada.
+func (tracker *connTracker) remove(conn net.Conn) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	delete(tracker.conns, conn)
+}
+
+// Devuelve el número de conexiones abiertas y cuántas fueron tomadas.
+func (tracker *connTracker) count() (int, int) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	hijacked := 0
+	for _, state := range tracker.conns {
+		if state.hijacked {
+			hijacked++
+		}
+	}
+	return len(tracker.conns), hijacked
+}
+
+// Indica si el servidor se está deteniendo: las respuestas pendientes se
+// envían con "Connection: close".
+func (tracker *connTracker) shuttingDown() bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return tracker.closing
+}
+
+// Devuelve un canal que se cierra cuando el servidor empieza a detenerse.
+func (tracker *connTracker) done() <-chan struct{} {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if tracker.stopped == nil {
+		tracker.stopped = make(chan struct{})
+	}
+	return tracker.stopped
+}
+
+// Activa o desactiva el modo de cierre. Al activarlo cierra las conexiones
+// tomadas por manejadores y las inactivas (esperando la siguiente
+// solicitud); las que atienden una solicitud se cierran al responderla.
+func (tracker *connTracker) setClosing(closing bool) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if tracker.stopped == nil {
+		tracker.stopped = make(chan struct{})
+	}
+	if closing && !tracker.closing {
+		close(tracker.stopped)
+	} else if !closing && tracker.closing {
+		tracker.stopped = make(chan struct{})
+	}
+
+	tracker.closing = closing
+	if !closing {
+		return
+	}
+	for conn, state := range tracker.conns {
+		if state.hijacked || state.active <= 0 {
+			conn.Close()
+			delete(tracker.conns, conn)
+		}
+	}
+}
+
+// Conexión entregada a un manejador: al cerrarla deja de contarse.
+type hijackedConn struct {
+	net.Conn
+	tracker *connTracker
+	once    sync.Once
+}
+
+func (conn *hijackedConn) Close() error {
+	conn.once.Do(func() { conn.tracker.remove(conn.Conn) })
+	return conn.Conn.Close()
+}
+
+// Devuelve el número de conexiones abiertas, incluidas las tomadas por
+// manejadores (Hijack o cambio de protocolo), y cuántas de ellas lo fueron.
+func (server *HttpServer) Connections() (int, int) {
+	return server.conns.count()
+}
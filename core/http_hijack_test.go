@@ -0,0 +1,125 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Espera a que el servidor cuente las conexiones indicadas.
+func waitConnections(t *testing.T, server *HttpServer, active, hijacked int) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		gotActive, gotHijacked := server.Connections()
+		if gotActive == active && gotHijacked == hijacked {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	gotActive, gotHijacked := server.Connections()
+	t.Fatalf("Expected %d connections (%d hijacked), not %d (%d)", active, hijacked, gotActive, gotHijacked)
+}
+
+func TestHijack(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+
+	done := make(chan struct{})
+	server.Get("/tunnel", func(request *HttpRequest) (*HttpResponse, error) {
+		return Hijack(func(conn net.Conn, reader *bufio.Reader) {
+			defer close(done)
+			defer conn.Close()
+
+			conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+			// Protocolo propio: devuelve cada línea en mayúsculas
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				conn.Write([]byte(strings.ToUpper(line)))
+			}
+		}), nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Act
+	// La primera línea del protocolo llega junto con la solicitud y queda en el reader
+	fmt.Fprint(conn, "GET /tunnel HTTP/1.1\r\nHost: x\r\n\r\nhello\n")
+	reader := bufio.NewReader(conn)
+
+	// Assert
+	status, _ := reader.ReadString('\n')
+	if status != "HTTP/1.1 200 Connection Established\r\n" {
+		t.Fatalf("Expected handler's own status line, not %q", status)
+	}
+	reader.ReadString('\n')
+
+	if line, _ := reader.ReadString('\n'); line != "HELLO\n" {
+		t.Errorf("Expected HELLO, not %q", line)
+	}
+	fmt.Fprint(conn, "again\n")
+	if line, _ := reader.ReadString('\n'); line != "AGAIN\n" {
+		t.Errorf("Expected AGAIN, not %q", line)
+	}
+
+	waitConnections(t, server, 1, 1)
+
+	// Al cerrar el cliente, el manejador cierra la conexión y deja de contarse
+	conn.Close()
+	<-done
+	waitConnections(t, server, 0, 0)
+}
+
+func TestStopClosesHijackedConnections(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+
+	server.Get("/hold", func(request *HttpRequest) (*HttpResponse, error) {
+		return Hijack(func(conn net.Conn, reader *bufio.Reader) {
+			// No cierra la conexión: solo espera datos
+			reader.ReadByte()
+		}), nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprint(conn, "GET /hold HTTP/1.1\r\nHost: x\r\n\r\n")
+	waitConnections(t, server, 1, 1)
+
+	// Act
+	server.Stop()
+
+	// Assert
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected connection closed by Stop, not %v", err)
+	}
+	waitConnections(t, server, 0, 0)
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestChainOrder(t *testing.T) {
+	// Arrange
+	order := make([]string, 0)
+	trace := func(name string) Middleware {
+		return func(next Handle) Handle {
+			return func(request *HttpRequest) (*HttpResponse, error) {
+				order = append(order, name)
+				return next(request)
+			}
+		}
+	}
+
+	server := NewHttpServer()
+	server.Use(trace("global1"), trace("global2"))
+	server.Get("/route", func(request *HttpRequest) (*HttpResponse, error) {
+		order = append(order, "handler")
+		return Ok(), nil
+	}).Use(trace("route"))
+
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+
+	go server.Handle(conn2)
+
+	// Act
+	go fmt.Fprint(conn1, "GET /route HTTP/1.0\r\n\r\n")
+	bufio.NewReader(conn1).ReadString('\n')
+
+	// Assert
+	expected := "global1,global2,route,handler"
+	if strings.Join(order, ",") != expected {
+		t.Errorf("Expected order %s, not %s", expected, strings.Join(order, ","))
+	}
+}
+
+func TestOptionsAllow(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	handler := func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok(), nil
+	}
+	server.Get("/file", handler)
+	server.Delete("/file", handler)
+
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+
+	go server.Handle(conn2)
+
+	// Act
+	go fmt.Fprint(conn1, "OPTIONS /file HTTP/1.0\r\n\r\n")
+
+	reader := bufio.NewReader(conn1)
+	status, _ := reader.ReadString('\n')
+	allow, _ := reader.ReadString('\n')
+
+	// Assert
+	if status != "HTTP/1.0 204 No Content\r\n" {
+		t.Errorf("Expected 204 No Content, not %q", status)
+	}
+
+	if allow != "Allow: GET, DELETE, OPTIONS\r\n" {
+		t.Errorf("Expected Allow header, not %q", allow)
+	}
+}
+
+func TestHandlerRequireRolesAndScopes(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	handle := func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text("ok"), nil
+	}
+	server.Delete("/deletefile", handle).RequireRoles("admin", "ops").RequireScopes("files:write")
+	handler, _ := server.FindHandler(makeAuthzRequest(nil))
+
+	cases := []struct {
+		principal *Principal
+		code      int
+	}{
+		{nil, 401},
+		{&Principal{Name: "a", Roles: []string{"ops"}, Scopes: []string{"files:write"}}, 200},
+		{&Principal{Name: "b", Roles: []string{"admin"}}, 403},
+		{&Principal{Name: "c", Scopes: []string{"files:write"}}, 403},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("TestHandlerRequireRolesAndScopes %d", i), func(t *testing.T) {
+			// Act
+			resp := server.route(handler, true, makeAuthzRequest(c.principal))
+
+			// Assert
+			if resp.StatusCode != c.code {
+				t.Errorf("Expected %d, not %d", c.code, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func makeAuthzRequest(principal *Principal) *HttpRequest {
+	target, _ := url.Parse("/deletefile")
+	request := NewHttpRequest("DELETE", target, map[string]string{}, "")
+	request.Principal = principal
+	return request
+}
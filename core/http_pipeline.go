@@ -0,0 +1,172 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// Una solicitud en proceso dentro del pipeline de una conexión.
+type pipelined struct {
+	request  *HttpRequest
+	response chan *HttpResponse // Recibe la respuesta cuando el manejador termina
+	close    bool               // Si es true, la conexión se cierra tras la respuesta
+}
+
+// Atiende una conexión con pipelining: las solicitudes ya enviadas por el
+// cliente se leen sin esperar a responder las anteriores y se procesan a la
+// vez (hasta server.Pipelining), pero las respuestas se escriben en el orden
+// de las solicitudes.
+//
+// Las solicitudes que necesitan la conexión (cambio de protocolo, CONNECT y
+// proxy de reenvío, manejadores con StreamBody y Expect) esperan a que se
+// escriban las respuestas pendientes y se atienden solas.
+func (server *HttpServer) servePipelined(conn net.Conn, reader *bufio.Reader, remoteAddr string) error {
+	queue := make(chan *pipelined, server.Pipelining-1)
+	var pending sync.WaitGroup
+	stopped := make(chan struct{})
+	writerDone := make(chan struct{})
+
+	// Escritor: envía las respuestas en orden. Si una cierra la conexión o
+	// falla la escritura, descarta las siguientes y desbloquea al lector.
+	go func() {
+		defer close(writerDone)
+		failed := false
+		for p := range queue {
+			resp := <-p.response
+			if !failed {
+				keepAlive, err := server.writeResponse(conn, p.request, resp, !p.close)
+				if err != nil || !keepAlive {
+					failed = true
+					close(stopped)
+					conn.SetReadDeadline(time.Now())
+				}
+			}
+			server.conns.end(conn)
+			pending.Done()
+		}
+	}()
+	defer func() {
+		close(queue)
+		<-writerDone
+	}()
+
+	for served := 0; ; served++ {
+		select {
+		case <-stopped:
+			return nil
+		default:
+		}
+
+		// Entre solicitudes de una conexión persistente se limita la espera.
+		if served > 0 && server.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(server.IdleTimeout))
+		}
+
+		request, err := readRequestHead(reader, server.StrictParsing)
+		select {
+		case <-stopped:
+			return nil
+		default:
+		}
+		if served > 0 && err != nil && !errors.As(err, new(*headError)) {
+			return nil
+		}
+		if err != nil {
+			// La respuesta de error va después de las pendientes.
+			pending.Wait()
+			writeBadRequest(conn, err)
+			return nil
+		}
+
+		conn.SetReadDeadline(time.Time{})
+
+		request.RemoteAddr = remoteAddr
+		request.serverDone = server.conns.done()
+		server.applyForwarded(request)
+		server.conns.begin(conn)
+
+		handler, pathMatched := server.FindHandler(request)
+		if server.needsConnection(handler, request) {
+			pending.Wait()
+			select {
+			case <-stopped:
+				server.conns.end(conn)
+				return nil
+			default:
+			}
+
+			keepAlive, err := server.serve(conn, reader, request)
+			server.conns.end(conn)
+			if err != nil || !keepAlive {
+				return err
+			}
+			continue
+		}
+
+		p := &pipelined{request: request, response: make(chan *HttpResponse, 1)}
+		pending.Add(1)
+		queue <- p
+
+		// El cuerpo se lee completo antes de pasar a la siguiente solicitud.
+		if errResp := server.readPipelinedBody(handler, request, reader); errResp != nil {
+			p.close = true
+			p.response <- errResp
+			return nil
+		}
+
+		go func() {
+			p.response <- server.respondPipelined(handler, pathMatched, request)
+		}()
+
+		if !request.KeepAlive() {
+			return nil
+		}
+	}
+}
+
+// Indica si la solicitud debe atenderse sola, con acceso a la conexión.
+func (server *HttpServer) needsConnection(handler *Handler, request *HttpRequest) bool {
+	return request.Header("Upgrade") != "" ||
+		request.Header("Expect") != "" ||
+		server.proxied(request) ||
+		(handler != nil && handler.Stream)
+}
+
+// Lee el cuerpo de una solicitud del pipeline. Devuelve la respuesta de error
+// si no es válido; la conexión debe cerrarse en ese caso.
+func (server *HttpServer) readPipelinedBody(handler *Handler, request *HttpRequest, reader *bufio.Reader) *HttpResponse {
+	slog.Info("Request", "address", request.RemoteAddr, "client", request.ClientIP(), "method", request.Method, "path", request.Target.Path)
+
+	// HTTP/1.1 exige la cabecera Host (RFC 9112, sección 3.2).
+	if request.Version == "HTTP/1.1" && request.Header("Host") == "" && request.Target.Host == "" {
+		return BadRequest().Text("missing host header")
+	}
+
+	body, errResp := server.requestBody(request, reader)
+	if errResp != nil {
+		return errResp
+	}
+
+	errResp, _ = server.prepareBody(handler, request, body)
+	return errResp
+}
+
+// Ejecuta el manejador de una solicitud del pipeline.
+func (server *HttpServer) respondPipelined(handler *Handler, pathMatched bool, request *HttpRequest) *HttpResponse {
+	resp := server.dispatch(handler, pathMatched, request)
+	if resp == nil {
+		resp = NewHttpResponse(500, "Internal Server Error", "500 Internal Server Error")
+	}
+
+	// Con otras solicitudes en proceso no se puede entregar la conexión.
+	if resp.Hijack != nil || resp.Upgrade != nil {
+		slog.Warn("Connection takeover in pipelined request", "address", request.RemoteAddr, "path", request.Target.Path)
+		resp = NewHttpResponse(500, "Internal Server Error", "").Text("connection takeover not allowed in pipelined request")
+	}
+
+	return server.finish(request, resp)
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestReaderPipelined(t *testing.T) {
+	// Arrange: tres solicitudes en una sola escritura
+	input := "GET /a HTTP/1.1\r\nHost: x\r\n\r\n" +
+		"POST /b HTTP/1.1\r\nHost: x\r\nContent-Length: 4\r\n\r\nbody" +
+		"GET /c HTTP/1.1\r\nHost: x\r\n\r\n"
+	requestReader := NewRequestReader(strings.NewReader(input))
+
+	for _, expected := range []string{"/a", "/b", "/c"} {
+		// Act
+		request, err := requestReader.ReadRequest()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, %v", err)
+		}
+		if request.Target.Path != expected {
+			t.Errorf("Expected path %s, not %s", expected, request.Target.Path)
+		}
+		if expected == "/b" && request.Body != "body" {
+			t.Errorf("Expected body to be body, not %q", request.Body)
+		}
+	}
+
+	if _, err := requestReader.ReadRequest(); err == nil {
+		t.Error("Expected error after the last request")
+	}
+}
+
+// Arranca el servidor en un puerto efímero y devuelve una conexión con él.
+func dialPipeline(t *testing.T, server *HttpServer) net.Conn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+// Lee una respuesta con Content-Length y devuelve el cuerpo.
+func readPipelinedBody(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+	length := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected a response, not %v", err)
+		}
+		if line == "\r\n" {
+			break
+		}
+		fmt.Sscanf(line, "Content-Length: %d", &length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestPipelinedResponsesInOrder(t *testing.T) {
+	// Arrange: /slow solo termina cuando /fast ha empezado, lo que exige procesarlas a la vez
+	server := NewHttpServer()
+	server.Pipelining = 4
+	fastStarted := make(chan struct{})
+	server.Get("/slow", func(request *HttpRequest) (*HttpResponse, error) {
+		select {
+		case <-fastStarted:
+			return Ok().Text("slow"), nil
+		case <-time.After(2 * time.Second):
+			return Ok().Text("sequential"), nil
+		}
+	})
+	server.Get("/fast", func(request *HttpRequest) (*HttpResponse, error) {
+		close(fastStarted)
+		return Ok().Text("fast"), nil
+	})
+	server.Post("/echo", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(request.Body), nil
+	})
+	conn := dialPipeline(t, server)
+
+	// Act
+	fmt.Fprint(conn, "GET /slow HTTP/1.1\r\nHost: x\r\n\r\n"+
+		"GET /fast HTTP/1.1\r\nHost: x\r\n\r\n"+
+		"POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 4\r\n\r\necho")
+	reader := bufio.NewReader(conn)
+
+	// Assert
+	for _, expected := range []string{"slow", "fast", "echo"} {
+		if body := readPipelinedBody(t, reader); body != expected {
+			t.Errorf("Expected %s, not %s", expected, body)
+		}
+	}
+}
+
+func TestPipelinedSequentialByDefault(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	server.Get("/n", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(request.Target.Query().Get("v")), nil
+	})
+	conn := dialPipeline(t, server)
+
+	// Act: ninguna solicitud se pierde aunque lleguen juntas
+	fmt.Fprint(conn, "GET /n?v=1 HTTP/1.1\r\nHost: x\r\n\r\nGET /n?v=2 HTTP/1.1\r\nHost: x\r\n\r\nGET /n?v=3 HTTP/1.1\r\nHost: x\r\n\r\n")
+	reader := bufio.NewReader(conn)
+
+	// Assert
+	for _, expected := range []string{"1", "2", "3"} {
+		if body := readPipelinedBody(t, reader); body != expected {
+			t.Errorf("Expected %s, not %s", expected, body)
+		}
+	}
+}
+
+func TestPipelinedConnectionClose(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	server.Pipelining = 4
+	server.Get("/n", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(request.Target.Query().Get("v")), nil
+	})
+	conn := dialPipeline(t, server)
+
+	// Act: la segunda solicitud cierra la conexión y la tercera no se atiende
+	fmt.Fprint(conn, "GET /n?v=1 HTTP/1.1\r\nHost: x\r\n\r\n"+
+		"GET /n?v=2 HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n"+
+		"GET /n?v=3 HTTP/1.1\r\nHost: x\r\n\r\n")
+	reader := bufio.NewReader(conn)
+
+	// Assert
+	for _, expected := range []string{"1", "2"} {
+		if body := readPipelinedBody(t, reader); body != expected {
+			t.Errorf("Expected %s, not %s", expected, body)
+		}
+	}
+	if rest, _ := io.ReadAll(reader); len(rest) != 0 {
+		t.Errorf("Expected connection closed after Connection: close, not %q", rest)
+	}
+}
+
+func TestPipelinedStreamBody(t *testing.T) {
+	// Arrange: un manejador con StreamBody espera a las respuestas pendientes
+	server := NewHttpServer()
+	server.Pipelining = 4
+	server.Get("/n", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(request.Target.Query().Get("v")), nil
+	})
+	server.Post("/upload", func(request *HttpRequest) (*HttpResponse, error) {
+		data, err := io.ReadAll(request.BodyReader)
+		if err != nil {
+			return nil, err
+		}
+		return Ok().Text(strings.ToUpper(string(data))), nil
+	}).StreamBody()
+	conn := dialPipeline(t, server)
+
+	// Act
+	fmt.Fprint(conn, "GET /n?v=1 HTTP/1.1\r\nHost: x\r\n\r\n"+
+		"POST /upload HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n4\r\ndata\r\n0\r\n\r\n"+
+		"GET /n?v=3 HTTP/1.1\r\nHost: x\r\n\r\n")
+	reader := bufio.NewReader(conn)
+
+	// Assert
+	for _, expected := range []string{"1", "DATA", "3"} {
+		if body := readPipelinedBody(t, reader); body != expected {
+			t.Errorf("Expected %s, not %s", expected, body)
+		}
+	}
+}
+
+func TestPipelinedBadRequest(t *testing.T) {
+	tests := []struct {
+		pipelining int
+		request    string
+		status     string
+	}{
+		{1, "POST /n HTTP/1.1\r\nHost: x\r\n\r\n", "HTTP/1.1 400 Bad Request\r\n"},
+		{4, "POST /n HTTP/1.1\r\nHost: x\r\n\r\n", "HTTP/1.1 400 Bad Request\r\n"},
+		{1, "POST /n HTTP/1.0\r\n\r\n", "HTTP/1.0 400 Bad Request\r\n"},
+		{4, "GET /n HTTP/1.1\r\n\r\n", "HTTP/1.1 400 Bad Request\r\n"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("TestPipelinedBadRequest %d", i), func(t *testing.T) {
+			// Arrange
+			server := NewHttpServer()
+			server.Pipelining = test.pipelining
+			server.Get("/n", func(request *HttpRequest) (*HttpResponse, error) {
+				return Ok().Text("ok"), nil
+			})
+			conn := dialPipeline(t, server)
+
+			// Act: la segunda solicitud no es válida, sin ser un error de sintaxis
+			fmt.Fprint(conn, "GET /n HTTP/1.1\r\nHost: x\r\n\r\n"+test.request)
+			reader := bufio.NewReader(conn)
+
+			// Assert: se responde con la versión de la solicitud y se cierra
+			if body := readPipelinedBody(t, reader); body != "ok" {
+				t.Errorf("Expected ok, not %s", body)
+			}
+			if status, _ := reader.ReadString('\n'); status != test.status {
+				t.Errorf("Expected %q, not %q", test.status, status)
+			}
+			readPipelinedBody(t, reader)
+			if rest, _ := io.ReadAll(reader); len(rest) != 0 {
+				t.Errorf("Expected connection closed after 400, not %q", rest)
+			}
+		})
+	}
+}
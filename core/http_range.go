@@ -0,0 +1,303 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formato de fecha HTTP (IMF-fixdate) usado en Last-Modified, Date, etc.
+const TimeFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// Máximo de rangos aceptados en una sola solicitud; con más se ignora Range.
+const maxRanges = 32
+
+// Error devuelto cuando ningún rango pedido se solapa con el contenido.
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// Representa un rango de bytes [Start, Start+Length) del contenido.
+type ByteRange struct {
+	Start  int64
+	Length int64
+}
+
+// Devuelve el valor de Content-Range para el rango dentro de un contenido de tamaño size.
+func (r ByteRange) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
+}
+
+// Parsea una fecha HTTP en cualquiera de los tres formatos de RFC 9110.
+func ParseTime(value string) (time.Time, error) {
+	formats := []string{TimeFormat, time.RFC850, time.ANSIC}
+
+	var err error
+	for _, format := range formats {
+		var t time.Time
+		t, err = time.Parse(format, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// Parsea la cabecera Range para un contenido de tamaño size.
+// Devuelve errRangeNotSatisfiable si ningún rango se solapa con el contenido,
+// u otro error si la cabecera está mal formada (en cuyo caso debe ignorarse).
+func ParseRange(header string, size int64) ([]ByteRange, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, fmt.Errorf("invalid range unit")
+	}
+
+	specs := strings.Split(header[len(prefix):], ",")
+	if len(specs) > maxRanges {
+		return nil, fmt.Errorf("too many ranges")
+	}
+
+	ranges := make([]ByteRange, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		first, last, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid range: %q", spec)
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		var r ByteRange
+		if first == "" {
+			// Sufijo: los últimos N bytes
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid range: %q", spec)
+			}
+			if n == 0 {
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			r = ByteRange{Start: size - n, Length: n}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, fmt.Errorf("invalid range: %q", spec)
+			}
+			if start >= size {
+				// Rango fuera del contenido: no satisfacible, pero los demás pueden serlo
+				continue
+			}
+
+			end := size - 1
+			if last != "" {
+				end, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, fmt.Errorf("invalid range: %q", spec)
+				}
+				if end >= size {
+					end = size - 1
+				}
+			}
+			r = ByteRange{Start: start, Length: end - start + 1}
+		}
+
+		if r.Length > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+
+	if len(ranges) == 0 {
+		return nil, errRangeNotSatisfiable
+	}
+
+	return ranges, nil
+}
+
+// Indica si la condición If-Range permite aplicar Range.
+// Acepta un ETag fuerte igual al de la respuesta o la fecha exacta de modificación.
+func ifRangeMatches(value string, etag string, modTime time.Time) bool {
+	if value == "" {
+		return true
+	}
+
+	if strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "W/") {
+		// If-Range exige comparación fuerte: los ETag débiles nunca coinciden
+		return etag != "" && !strings.HasPrefix(etag, "W/") && value == etag
+	}
+
+	t, err := ParseTime(value)
+	if err != nil || modTime.IsZero() {
+		return false
+	}
+
+	return modTime.UTC().Truncate(time.Second).Equal(t)
+}
+
+// Crea una respuesta para un contenido que admite acceso aleatorio, como un archivo.
+// Anuncia Accept-Ranges y, si modTime no es cero, envía Last-Modified y un ETag
+// derivado de la fecha y el tamaño, evaluando con ellos las precondiciones
+// (304/412). Responde a Range con 206 Partial Content (un rango o
+// multipart/byteranges) o 416 Range Not Satisfiable, respetando If-Range.
+// La respuesta toma posesión de content: si es un io.Closer se cierra al enviarla.
+func ServeContent(request *HttpRequest, contentType string, modTime time.Time, content io.ReadSeeker, size int64) *HttpResponse {
+	response := Ok().SetContentType(contentType).SetHeader("Accept-Ranges", "bytes")
+
+	etag := ""
+	if !modTime.IsZero() {
+		etag = FileETag(modTime, size)
+		response.SetHeader("Last-Modified", modTime.UTC().Format(TimeFormat))
+		response.SetHeader("ETag", etag)
+	}
+
+	if conditional := CheckPreconditions(request, etag, modTime); conditional != nil {
+		closeContent(content)
+		return conditional
+	}
+
+	rangeHeader := request.Header("Range")
+	if rangeHeader == "" || (request.Method != "GET" && request.Method != "HEAD") ||
+		!ifRangeMatches(request.Header("If-Range"), response.Headers["ETag"], modTime) {
+		return response.SetBodyReader(content, size)
+	}
+
+	ranges, err := ParseRange(rangeHeader, size)
+	if errors.Is(err, errRangeNotSatisfiable) {
+		closeContent(content)
+		return NewHttpResponse(416, "Range Not Satisfiable", "").
+			SetHeader("Content-Range", fmt.Sprintf("bytes */%d", size)).
+			SetHeader("Accept-Ranges", "bytes")
+	}
+
+	// Una cabecera Range inválida, o rangos que piden más que el contenido completo, se ignoran.
+	if err != nil || totalLength(ranges) > size {
+		return response.SetBodyReader(content, size)
+	}
+
+	response.SetStatusCode(206).SetStatusText("Partial Content")
+
+	if len(ranges) == 1 {
+		r := ranges[0]
+		if _, err := content.Seek(r.Start, io.SeekStart); err != nil {
+			closeContent(content)
+			return NewHttpResponse(500, "Internal Server Error", "can't seek content")
+		}
+		response.SetHeader("Content-Range", r.ContentRange(size))
+		return response.SetBodyReader(content, r.Length)
+	}
+
+	body := newMultipartRanges(content, contentType, ranges, size)
+	response.SetContentType("multipart/byteranges; boundary=" + body.boundary)
+
+	return response.SetBodyReader(body, body.length())
+}
+
+// Suma las longitudes de los rangos.
+func totalLength(ranges []ByteRange) int64 {
+	var total int64
+	for _, r := range ranges {
+		total += r.Length
+	}
+	return total
+}
+
+// Cierra el contenido si implementa io.Closer.
+func closeContent(content io.Reader) {
+	if closer, ok := content.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// Cuerpo multipart/byteranges que lee cada rango del contenido bajo demanda.
+type multipartRanges struct {
+	content  io.ReadSeeker
+	boundary string
+	parts    []rangePart
+	trailer  string
+	current  io.Reader // Lector de la parte en curso
+	index    int       // Índice de la siguiente parte
+}
+
+// Una parte del cuerpo multipart: sus cabeceras y el rango de bytes.
+type rangePart struct {
+	header string
+	r      ByteRange
+}
+
+func newMultipartRanges(content io.ReadSeeker, contentType string, ranges []ByteRange, size int64) *multipartRanges {
+	random := make([]byte, 12)
+	rand.Read(random)
+	boundary := hex.EncodeToString(random)
+
+	parts := make([]rangePart, len(ranges))
+	for i, r := range ranges {
+		parts[i] = rangePart{
+			header: fmt.Sprintf("\r\n--%s\r\nContent-Type: %s\r\nContent-Range: %s\r\n\r\n", boundary, contentType, r.ContentRange(size)),
+			r:      r,
+		}
+	}
+	// La primera parte no necesita el CRLF previo
+	parts[0].header = strings.TrimPrefix(parts[0].header, "\r\n")
+
+	return &multipartRanges{
+		content:  content,
+		boundary: boundary,
+		parts:    parts,
+		trailer:  fmt.Sprintf("\r\n--%s--\r\n", boundary),
+	}
+}
+
+// Devuelve la longitud total del cuerpo.
+func (m *multipartRanges) length() int64 {
+	total := int64(len(m.trailer))
+	for _, part := range m.parts {
+		total += int64(len(part.header)) + part.r.Length
+	}
+	return total
+}
+
+func (m *multipartRanges) Read(p []byte) (int, error) {
+	for {
+		if m.current != nil {
+			n, err := m.current.Read(p)
+			if !errors.Is(err, io.EOF) {
+				return n, err
+			}
+			m.current = nil
+			if n > 0 {
+				return n, nil
+			}
+		}
+
+		if m.index > len(m.parts) {
+			return 0, io.EOF
+		}
+
+		if m.index == len(m.parts) {
+			m.current = strings.NewReader(m.trailer)
+			m.index++
+			continue
+		}
+
+		part := m.parts[m.index]
+		if _, err := m.content.Seek(part.r.Start, io.SeekStart); err != nil {
+			return 0, err
+		}
+		m.current = io.MultiReader(strings.NewReader(part.header), io.LimitReader(m.content, part.r.Length))
+		m.index++
+	}
+}
+
+func (m *multipartRanges) Close() error {
+	closeContent(m.content)
+	return nil
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var ParseRangeTests = []struct {
+	header   string
+	size     int64
+	expected []ByteRange
+	wantErr  bool
+}{
+	{"bytes=0-4", 10, []ByteRange{{0, 5}}, false},
+	{"bytes=5-", 10, []ByteRange{{5, 5}}, false},
+	{"bytes=-3", 10, []ByteRange{{7, 3}}, false},
+	{"bytes=-30", 10, []ByteRange{{0, 10}}, false},
+	{"bytes=8-20", 10, []ByteRange{{8, 2}}, false},
+	{"bytes=0-1, 4-5", 10, []ByteRange{{0, 2}, {4, 2}}, false},
+	{"bytes=20-30, 1-1", 10, []ByteRange{{1, 1}}, false},
+	{"bytes=20-30", 10, nil, true},
+	{"bytes=5-1", 10, nil, true},
+	{"bytes=a-b", 10, nil, true},
+	{"items=0-1", 10, nil, true},
+	{"bytes=" + strings.Repeat("0-0,", maxRanges+1), 10, nil, true},
+}
+
+func TestParseRange(t *testing.T) {
+	for i, test := range ParseRangeTests {
+		t.Run(fmt.Sprintf("TestParseRange %d", i), func(t *testing.T) {
+			// Act
+			ranges, err := ParseRange(test.header, test.size)
+
+			// Assert
+			if test.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, not %v", test.wantErr, err)
+			}
+
+			if !reflect.DeepEqual(ranges, test.expected) {
+				t.Errorf("Expected %v, not %v", test.expected, ranges)
+			}
+		})
+	}
+}
+
+// makeRangeRequest construye una solicitud GET con las cabeceras dadas.
+func makeRangeRequest(headers map[string]string) *HttpRequest {
+	target, _ := url.Parse("/file")
+	return NewHttpRequest("GET", target, headers, "")
+}
+
+// readResponseBody escribe la respuesta y devuelve cabecera y cuerpo.
+func readResponseBody(t *testing.T, response *HttpResponse) (string, []byte) {
+	t.Helper()
+	var buffer bytes.Buffer
+	if _, err := response.WriteTo(&buffer); err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	head, body, _ := bytes.Cut(buffer.Bytes(), []byte("\r\n\r\n"))
+	return string(head), body
+}
+
+func TestServeContentFull(t *testing.T) {
+	// Arrange
+	modTime := time.Date(2025, 6, 8, 10, 0, 0, 0, time.UTC)
+
+	// Act
+	response := ServeContent(makeRangeRequest(map[string]string{}), "text/plain", modTime, strings.NewReader("0123456789"), 10)
+	head, body := readResponseBody(t, response)
+
+	// Assert
+	if response.StatusCode != 200 {
+		t.Errorf("Expected status code to be 200, not %d", response.StatusCode)
+	}
+
+	if !strings.Contains(head, "Accept-Ranges: bytes") || !strings.Contains(head, "Last-Modified: Sun, 08 Jun 2025 10:00:00 GMT") {
+		t.Errorf("Expected Accept-Ranges and Last-Modified headers, not %q", head)
+	}
+
+	if string(body) != "0123456789" {
+		t.Errorf("Expected full body, not %q", body)
+	}
+}
+
+func TestServeContentSingleRange(t *testing.T) {
+	// Act
+	response := ServeContent(makeRangeRequest(map[string]string{"Range": "bytes=2-5"}), "text/plain", time.Time{}, strings.NewReader("0123456789"), 10)
+	head, body := readResponseBody(t, response)
+
+	// Assert
+	if response.StatusCode != 206 {
+		t.Errorf("Expected status code to be 206, not %d", response.StatusCode)
+	}
+
+	if !strings.Contains(head, "Content-Range: bytes 2-5/10") || !strings.Contains(head, "Content-Length: 4") {
+		t.Errorf("Expected Content-Range and Content-Length, not %q", head)
+	}
+
+	if string(body) != "2345" {
+		t.Errorf("Expected body to be 2345, not %q", body)
+	}
+}
+
+func TestServeContentMultipleRanges(t *testing.T) {
+	// Act
+	response := ServeContent(makeRangeRequest(map[string]string{"Range": "bytes=0-1,-2"}), "text/plain", time.Time{}, strings.NewReader("0123456789"), 10)
+	head, body := readResponseBody(t, response)
+
+	// Assert
+	if response.StatusCode != 206 {
+		t.Fatalf("Expected status code to be 206, not %d", response.StatusCode)
+	}
+
+	contentType := response.Headers["Content-Type"]
+	boundary, ok := strings.CutPrefix(contentType, "multipart/byteranges; boundary=")
+	if !ok {
+		t.Fatalf("Expected multipart/byteranges, not %q", contentType)
+	}
+
+	if !strings.Contains(head, fmt.Sprintf("Content-Length: %d", len(body))) {
+		t.Errorf("Expected Content-Length to match body length %d, %q", len(body), head)
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	expected := []struct{ contentRange, data string }{
+		{"bytes 0-1/10", "01"},
+		{"bytes 8-9/10", "89"},
+	}
+	for _, e := range expected {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Expected no error, %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Range") != e.contentRange || string(data) != e.data {
+			t.Errorf("Expected part %s %q, not %s %q", e.contentRange, e.data, part.Header.Get("Content-Range"), data)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("Expected no more parts, %v", err)
+	}
+}
+
+func TestServeContentNotSatisfiable(t *testing.T) {
+	// Act
+	response := ServeContent(makeRangeRequest(map[string]string{"Range": "bytes=50-60"}), "text/plain", time.Time{}, strings.NewReader("0123456789"), 10)
+
+	// Assert
+	if response.StatusCode != 416 {
+		t.Errorf("Expected status code to be 416, not %d", response.StatusCode)
+	}
+
+	if response.Headers["Content-Range"] != "bytes */10" {
+		t.Errorf("Expected Content-Range bytes */10, not %q", response.Headers["Content-Range"])
+	}
+}
+
+func TestServeContentIfRange(t *testing.T) {
+	modTime := time.Date(2025, 6, 8, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ifRange string
+		status  int
+	}{
+		{"Sun, 08 Jun 2025 10:00:00 GMT", 206},
+		{"Sun, 08 Jun 2025 09:00:00 GMT", 200},
+		{"\"some-etag\"", 200},
+		{"not a date", 200},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("TestServeContentIfRange %d", i), func(t *testing.T) {
+			// Act
+			headers := map[string]string{"Range": "bytes=0-0", "If-Range": test.ifRange}
+			response := ServeContent(makeRangeRequest(headers), "text/plain", modTime, strings.NewReader("0123456789"), 10)
+
+			// Assert
+			if response.StatusCode != test.status {
+				t.Errorf("Expected status code to be %d, not %d", test.status, response.StatusCode)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -25,10 +26,21 @@ var HTTP_METHODS = map[string]bool{
 // Representa una solicitud HTTP recibida.
 // Contiene el método, el objetivo (URL), las cabeceras y el cuerpo de la solicitud.
 type HttpRequest struct {
-	Method  string            // Método HTTP (GET, POST, etc.)
-	Target  *url.URL          // URL objetivo de la solicitud
-	Headers map[string]string // Cabeceras HTTP como un mapa de clave-valor
-	Body    string            // Cuerpo de la solicitud (si existe)
+	Method     string            // Método HTTP (GET, POST, etc.)
+	Target     *url.URL          // URL objetivo de la solicitud
+	Version    string            // Versión del protocolo (HTTP/1.0 o HTTP/1.1)
+	Headers    map[string]string // Cabeceras HTTP como un mapa de clave-valor
+	Body       string            // Cuerpo de la solicitud (si existe)
+	RawBody    []byte            // Cuerpo de la solicitud como bytes, sin conversiones
+	BodyReader io.Reader         // Cuerpo sin leer, solo para manejadores con StreamBody
+	Principal  *Principal        // Identidad autenticada (nil si la solicitud es anónima)
+	RemoteAddr string            // Dirección ("ip:puerto") de la conexión, o el origen del protocolo PROXY
+	RealIP     string            // IP del cliente según las cabeceras de proxies de confianza (vacía si no aplica)
+	Scheme     string            // Esquema usado por el cliente ("http" o "https")
+	Host       string            // Host solicitado por el cliente (Host o X-Forwarded-Host/Forwarded)
+
+	pendingBody func() *HttpResponse // Lectura del cuerpo aplazada hasta el manejador (Expect: 100-continue)
+	serverDone  <-chan struct{}      // Se cierra cuando el servidor que atiende la solicitud se detiene
 }
 
 // Crea una nueva instancia de HttpRequest.
@@ -41,12 +53,159 @@ func NewHttpRequest(method string, target *url.URL, header map[string]string, bo
 	}
 }
 
+// Devuelve el cuerpo de la solicitud como bytes.
+// Usa RawBody si existe; si no, convierte Body.
+func (request *HttpRequest) BodyBytes() []byte {
+	if request.RawBody != nil {
+		return request.RawBody
+	}
+	return []byte(request.Body)
+}
+
+// Devuelve el valor de una cabecera sin distinguir mayúsculas y minúsculas.
+func (request *HttpRequest) Header(key string) string {
+	if value, ok := request.Headers[key]; ok {
+		return value
+	}
+
+	for k, v := range request.Headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// Busca la clave de una cabecera sin distinguir mayúsculas y minúsculas.
+func FindHeader(headers map[string]string, name string) (string, bool) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// Elimina una cabecera con cualquier capitalización.
+func DeleteHeader(headers map[string]string, name string) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+}
+
+// Indica si la conexión debe mantenerse abierta después de responder.
+// HTTP/1.1 es persistente salvo "Connection: close"; HTTP/1.0 solo con "Connection: keep-alive".
+func (request *HttpRequest) KeepAlive() bool {
+	connection := strings.ToLower(request.Header("Connection"))
+
+	if request.Version == "HTTP/1.1" {
+		return !strings.Contains(connection, "close")
+	}
+
+	return strings.Contains(connection, "keep-alive")
+}
+
+// Devuelve la IP del cliente: RealIP si un proxy de confianza la indicó,
+// o RemoteAddr sin el puerto.
+func (request *HttpRequest) ClientIP() string {
+	if request.RealIP != "" {
+		return request.RealIP
+	}
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
 // Lee una solicitud HTTP completa desde una conexión de red.
 // Devuelve un puntero a HttpRequest o un error si ocurre algún problema.
+// Los bytes leídos de más se pierden: para varias solicitudes en la misma
+// conexión (pipelining) hay que usar un RequestReader.
 func ReadRequest(conn net.Conn) (*HttpRequest, error) {
-	lines := make([]string, 0)
+	return NewRequestReader(conn).ReadRequest()
+}
 
-	reader := bufio.NewReader(conn)
+// RequestReader lee solicitudes sucesivas de una conexión con un único
+// bufio.Reader, de modo que las solicitudes enviadas en pipeline, una tras
+// otra sin esperar respuesta, no se pierden entre lecturas.
+type RequestReader struct {
+	Strict bool // Si es true, las cabeceras se analizan como en ReadRequestHeadStrict
+
+	reader *bufio.Reader
+}
+
+// Crea un RequestReader para la conexión.
+func NewRequestReader(conn io.Reader) *RequestReader {
+	return &RequestReader{reader: bufio.NewReader(conn)}
+}
+
+// Lee la siguiente solicitud completa, con el cuerpo.
+func (requestReader *RequestReader) ReadRequest() (*HttpRequest, error) {
+	request, err := readRequestHead(requestReader.reader, requestReader.Strict)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parsea el cuerpo de la solicitud si Content-Length existe (o body vacío en otro caso)
+	if err := ParseBody(request, requestReader.reader); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// Devuelve cuántos bytes de solicitudes siguientes hay ya leídos de la conexión.
+func (requestReader *RequestReader) Buffered() int {
+	return requestReader.reader.Buffered()
+}
+
+// Error devuelto cuando la conexión no contiene ninguna solicitud.
+var errEmptyRequest = errors.New("empty request")
+
+// Error de una solicitud cuya cabecera se leyó pero no es válida, a
+// diferencia de los errores de lectura o de una conexión sin solicitud.
+// Conserva la versión de la línea de inicio para responder con ella.
+type headError struct {
+	version string
+	err     error
+}
+
+// Crea un headError con la versión de la primera línea, HTTP/1.0 si no es
+// HTTP/1.1.
+func newHeadError(lines []string, err error) *headError {
+	version := "HTTP/1.0"
+	if len(lines) > 0 && strings.HasSuffix(strings.TrimRight(lines[0], "\r\n"), " HTTP/1.1") {
+		version = "HTTP/1.1"
+	}
+	return &headError{version: version, err: err}
+}
+
+func (e *headError) Error() string {
+	return e.err.Error()
+}
+
+func (e *headError) Unwrap() error {
+	return e.err
+}
+
+// Lee la línea de inicio y las cabeceras de una solicitud, sin el cuerpo.
+// El cuerpo queda pendiente en el reader para leerlo con ParseBody o en streaming.
+func ReadRequestHead(reader *bufio.Reader) (*HttpRequest, error) {
+	return readRequestHead(reader, false)
+}
+
+// Como ReadRequestHead, pero con las reglas estrictas de ParseRequestStrict;
+// además, cada línea debe terminar en CRLF.
+func ReadRequestHeadStrict(reader *bufio.Reader) (*HttpRequest, error) {
+	return readRequestHead(reader, true)
+}
+
+func readRequestHead(reader *bufio.Reader, strict bool) (*HttpRequest, error) {
+	lines := make([]string, 0)
 
 	// Lee las líneas de la cabecera hasta encontrar una línea vacía
 	for {
@@ -62,12 +221,22 @@ func ReadRequest(conn net.Conn) (*HttpRequest, error) {
 			return nil, err
 		}
 
+		// En modo estricto un LF sin CR no termina la línea
+		if strict && !strings.HasSuffix(line, "\r\n") {
+			return nil, newHeadError(append(lines, line), fmt.Errorf("can't parse request: %w: line without CRLF", ErrMalformedRequest))
+		}
+
 		// Elimina los sufijos de retorno de carro y nueva línea
 		line = strings.TrimSuffix(line, "\r\n")
 		line = strings.TrimSuffix(line, "\n")
 
-		// Una línea vacía indica el final de las cabeceras
+		// Una línea vacía indica el final de las cabeceras. Las que llegan
+		// antes de la línea de inicio se ignoran (RFC 9112, sección 2.2),
+		// p. ej. un CRLF de más enviado tras el cuerpo de un POST.
 		if line == "" {
+			if len(lines) == 0 {
+				continue
+			}
 			break
 		}
 
@@ -76,36 +245,54 @@ func ReadRequest(conn net.Conn) (*HttpRequest, error) {
 
 	// Si no se leyeron líneas, la solicitud está vacía
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty request")
+		return nil, errEmptyRequest
 	}
 
 	// Une las líneas de la cabecera y añade el doble salto de línea final
 	headersPart := strings.Join(lines, "\r\n") + "\r\n\r\n"
 
 	// Parsea la parte de las cabeceras para obtener la estructura HttpRequest inicial
-	request, err := ParseRequest(headersPart)
+	request, err := parseRequest(headersPart, strict)
 	if err != nil {
-		return nil, fmt.Errorf("can't parse request: %w", err)
+		return nil, newHeadError(lines, fmt.Errorf("can't parse request: %w", err))
 	}
 
-	// Si el método es POST, exige Content-Length
+	// Si el método es POST, exige Content-Length (o un cuerpo chunked)
 	if request.Method == "POST" {
-		if _, ok := request.Headers["Content-Length"]; !ok {
-			return nil, fmt.Errorf("post request without content length")
+		if request.Header("Content-Length") == "" && !isChunked(request) {
+			return nil, newHeadError(lines, fmt.Errorf("post request without content length"))
 		}
 	}
-	
-	// Parsea el cuerpo de la solicitud si Content-Length existe (o body vacío en otro caso)
-	if err := ParseBody(request, reader); err != nil {
-		return nil, err
-	}
 
 	return request, nil
 }
 
 // Analiza la parte de las cabeceras de una solicitud HTTP (como string).
 // Devuelve un puntero a HttpRequest (sin el cuerpo) o un error.
+// Es tolerante: ignora las líneas de cabecera mal formadas y, si una
+// cabecera se repite, se queda con el último valor.
 func ParseRequest(headersPart string) (*HttpRequest, error) {
+	return parseRequest(headersPart, false)
+}
+
+// Analiza la cabecera de una solicitud con las reglas de RFC 9112, para
+// que ningún intermediario pueda interpretar la solicitud de otra forma
+// (request smuggling). Rechaza con ErrMalformedRequest:
+//   - líneas de inicio que no sean "método SP target SP versión";
+//   - nombres de cabecera que no sean tokens, incluido un espacio antes de ":";
+//   - cabeceras plegadas (obs-fold) y valores con caracteres de control;
+//   - Content-Length o Host repetidos y Content-Length que no sea un número;
+//   - Transfer-Encoding junto con Content-Length, distinto de "chunked" o en HTTP/1.0.
+//
+// Las demás cabeceras repetidas se combinan separadas por comas.
+func ParseRequestStrict(headersPart string) (*HttpRequest, error) {
+	return parseRequest(headersPart, true)
+}
+
+// Error de las solicitudes rechazadas por el análisis estricto.
+var ErrMalformedRequest = errors.New("malformed request")
+
+func parseRequest(headersPart string, strict bool) (*HttpRequest, error) {
 	// Encuentra el final de las cabeceras (doble salto de línea CRLF)
 	headerEndIndex := strings.Index(headersPart, "\r\n\r\n")
 	if headerEndIndex == -1 {
@@ -120,9 +307,18 @@ func ParseRequest(headersPart string) (*HttpRequest, error) {
 
 	// Parsea la línea de inicio (ej: "GET /path HTTP/1.0")
 	start := strings.SplitN(lines[0], " ", 3)
+	if strict {
+		start = strings.Split(lines[0], " ")
+		if len(start) != 3 || !isToken(start[0]) || !validTarget(start[0], start[1]) {
+			return nil, fmt.Errorf("%w: bad request line %q", ErrMalformedRequest, lines[0])
+		}
+	}
 	if len(start) < 2 {
 		return nil, fmt.Errorf("no method or target")
 	}
+	if len(start) < 3 {
+		return nil, fmt.Errorf("no version")
+	}
 
 	// Extrae el método
 	method := start[0]
@@ -135,8 +331,15 @@ func ParseRequest(headersPart string) (*HttpRequest, error) {
 	// Extrae el target (URL)
 	targetStr := start[1]
 
-	// Parsea el target como una URL
-	target, err := url.Parse(targetStr)
+	// Parsea el target como una URL. CONNECT usa la forma de autoridad
+	// ("host:puerto"), que url.Parse interpretaría como esquema y ruta opaca.
+	var target *url.URL
+	var err error
+	if method == "CONNECT" {
+		target, err = parseAuthority(targetStr)
+	} else {
+		target, err = url.Parse(targetStr)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("bad target format: %w", err)
 	}
@@ -152,6 +355,19 @@ func ParseRequest(headersPart string) (*HttpRequest, error) {
 	// Crea un mapa para almacenar las cabeceras
 	headers := make(map[string]string)
 
+	if strict {
+		headers, err := parseStrictHeaders(lines[1:])
+		if err != nil {
+			return nil, err
+		}
+		request := NewHttpRequest(method, target, headers, "")
+		request.Version = version
+		if err := checkFraming(request); err != nil {
+			return nil, err
+		}
+		return request, nil
+	}
+
 	// Procesa cada línea de cabecera (a partir de la segunda línea)
 	for _, line := range lines[1:] {
 		// Divide la línea en clave y valor por el primer ":"
@@ -176,38 +392,62 @@ func ParseRequest(headersPart string) (*HttpRequest, error) {
 	body := ""
 
 	// Crea y devuelve el objeto HttpRequest con los datos parseados
-	return NewHttpRequest(method, target, headers, body), nil
+	request := NewHttpRequest(method, target, headers, body)
+	request.Version = version
+
+	// Con chunked, Content-Length no describe el cuerpo y no debe reenviarse
+	// (RFC 9112, sección 6.3).
+	if isChunked(request) {
+		DeleteHeader(headers, "Content-Length")
+	}
+
+	return request, nil
+}
+
+// Parsea un target en forma de autoridad ("example.com:443", "[::1]:8443").
+func parseAuthority(authority string) (*url.URL, error) {
+	host, port, err := net.SplitHostPort(authority)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" || strings.ContainsAny(host, "/?#@ ") {
+		return nil, fmt.Errorf("invalid host %q", host)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return nil, fmt.Errorf("invalid port %q", port)
+	}
+	return &url.URL{Host: authority}, nil
 }
 
 // Parsea el cuerpo de la solicitud HTTP si existe.
+// Lee Content-Length bytes o decodifica el cuerpo chunked completo, y lo
+// descomprime si usa Content-Encoding gzip o deflate.
 func ParseBody(request *HttpRequest, reader *bufio.Reader) error {
-	// Comprueba si existe la cabecera Content-Length para leer el cuerpo
-	contentLengthStr, ok := request.Headers["Content-Length"]
-	if !ok {
-		return nil
+	body, err := newBodyReader(request, reader)
+	if err != nil {
+		return err
 	}
 
-	// Convierte el valor de Content-Length a entero
-	var contentLength int
-	_, err := fmt.Sscan(contentLengthStr, &contentLength)
+	body, err = decodeBody(request, body, DefaultMaxDecompressedSize)
 	if err != nil {
-		return fmt.Errorf("bad content length format: %w", err)
+		return err
 	}
 
-	// Si hay longitud de contenido, lee el cuerpo
-	if contentLength <= 0 {
+	if body == nil {
 		return nil
 	}
 
-	body := make([]byte, contentLength)
-
-	// Lee exactamente contentLength bytes desde el reader
-	_, err = io.ReadAtLeast(reader, body, contentLength)
+	data, err := io.ReadAll(body)
 	if err != nil {
 		return fmt.Errorf("can't read body: %w", err)
 	}
 
-	request.Body = string(body)
+	if len(data) == 0 {
+		return nil
+	}
+
+	request.RawBody = data
+	request.Body = string(data)
 
 	return nil
 }
@@ -53,6 +53,12 @@ var RejectReadRequestTests = []string{
 	"\r\n\r\n",
 	// bad content length format
 	"GET / HTTP/1.0\r\nContent-Length: A\r\n\r\nContent",
+	"GET / HTTP/1.0\r\nContent-Length: 7abc\r\n\r\nContent",
+	"GET / HTTP/1.0\r\nContent-Length: +7\r\n\r\nContent",
+	"GET / HTTP/1.0\r\nContent-Length: -1\r\n\r\nContent",
+	// unsupported transfer encoding
+	"GET / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: gzip\r\n\r\nContent",
+	"GET / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked, gzip\r\n\r\nContent",
 	// can't read body
 	"GET / HTTP/1.0\r\nContent-Length: 7\r\n\r\n",
 	// post request without content length
@@ -100,8 +106,16 @@ var RejectParseRequestTests = []string{
 	"HTTP/1.0\r\n\r\n",
 	// no target
 	"GET\r\n\r\n",
+	// no version
+	"GET /\r\n\r\n",
 	// bad target format
 	"GET : HTTP/1.0\r\n\r\n",
+	// connect without port
+	"CONNECT example.com HTTP/1.1\r\n\r\n",
+	// connect with origin-form target
+	"CONNECT /path HTTP/1.1\r\n\r\n",
+	// connect with bad port
+	"CONNECT example.com:99999 HTTP/1.1\r\n\r\n",
 }
 
 func TestParseRequestReject(t *testing.T) {
@@ -121,3 +135,21 @@ func TestParseRequestReject(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRequestConnect(t *testing.T) {
+	// Act
+	request, err := ParseRequest("CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	if request.Target.Host != "example.com:443" || request.Target.Scheme != "" || request.Target.Opaque != "" {
+		t.Errorf("Expected authority example.com:443, not %#v", request.Target)
+	}
+
+	if request.Target.Hostname() != "example.com" || request.Target.Port() != "443" {
+		t.Errorf("Expected host example.com and port 443, not %s %s", request.Target.Hostname(), request.Target.Port())
+	}
+}
@@ -1,28 +1,46 @@
 package core
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
-	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 // Representa una respuesta HTTP.
+// El cuerpo puede ser texto (Body), binario (RawBody) o un flujo (BodyReader).
+// Si hay varios definidos, BodyReader tiene prioridad sobre RawBody y RawBody sobre Body.
 type HttpResponse struct {
-	StatusCode int               // Código de estado HTTP (ej. 200, 404).
-	StatusText string            // Texto del estado HTTP (ej. "OK", "Not Found").
-	Headers    map[string]string // Cabeceras HTTP.
-	Body       string            // Cuerpo de la respuesta.
+	StatusCode    int               // Código de estado HTTP (ej. 200, 404).
+	StatusText    string            // Texto del estado HTTP (ej. "OK", "Not Found").
+	Headers       map[string]string // Cabeceras HTTP.
+	Body          string            // Cuerpo de la respuesta como texto.
+	RawBody       []byte            // Cuerpo de la respuesta como bytes (binario).
+	BodyReader    io.Reader         // Cuerpo transmitido desde un lector sin cargarlo en memoria.
+	ContentLength int64             // Longitud de BodyReader en bytes (-1 si se desconoce).
+	Version       string            // Versión del protocolo (por defecto HTTP/1.0).
+	SetCookies    []string          // Valores de Set-Cookie, cada uno en su propia línea (además de Headers).
+	// Con 101 Switching Protocols, recibe la conexión (y el reader con los bytes
+	// ya leídos) después de enviar la cabecera. La conexión se cierra al volver.
+	Upgrade func(conn net.Conn, reader *bufio.Reader)
+	// Si no es nil, el servidor no escribe la respuesta y entrega la conexión
+	// a esta función, que pasa a ser responsable de cerrarla (ver Hijack).
+	Hijack func(conn net.Conn, reader *bufio.Reader)
 }
 
 // Crea una nueva instancia de HttpResponse con los valores proporcionados.
 func NewHttpResponse(statusCode int, statusText string, body string) *HttpResponse {
 	return &HttpResponse{
-		StatusCode: statusCode,
-		StatusText: statusText,
-		Headers:    make(map[string]string),
-		Body:       body,
+		StatusCode:    statusCode,
+		StatusText:    statusText,
+		Headers:       make(map[string]string),
+		Body:          body,
+		ContentLength: -1,
 	}
 }
 
@@ -41,6 +59,16 @@ func BadRequest() *HttpResponse {
 	return NewHttpResponse(400, "Bad Request", "")
 }
 
+// Crea una respuesta HTTP 401 Unauthorized predeterminada.
+func Unauthorized() *HttpResponse {
+	return NewHttpResponse(401, "Unauthorized", "")
+}
+
+// Crea una respuesta HTTP 403 Forbidden predeterminada.
+func Forbidden() *HttpResponse {
+	return NewHttpResponse(403, "Forbidden", "")
+}
+
 // Establece el código de estado de la respuesta.
 func (response *HttpResponse) SetStatusCode(code int) *HttpResponse {
 	response.StatusCode = code
@@ -59,12 +87,75 @@ func (response *HttpResponse) SetHeader(key, value string) *HttpResponse {
 	return response
 }
 
+// Añade un valor a la cabecera Vary si no está ya presente.
+func (response *HttpResponse) AddVary(value string) *HttpResponse {
+	vary, ok := response.Headers["Vary"]
+	if !ok || vary == "" {
+		return response.SetHeader("Vary", value)
+	}
+
+	for _, item := range strings.Split(vary, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" || strings.EqualFold(item, value) {
+			return response
+		}
+	}
+
+	return response.SetHeader("Vary", vary+", "+value)
+}
+
 // Establece el cuerpo de la respuesta.
 func (response *HttpResponse) SetBody(body string) *HttpResponse {
 	response.Body = body
+	response.RawBody = nil
+	response.BodyReader = nil
+	return response
+}
+
+// Establece el cuerpo de la respuesta como bytes sin convertirlo a string.
+func (response *HttpResponse) SetBodyBytes(body []byte) *HttpResponse {
+	response.Body = ""
+	response.RawBody = body
+	response.BodyReader = nil
 	return response
 }
 
+// Establece un lector como cuerpo de la respuesta.
+// length es el número de bytes que se enviarán, o -1 si se desconoce.
+// Si el lector implementa io.Closer se cierra después de enviar la respuesta.
+func (response *HttpResponse) SetBodyReader(reader io.Reader, length int64) *HttpResponse {
+	response.Body = ""
+	response.RawBody = nil
+	response.BodyReader = reader
+	response.ContentLength = length
+	return response
+}
+
+// Indica si el cuerpo de la respuesta se transmite desde un lector.
+func (response *HttpResponse) IsStreamed() bool {
+	return response.BodyReader != nil
+}
+
+// Devuelve el cuerpo en memoria como bytes (RawBody o Body).
+// No incluye el contenido de BodyReader.
+func (response *HttpResponse) BodyBytes() []byte {
+	if response.RawBody != nil {
+		return response.RawBody
+	}
+	return []byte(response.Body)
+}
+
+// Devuelve la longitud del cuerpo en bytes, o -1 si se desconoce.
+func (response *HttpResponse) BodyLength() int64 {
+	if response.BodyReader != nil {
+		return response.ContentLength
+	}
+	if response.RawBody != nil {
+		return int64(len(response.RawBody))
+	}
+	return int64(len(response.Body))
+}
+
 // Establece la cabecera Content-Type.
 func (response *HttpResponse) SetContentType(contentType string) *HttpResponse {
 	response.SetHeader("Content-Type", contentType)
@@ -85,38 +176,169 @@ func (response *HttpResponse) Json(json string) *HttpResponse {
 	return response
 }
 
-// Convierte la respuesta HTTP a su representación en formato de cadena HTTP/1.0.
-// Calcula automáticamente la cabecera Content-Length.
-func (response *HttpResponse) String() string {
+// Indica si el código de estado permite enviar un cuerpo.
+func (response *HttpResponse) allowsBody() bool {
+	code := response.StatusCode
+	return !(code >= 100 && code < 200) && code != 204 && code != 304
+}
+
+// Indica si el cuerpo se envía con Transfer-Encoding: chunked
+// (longitud desconocida en una respuesta HTTP/1.1).
+func (response *HttpResponse) isChunked() bool {
+	return response.Version == "HTTP/1.1" && response.allowsBody() && response.BodyLength() < 0
+}
+
+// Construye la línea de estado y las cabeceras de la respuesta, terminadas en una línea vacía.
+// Calcula automáticamente la cabecera Content-Length cuando la longitud del cuerpo es conocida.
+func (response *HttpResponse) header() []byte {
 	// Calcula y establece la longitud del contenido.
-	contentLength := len(response.Body)
-	response.SetHeader("Content-Length", fmt.Sprint(contentLength))
+	// Las respuestas 1xx, 204 y 304 nunca llevan cuerpo ni Content-Length.
+	// Si la longitud se desconoce, HTTP/1.1 usa chunked y HTTP/1.0 cierra la conexión.
+	// Las que el manejador haya puesto se descartan, con cualquier capitalización.
+	for key := range response.Headers {
+		if strings.EqualFold(key, "Content-Length") || strings.EqualFold(key, "Transfer-Encoding") {
+			delete(response.Headers, key)
+		}
+	}
+	if response.allowsBody() {
+		if length := response.BodyLength(); length >= 0 {
+			response.SetHeader("Content-Length", strconv.FormatInt(length, 10))
+		} else if response.isChunked() {
+			response.SetHeader("Transfer-Encoding", "chunked")
+		}
+	}
 
-	// Formatea las cabeceras.
+	// Ordena las cabeceras para que la salida sea determinista.
 	keys := make([]string, 0, len(response.Headers))
 	for key := range response.Headers {
 		keys = append(keys, key)
 	}
 	sort.Strings(keys)
-	headersStr := ""
+
+	version := response.Version
+	if version == "" {
+		version = "HTTP/1.0"
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(version)
+	buffer.WriteByte(' ')
+	buffer.WriteString(strconv.Itoa(response.StatusCode))
+	buffer.WriteByte(' ')
+	buffer.WriteString(sanitizeFieldValue(response.StatusText))
+	buffer.WriteString("\r\n")
 	for _, key := range keys {
-		headersStr += fmt.Sprintf("%s: %s\r\n", key, response.Headers[key])
+		// Un nombre con espacios, ":" o saltos de línea rompería la respuesta.
+		if !isToken(key) {
+			continue
+		}
+		buffer.WriteString(key)
+		buffer.WriteString(": ")
+		buffer.WriteString(sanitizeFieldValue(response.Headers[key]))
+		buffer.WriteString("\r\n")
+	}
+	for _, cookie := range response.SetCookies {
+		buffer.WriteString("Set-Cookie: ")
+		buffer.WriteString(sanitizeFieldValue(cookie))
+		buffer.WriteString("\r\n")
 	}
+	buffer.WriteString("\r\n")
 
-	// Construye la cadena de respuesta HTTP completa.
-	return fmt.Sprintf("HTTP/1.0 %d %s\r\n%s\r\n%s", response.StatusCode, response.StatusText, headersStr, response.Body)
+	return buffer.Bytes()
 }
 
+// Reemplaza por espacios los caracteres de control de un valor de cabecera,
+// para que un valor con CRLF no pueda añadir cabeceras ni otra respuesta
+// (response splitting).
+func sanitizeFieldValue(value string) string {
+	if validFieldValue(value) {
+		return value
+	}
+	return strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\t') || r == 0x7F {
+			return ' '
+		}
+		return r
+	}, value)
+}
+
+// Convierte la respuesta HTTP a su representación en formato de cadena HTTP/1.0.
+// Calcula automáticamente la cabecera Content-Length.
+// Los cuerpos transmitidos desde un lector no se incluyen.
+func (response *HttpResponse) String() string {
+	head := response.header()
+	if response.IsStreamed() || !response.allowsBody() {
+		return string(head)
+	}
+	return string(head) + string(response.BodyBytes())
+}
+
+// Escribe la respuesta completa en w.
+// Los cuerpos en memoria se envían junto con las cabeceras sin copiarlos,
+// y los cuerpos de un lector se copian con io.Copy, que usa sendfile cuando
+// w es una conexión TCP y el lector es un archivo.
+func (response *HttpResponse) WriteTo(w io.Writer) (int64, error) {
+	return response.write(w, true)
+}
+
+// Escribe la respuesta en w; si withBody es false solo envía la cabecera
+// (por ejemplo, para solicitudes HEAD) manteniendo Content-Length.
+func (response *HttpResponse) write(w io.Writer, withBody bool) (int64, error) {
+	head := response.header()
+
+	if !withBody || !response.allowsBody() {
+		if closer, ok := response.BodyReader.(io.Closer); ok {
+			closer.Close()
+		}
+		n, err := w.Write(head)
+		return int64(n), err
+	}
+
+	if !response.IsStreamed() {
+		buffers := net.Buffers{head}
+		if body := response.BodyBytes(); len(body) > 0 {
+			buffers = append(buffers, body)
+		}
+		return buffers.WriteTo(w)
+	}
+
+	if closer, ok := response.BodyReader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	written, err := w.Write(head)
+	if err != nil {
+		return int64(written), err
+	}
+
+	reader := response.BodyReader
+	if response.ContentLength >= 0 {
+		reader = io.LimitReader(reader, response.ContentLength)
+	}
+
+	if response.isChunked() {
+		chunked := &chunkedWriter{writer: w}
+		n, err := io.Copy(chunked, reader)
+		if err != nil {
+			return int64(written) + n, err
+		}
+		return int64(written) + n, chunked.Close()
+	}
+
+	n, err := io.Copy(w, reader)
+	return int64(written) + n, err
+}
+
+// Envía la respuesta por la conexión.
 func (response *HttpResponse) WriteResponse(conn net.Conn) error {
 	slog.Info("Response", "address", conn.RemoteAddr().String(), "status_code", response.StatusCode, "status_text", response.StatusText)
 
-	_, err := conn.Write([]byte(response.String()))
+	_, err := response.WriteTo(conn)
 	if err != nil {
 		return err
 	}
 
 	return nil
-
 }
 
 // JsonObj serializa v a JSON y lo pone en el body con application/json.
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHttpResponseBodyBytes(t *testing.T) {
+	// Arrange
+	data := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, '\r', '\n'}
+
+	// Act
+	response := Ok().SetContentType("image/png").SetBodyBytes(data)
+
+	var buffer bytes.Buffer
+	_, err := response.WriteTo(&buffer)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	expected := append([]byte("HTTP/1.0 200 OK\r\nContent-Length: 8\r\nContent-Type: image/png\r\n\r\n"), data...)
+
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("Expected message to be %q, not %q", expected, buffer.Bytes())
+	}
+
+	if response.String() != string(expected) {
+		t.Errorf("Expected String to be %q, not %q", expected, response.String())
+	}
+}
+
+func TestHttpResponseBodyReader(t *testing.T) {
+	// Arrange
+	response := Ok().SetBodyReader(strings.NewReader("Content and more"), 7)
+
+	// Act
+	var buffer bytes.Buffer
+	_, err := response.WriteTo(&buffer)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	expected := "HTTP/1.0 200 OK\r\nContent-Length: 7\r\n\r\nContent"
+
+	if buffer.String() != expected {
+		t.Errorf("Expected message to be %q, not %q", expected, buffer.String())
+	}
+}
+
+func TestHttpResponseBodyReaderUnknownLength(t *testing.T) {
+	// Arrange
+	response := Ok().SetHeader("Content-Length", "99").SetBodyReader(strings.NewReader("Content"), -1)
+
+	// Act
+	var buffer bytes.Buffer
+	_, err := response.WriteTo(&buffer)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	expected := "HTTP/1.0 200 OK\r\n\r\nContent"
+
+	if buffer.String() != expected {
+		t.Errorf("Expected message to be %q, not %q", expected, buffer.String())
+	}
+
+	if response.String() != "HTTP/1.0 200 OK\r\n\r\n" {
+		t.Errorf("Expected String to omit streamed body, not %q", response.String())
+	}
+}
+
+func TestHttpResponseBodyFileOverTcp(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "data.bin")
+	data := bytes.Repeat([]byte{0x00, 0x01, 0xfe, 0xff}, 64*1024)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	defer ln.Close()
+
+	// Act
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		Ok().SetBodyReader(file, int64(len(data))).WriteResponse(conn)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	defer conn.Close()
+
+	message, err := io.ReadAll(conn)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	head := "HTTP/1.0 200 OK\r\nContent-Length: 262144\r\n\r\n"
+	if !bytes.HasPrefix(message, []byte(head)) {
+		t.Fatalf("Expected message to start with %q", head)
+	}
+
+	if !bytes.Equal(message[len(head):], data) {
+		t.Errorf("Expected body to match file contents")
+	}
+}
+
+func TestReadRequestBinaryBody(t *testing.T) {
+	// Arrange
+	conn1, conn2 := net.Pipe()
+
+	defer conn2.Close()
+
+	data := []byte{0x00, 0xff, '\r', '\n', '\r', '\n', 0x7f}
+
+	go func() {
+		conn1.Write(append([]byte("POST /upload HTTP/1.0\r\nContent-Length: 7\r\n\r\n"), data...))
+		conn1.Close()
+	}()
+
+	// Act
+	request, err := ReadRequest(conn2)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	if !bytes.Equal(request.RawBody, data) {
+		t.Errorf("Expected raw body to be %v, not %v", data, request.RawBody)
+	}
+
+	if !bytes.Equal(request.BodyBytes(), data) {
+		t.Errorf("Expected body bytes to be %v, not %v", data, request.BodyBytes())
+	}
+}
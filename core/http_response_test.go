@@ -120,3 +120,31 @@ func TestHttpResponseWriteResponse(t *testing.T) {
 		t.Errorf("Expected message to be %s, not %s", expected, message)
 	}
 }
+
+func TestHttpResponseHeaderInjection(t *testing.T) {
+	// Arrange
+	response := Ok().SetBody("ok").
+		SetStatusText("OK\r\nX-Injected: 1").
+		SetHeader("Location", "/next\r\nSet-Cookie: session=stolen").
+		SetHeader("Bad Name", "value").
+		SetHeader("content-length", "100")
+
+	// Act
+	message := response.String()
+
+	// Assert
+	expected := "HTTP/1.0 200 OK  X-Injected: 1\r\nContent-Length: 2\r\nLocation: /next  Set-Cookie: session=stolen\r\n\r\nok"
+	if message != expected {
+		t.Errorf("Expected message to be %q, not %q", expected, message)
+	}
+}
+
+func TestHttpResponseNoContentString(t *testing.T) {
+	// Act
+	message := NewHttpResponse(204, "No Content", "ignored").String()
+
+	// Assert
+	if message != "HTTP/1.0 204 No Content\r\n\r\n" {
+		t.Errorf("Expected no body for 204, not %q", message)
+	}
+}
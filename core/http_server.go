@@ -1,81 +1,192 @@
 package core
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"os"
 	"os/signal"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 // Define el tipo para las funciones que manejan las solicitudes HTTP.
 // Recibe un puntero a HttpRequest y devuelve un puntero a HttpResponse y un error.
 type Handle func(request *HttpRequest) (*HttpResponse, error)
 
+// Define el tipo para las funciones intermedias (middleware).
+// Recibe el siguiente Handle de la cadena y devuelve uno nuevo que lo envuelve.
+type Middleware func(next Handle) Handle
+
+// Envuelve handle con los middlewares; el primero de la lista es el más externo.
+func Chain(handle Handle, middlewares ...Middleware) Handle {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handle = middlewares[i](handle)
+	}
+	return handle
+}
+
 // Representa un manejador para una ruta y método HTTP específicos.
 type Handler struct {
-	Method string // Método HTTP (ej. "GET", "POST")
-	Path   string // Ruta de la URL (ej. "/users")
-	Handle Handle // Función que manejará la solicitud
+	Method      string       // Método HTTP (ej. "GET", "POST")
+	Path        string       // Ruta de la URL (ej. "/users")
+	Handle      Handle       // Función que manejará la solicitud
+	Stream      bool         // Si es true, el cuerpo se entrega sin leer en HttpRequest.BodyReader
+	Middlewares []Middleware // Middlewares aplicados solo a esta ruta
+	Roles       []string     // Roles aceptados (basta con uno); vacío si no se exige ninguno
+	Scopes      []string     // Permisos exigidos (todos); vacío si no se exige ninguno
+}
+
+// Añade middlewares que se aplican solo a esta ruta, después de los globales.
+func (handler *Handler) Use(middlewares ...Middleware) *Handler {
+	handler.Middlewares = append(handler.Middlewares, middlewares...)
+	return handler
+}
+
+// Exige que el principal autenticado tenga al menos uno de los roles dados.
+// Sin principal responde 401 y con un rol insuficiente 403.
+func (handler *Handler) RequireRoles(roles ...string) *Handler {
+	handler.Roles = append(handler.Roles, roles...)
+	return handler
+}
+
+// Exige que el principal autenticado tenga todos los permisos (scopes) dados.
+func (handler *Handler) RequireScopes(scopes ...string) *Handler {
+	handler.Scopes = append(handler.Scopes, scopes...)
+	return handler
+}
+
+// Devuelve el Handle de la ruta con sus middlewares y la comprobación de
+// roles y permisos, que se ejecuta después de la autenticación.
+func (handler *Handler) chain() Handle {
+	authorized := func(request *HttpRequest) (*HttpResponse, error) {
+		if denied := Authorize(request, handler.Roles, handler.Scopes); denied != nil {
+			return denied, nil
+		}
+		// Con "Expect: 100-continue" el cuerpo se pide solo ahora.
+		if invalid := request.loadBody(); invalid != nil {
+			return invalid, nil
+		}
+		return handler.Handle(request)
+	}
+	return Chain(authorized, handler.Middlewares...)
+}
+
+// Indica que el manejador recibe el cuerpo de la solicitud en streaming
+// (HttpRequest.BodyReader) en lugar de cargarlo completo en memoria.
+func (handler *Handler) StreamBody() *Handler {
+	handler.Stream = true
+	return handler
 }
 
 // Representa el servidor HTTP.
 type HttpServer struct {
-	Handlers []Handler    // Lista de manejadores registrados
-	Listener net.Listener // Listener para aceptar conexiones
+	Handlers            []*Handler     // Lista de manejadores registrados
+	Middlewares         []Middleware   // Middlewares globales, aplicados a todas las solicitudes
+	Listener            net.Listener   // Listener para aceptar conexiones
+	IdleTimeout         time.Duration  // Tiempo máximo de espera de la siguiente solicitud en una conexión persistente
+	AutoETag            bool           // Si es true, añade ETag a las respuestas en memoria y responde 304/412
+	WeakETags           bool           // Si es true, los ETag automáticos son débiles (W/"...")
+	Compression         *Compression   // Configuración de compresión de respuestas (nil la desactiva)
+	MaxDecompressedSize int64          // Tamaño máximo de un cuerpo de solicitud descomprimido (0 usa DefaultMaxDecompressedSize)
+	MaxBodySize         int64          // Tamaño máximo del cuerpo recibido (0 sin límite); con Content-Length se rechaza con 413 antes de leerlo
+	TrustedProxies      []*net.IPNet   // Redes de los proxies cuyas cabeceras Forwarded/X-Forwarded-* y PROXY se aceptan
+	ProxyProtocol       bool           // Si es true, las conexiones de proxies de confianza deben empezar con una cabecera PROXY v1/v2
+	Hosts               []*VirtualHost // Hosts virtuales; Handlers es el host por defecto
+	Proxy               Handle         // Si no es nil, atiende CONNECT y los targets absolutos (modo proxy de reenvío)
+	Pipelining          int            // Solicitudes en pipeline procesadas a la vez por conexión (0 o 1: una tras otra)
+	StrictParsing       bool           // Si es true, las solicitudes se analizan con las reglas estrictas de RFC 9112 (ver ParseRequestStrict)
+	// Si no es nil, recibe las conexiones HTTP/2 sin TLS: las que empiezan con
+	// el prefacio de HTTP/2 (upgrade es nil) y las que piden "Upgrade: h2c"
+	// (upgrade es esa solicitud, con el cuerpo ya leído, y la respuesta 101 ya enviada).
+	HTTP2 func(conn net.Conn, reader *bufio.Reader, remoteAddr string, upgrade *HttpRequest)
+
+	conns      connTracker // Conexiones abiertas
+	listenerMu sync.Mutex  // Protege Listener entre Serve y Stop
 }
 
 // Crea una nueva instancia de HttpServer.
 func NewHttpServer() *HttpServer {
 	return &HttpServer{
-		Handlers: []Handler{},
+		Handlers:    []*Handler{},
+		IdleTimeout: 30 * time.Second,
+		AutoETag:    true,
 	}
 }
 
 // Agrega un nuevo manejador al servidor.
-func (server *HttpServer) AddHandler(method, path string, handle Handle) {
-	handler := Handler{
+func (server *HttpServer) AddHandler(method, path string, handle Handle) *Handler {
+	handler := &Handler{
 		Method: method,
 		Path:   path,
 		Handle: handle,
 	}
 
 	server.Handlers = append(server.Handlers, handler)
+
+	return handler
+}
+
+// Añade middlewares globales. Se ejecutan antes de buscar la ruta, por lo que
+// también ven las solicitudes que terminarán en 404 o con método incorrecto.
+func (server *HttpServer) Use(middlewares ...Middleware) {
+	server.Middlewares = append(server.Middlewares, middlewares...)
 }
 
 // Un atajo para agregar un manejador para el método GET.
-func (server *HttpServer) Get(path string, handle Handle) {
-	server.AddHandler("GET", path, handle)
+func (server *HttpServer) Get(path string, handle Handle) *Handler {
+	return server.AddHandler("GET", path, handle)
+}
+
+// Un atajo para agregar un manejador para el método HEAD.
+func (server *HttpServer) Head(path string, handle Handle) *Handler {
+	return server.AddHandler("HEAD", path, handle)
 }
 
 // Un atajo para agregar un manejador para el método POST.
-func (server *HttpServer) Post(path string, handle Handle) {
-	server.AddHandler("POST", path, handle)
+func (server *HttpServer) Post(path string, handle Handle) *Handler {
+	return server.AddHandler("POST", path, handle)
+}
+
+// Un atajo para agregar un manejador para el método PUT.
+func (server *HttpServer) Put(path string, handle Handle) *Handler {
+	return server.AddHandler("PUT", path, handle)
 }
 
 // Un atajo para agregar un manejador para el método DELETE.
-func (server *HttpServer) Delete(path string, handle Handle) {
-	server.AddHandler("DELETE", path, handle)
+func (server *HttpServer) Delete(path string, handle Handle) *Handler {
+	return server.AddHandler("DELETE", path, handle)
 }
 
 // Ordena los manejadores por la especificidad de la ruta (más segmentos primero).
 func (server *HttpServer) SortHandlers() {
-	sort.Slice(server.Handlers, func(i, j int) bool {
+	sortHandlers(server.Handlers)
+	for _, host := range server.Hosts {
+		sortHandlers(host.Handlers)
+	}
+}
+
+// Ordena una tabla de rutas por especificidad.
+func sortHandlers(handlers []*Handler) {
+	sort.Slice(handlers, func(i, j int) bool {
 		// Cuenta el número de '/' en cada ruta.
-		iCount := strings.Count(server.Handlers[i].Path, "/")
-		jCount := strings.Count(server.Handlers[j].Path, "/")
+		iCount := strings.Count(handlers[i].Path, "/")
+		jCount := strings.Count(handlers[j].Path, "/")
 
 		if iCount != jCount {
 			// Ordena de forma descendente por el número de segmentos.
 			return iCount > jCount
 		}
 
-		iLen := len(server.Handlers[i].Path)
-		jLen := len(server.Handlers[j].Path)
+		iLen := len(handlers[i].Path)
+		jLen := len(handlers[j].Path)
 
 		return iLen > jLen
 	})
@@ -98,18 +209,12 @@ func MatchPath(requestPath, handlerPath string) bool {
 
 // Inicia el servidor HTTP en el puerto especificado.
 func (server *HttpServer) Start(port int) error {
-	// Ordena los manejadores antes de empezar a escuchar.
-	server.SortHandlers()
-
 	// Empieza a escuchar conexiones TCP en el puerto dado.
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return err
 	}
 
-	// Asigna el listener al servidor.
-	server.Listener = ln
-
 	// Canal para recibir señales del sistema operativo (SIGINT, SIGTERM).
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -123,6 +228,21 @@ func (server *HttpServer) Start(port int) error {
 		server.Stop()
 	}()
 
+	return server.Serve(ln)
+}
+
+// Atiende las conexiones del listener dado hasta que se llame a Stop.
+// Permite usar un puerto efímero (":0"), por ejemplo en pruebas.
+func (server *HttpServer) Serve(ln net.Listener) error {
+	// Ordena los manejadores antes de empezar a aceptar conexiones.
+	server.SortHandlers()
+
+	// Asigna el listener al servidor. Stop puede leerlo desde otra goroutine.
+	server.listenerMu.Lock()
+	server.Listener = ln
+	server.listenerMu.Unlock()
+	server.conns.setClosing(false)
+
 	slog.Info("Server started", "address", ln.Addr().String())
 
 	// Bucle principal para aceptar conexiones entrantes.
@@ -147,11 +267,18 @@ func (server *HttpServer) Start(port int) error {
 	}
 }
 
-// Detiene el servidor HTTP.
+// Detiene el servidor HTTP: deja de aceptar conexiones, cierra las inactivas
+// y las tomadas por manejadores, y las que atienden una solicitud se cierran
+// tras responderla con "Connection: close".
 func (server *HttpServer) Stop() {
-	if server.Listener != nil {
-		server.Listener.Close()
+	server.listenerMu.Lock()
+	ln := server.Listener
+	server.listenerMu.Unlock()
+
+	if ln != nil {
+		ln.Close()
 	}
+	server.conns.setClosing(true)
 }
 
 // Un envoltorio para Handle que registra cualquier error ocurrido durante el manejo de la conexión.
@@ -163,24 +290,352 @@ func (server *HttpServer) HandleWithError(conn net.Conn) {
 }
 
 // Maneja una conexión individual.
+// Atiende solicitudes sucesivas mientras la conexión sea persistente.
 func (server *HttpServer) Handle(conn net.Conn) error {
-	// Asegura que la conexión se cierre al final de la función.
-	defer conn.Close()
+	// Asegura que la conexión se cierre al final de la función,
+	// salvo que un manejador la haya tomado.
+	server.conns.add(conn)
+	hijacked := false
+	defer func() {
+		if !hijacked {
+			server.conns.remove(conn)
+			conn.Close()
+		}
+	}()
 
-	// Lee y parsea la solicitud HTTP de la conexión.
-	request, err := ReadRequest(conn)
-	if err != nil {
-		// En lugar de cerrar sin responder, devolvemos 400 Bad Request con el mensaje de error
-		resp := BadRequest().Text(err.Error())
-		resp.WriteResponse(conn)
+	// Un único reader por conexión para no perder bytes entre solicitudes.
+	reader := bufio.NewReader(conn)
+
+	// Detrás de un balanceador con protocolo PROXY, la dirección real del
+	// cliente llega en una cabecera al inicio de la conexión.
+	remoteAddr := conn.RemoteAddr().String()
+	if server.ProxyProtocol && server.trustedProxy(remoteAddr) {
+		source, err := readProxyHeader(reader)
+		if err != nil {
+			slog.Warn("Invalid PROXY protocol header", "address", remoteAddr, "error", err)
+			return nil
+		}
+		if source != "" {
+			remoteAddr = source
+		}
+	}
+
+	// Con conocimiento previo, el cliente HTTP/2 empieza con el prefacio.
+	if server.HTTP2 != nil && isHTTP2Preface(reader) {
+		slog.Info("HTTP/2 connection", "address", remoteAddr)
+		server.conns.hijack(conn)
+		server.HTTP2(conn, reader, remoteAddr, nil)
 		return nil
 	}
 
-	slog.Info("Request", "address", conn.RemoteAddr().String(), "method", request.Method, "path", request.Target.Path)
+	// Las solicitudes en pipeline pueden procesarse a la vez.
+	if server.Pipelining > 1 {
+		err := server.servePipelined(conn, reader, remoteAddr)
+		hijacked = errors.Is(err, errHijacked)
+		return nil
+	}
+
+	for served := 0; ; served++ {
+		// Entre solicitudes de una conexión persistente se limita la espera.
+		if served > 0 && server.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(server.IdleTimeout))
+		}
+
+		// Lee y parsea la cabecera de la solicitud HTTP de la conexión.
+		request, err := readRequestHead(reader, server.StrictParsing)
+		if served > 0 && err != nil && !errors.As(err, new(*headError)) {
+			// El cliente cerró la conexión persistente o expiró la espera.
+			return nil
+		}
+		if err != nil {
+			// En lugar de cerrar sin responder, devolvemos 400 Bad Request con el mensaje de error
+			writeBadRequest(conn, err)
+			return nil
+		}
+
+		conn.SetReadDeadline(time.Time{})
+
+		request.RemoteAddr = remoteAddr
+		request.serverDone = server.conns.done()
+		server.applyForwarded(request)
+
+		server.conns.begin(conn)
+		keepAlive, err := server.serve(conn, reader, request)
+		server.conns.end(conn)
+		if errors.Is(err, errHijacked) {
+			hijacked = true
+			return nil
+		}
+		if err != nil || !keepAlive {
+			return nil
+		}
+	}
+}
+
+// Prefacio con el que empieza una conexión HTTP/2 (RFC 9113, sección 3.4).
+const HTTP2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// Indica si la conexión empieza con el prefacio de HTTP/2, sin consumirlo.
+// Primero mira solo "PRI", que no es un método de HTTP/1.x, para no
+// bloquearse esperando 24 bytes de una solicitud HTTP/1.x más corta.
+func isHTTP2Preface(reader *bufio.Reader) bool {
+	if prefix, err := reader.Peek(3); err != nil || string(prefix) != "PRI" {
+		return false
+	}
+	preface, err := reader.Peek(len(HTTP2Preface))
+	return err == nil && string(preface) == HTTP2Preface
+}
+
+// Atiende una solicitud cuya cabecera ya fue leída y escribe la respuesta.
+// Devuelve si la conexión puede reutilizarse para otra solicitud.
+func (server *HttpServer) serve(conn net.Conn, reader *bufio.Reader, request *HttpRequest) (bool, error) {
+	slog.Info("Request", "address", request.RemoteAddr, "client", request.ClientIP(), "method", request.Method, "path", request.Target.Path)
+
+	// HTTP/1.1 exige la cabecera Host (RFC 9112, sección 3.2).
+	if request.Version == "HTTP/1.1" && request.Header("Host") == "" && request.Target.Host == "" {
+		_ = BadRequest().Text("missing host header").WriteResponse(conn)
+		return false, nil
+	}
+
+	handler, pathMatched := server.FindHandler(request)
+
+	body, errResp := server.requestBody(request, reader)
+	if errResp != nil {
+		_, err := server.writeResponse(conn, request, errResp, false)
+		return false, err
+	}
+
+	// Con "Expect: 100-continue" el cliente espera a que se pida el cuerpo.
+	var pending *continueReader
+	if body != nil && expectsContinue(request) {
+		pending = &continueReader{reader: body, writer: conn}
+		body = pending
+	}
+
+	// Cambio a HTTP/2 (h2c): el cuerpo se lee completo antes de responder 101.
+	upgradeH2C := server.HTTP2 != nil && isH2CUpgrade(request)
+	if upgradeH2C {
+		handler = nil
+	}
+
+	if pending != nil && !upgradeH2C && !server.proxied(request) && (handler == nil || !handler.Stream) {
+		// El cuerpo se lee justo antes del manejador, después de los
+		// middlewares y la autorización, que pueden rechazar sin pedirlo.
+		// Sin manejador (404, método incorrecto) no se pide nunca.
+		if handler != nil {
+			request.pendingBody = func() *HttpResponse {
+				errResp, _ := server.prepareBody(handler, request, body)
+				return errResp
+			}
+		}
+	} else if errResp, err := server.prepareBody(handler, request, body); errResp != nil {
+		_, _ = server.writeResponse(conn, request, errResp, false)
+		return false, err
+	}
+
+	if upgradeH2C {
+		slog.Info("Response", "address", request.RemoteAddr, "client", request.ClientIP(), "status_code", 101, "status_text", "Switching Protocols")
+		_, err := conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n"))
+		if err != nil {
+			return false, err
+		}
+		server.conns.hijack(conn)
+		server.HTTP2(conn, reader, request.RemoteAddr, request)
+		return false, nil
+	}
+
+	resp := server.dispatch(handler, pathMatched, request)
+	if resp == nil {
+		resp = NewHttpResponse(500, "Internal Server Error", "500 Internal Server Error")
+	}
+
+	// El manejador toma la conexión: no se escribe respuesta.
+	if resp.Hijack != nil {
+		slog.Info("Connection hijacked", "address", request.RemoteAddr, "client", request.ClientIP(), "path", request.Target.Path)
+		server.conns.hijack(conn)
+		resp.Hijack(&hijackedConn{Conn: conn, tracker: &server.conns}, reader)
+		return false, errHijacked
+	}
+
+	resp = server.finish(request, resp)
+
+	// Cambio de protocolo (ej. WebSocket): la conexión pasa al manejador del nuevo protocolo.
+	if resp.StatusCode == 101 && resp.Upgrade != nil {
+		resp.Version = "HTTP/1.1"
+		slog.Info("Response", "address", request.RemoteAddr, "client", request.ClientIP(), "status_code", resp.StatusCode, "status_text", resp.StatusText)
+		if _, err := resp.write(conn, false); err != nil {
+			return false, err
+		}
+		// Se cuenta como tomada para que Stop la cierre.
+		server.conns.hijack(conn)
+		resp.Upgrade(conn, reader)
+		return false, nil
+	}
+
+	// Si no se pidió el cuerpo, el cliente puede enviarlo o no: se cierra la conexión.
+	keepAlive, err := server.writeResponse(conn, request, resp, pending == nil || pending.sent)
+	if err != nil {
+		return false, err
+	}
+
+	// Descarta el resto del cuerpo que el manejador no leyó.
+	if keepAlive {
+		if err := discardBody(body); err != nil {
+			return false, nil
+		}
+	}
+
+	return keepAlive, nil
+}
+
+// Responde 400 a una solicitud que no se pudo leer, con la versión de su
+// línea de inicio si se conoce. La conexión se cierra a continuación.
+func writeBadRequest(conn net.Conn, err error) {
+	resp := BadRequest().Text(err.Error())
+	var head *headError
+	if errors.As(err, &head) && head.version == "HTTP/1.1" {
+		resp.Version = "HTTP/1.1"
+		resp.SetHeader("Connection", "close")
+	}
+	resp.WriteResponse(conn)
+}
+
+// Escribe la respuesta a la solicitud con las cabeceras de conexión.
+// Con reuse en false la conexión se cierra tras la respuesta.
+// Devuelve si la conexión puede reutilizarse para otra solicitud.
+func (server *HttpServer) writeResponse(conn net.Conn, request *HttpRequest, resp *HttpResponse, reuse bool) (bool, error) {
+	// Solo se reutiliza la conexión si el cliente lo admite y el final del cuerpo
+	// se puede delimitar (longitud conocida o chunked en HTTP/1.1).
+	// Si el servidor se está deteniendo, la conexión se cierra tras la respuesta.
+	keepAlive := reuse && !server.conns.shuttingDown() && request.KeepAlive() && (resp.BodyLength() >= 0 || request.Version == "HTTP/1.1")
+	if request.Version == "HTTP/1.1" {
+		resp.Version = "HTTP/1.1"
+		if !keepAlive {
+			resp.SetHeader("Connection", "close")
+		}
+	} else if keepAlive {
+		resp.SetHeader("Connection", "keep-alive")
+	}
+
+	slog.Info("Response", "address", request.RemoteAddr, "client", request.ClientIP(), "status_code", resp.StatusCode, "status_text", resp.StatusText)
+
+	// Las respuestas a HEAD llevan las cabeceras pero no el cuerpo.
+	if _, err := resp.write(conn, request.Method != "HEAD"); err != nil {
+		return false, err
+	}
+
+	return keepAlive, nil
+}
+
+// Descomprime el cuerpo y lo deja en la solicitud: como BodyReader para los
+// manejadores con StreamBody y el proxy de reenvío, o leído en Body y RawBody.
+// Si el cuerpo no es válido devuelve la respuesta de error (415, 413 o 400).
+func (server *HttpServer) prepareBody(handler *Handler, request *HttpRequest, body io.Reader) (*HttpResponse, error) {
+	// Descomprime de forma transparente los cuerpos gzip/deflate.
+	decoded, err := decodeBody(request, body, server.maxDecompressedSize())
+	if err != nil {
+		return NewHttpResponse(415, "Unsupported Media Type", "").
+			SetHeader("Accept-Encoding", "gzip, deflate").
+			Text(err.Error()), err
+	}
+
+	if (handler != nil && handler.Stream) || server.proxied(request) {
+		// El manejador leerá el cuerpo directamente de la conexión.
+		request.BodyReader = decoded
+	} else if decoded != nil {
+		data, err := io.ReadAll(decoded)
+		if errors.Is(err, ErrBodyTooLarge) {
+			return NewHttpResponse(413, "Payload Too Large", "").Text(err.Error()), err
+		}
+		if err != nil {
+			return BadRequest().Text(fmt.Sprintf("can't read body: %v", err)), err
+		}
+		if len(data) > 0 {
+			request.RawBody = data
+			request.Body = string(data)
+		}
+	}
+
+	return nil, nil
+}
+
+// Aplica a la respuesta la compresión y las peticiones condicionales.
+func (server *HttpServer) finish(request *HttpRequest, resp *HttpResponse) *HttpResponse {
+	if server.Compression != nil {
+		resp = server.Compression.Apply(request, resp)
+	}
+
+	if server.AutoETag {
+		resp = applyConditional(request, resp, server.WeakETags)
+	}
+
+	return resp
+}
+
+// Dispatch atiende una solicitud recibida por otro protocolo (por ejemplo,
+// un stream de HTTP/2) con el mismo proceso que HTTP/1.x: cabeceras de
+// proxies, middlewares, enrutamiento, compresión y peticiones condicionales.
+// El cuerpo se toma de request.BodyReader y se lee completo salvo para los
+// manejadores con StreamBody. request.RemoteAddr debe estar definido.
+func (server *HttpServer) Dispatch(request *HttpRequest) *HttpResponse {
+	server.applyForwarded(request)
+	slog.Info("Request", "address", request.RemoteAddr, "client", request.ClientIP(), "method", request.Method, "path", request.Target.Path, "version", request.Version)
+
+	handler, pathMatched := server.FindHandler(request)
+
+	body := request.BodyReader
+	request.BodyReader = nil
+	if body == nil && len(request.BodyBytes()) > 0 && handler != nil && handler.Stream {
+		body = bytes.NewReader(request.BodyBytes())
+	}
+	if errResp, _ := server.prepareBody(handler, request, body); errResp != nil {
+		return errResp
+	}
+
+	resp := server.dispatch(handler, pathMatched, request)
+	if resp == nil {
+		resp = NewHttpResponse(500, "Internal Server Error", "500 Internal Server Error")
+	}
+	return server.finish(request, resp)
+}
+
+// Indica si la solicitud pide cambiar a HTTP/2 sin TLS (RFC 7540, sección 3.2).
+func isH2CUpgrade(request *HttpRequest) bool {
+	if request.Version != "HTTP/1.1" || request.Header("HTTP2-Settings") == "" {
+		return false
+	}
+	return hasToken(request.Header("Upgrade"), "h2c") && hasToken(request.Header("Connection"), "http2-settings")
+}
+
+// Indica si la lista separada por comas contiene el token (sin distinguir mayúsculas).
+func hasToken(list, token string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Devuelve el límite de tamaño de los cuerpos descomprimidos.
+func (server *HttpServer) maxDecompressedSize() int64 {
+	if server.MaxDecompressedSize > 0 {
+		return server.MaxDecompressedSize
+	}
+	return DefaultMaxDecompressedSize
+}
+
+// Busca el manejador que corresponde al método y ruta de la solicitud.
+// Devuelve también si la ruta existe aunque el método no coincida.
+func (server *HttpServer) FindHandler(request *HttpRequest) (*Handler, bool) {
+	return findHandler(server.handlersFor(request), request)
+}
 
-	// Dispatch con detección de método incorrecto
+// Busca en una tabla de rutas el manejador para el método y la ruta.
+// Devuelve también si la ruta existe con algún método.
+func findHandler(handlers []*Handler, request *HttpRequest) (*Handler, bool) {
 	var pathMatched bool
-	for _, handler := range server.Handlers {
+	for _, handler := range handlers {
 		if !MatchPath(request.Target.Path, handler.Path) {
 			continue
 		}
@@ -190,28 +645,102 @@ func (server *HttpServer) Handle(conn net.Conn) error {
 			// Método no soportado en esta ruta
 			continue
 		}
-		
+
+		return handler, true
+	}
+
+	return nil, pathMatched
+}
+
+// Ejecuta los middlewares globales y después el manejador encontrado (con sus
+// propios middlewares) o genera la respuesta de error correspondiente.
+func (server *HttpServer) dispatch(handler *Handler, pathMatched bool, request *HttpRequest) *HttpResponse {
+	final := func(request *HttpRequest) (*HttpResponse, error) {
+		return server.route(handler, pathMatched, request), nil
+	}
+
+	// Los middlewares del host virtual se ejecutan después de los globales.
+	if host := server.hostFor(request); host != nil {
+		final = Chain(final, host.Middlewares...)
+	}
+
+	resp, err := Chain(final, server.Middlewares...)(request)
+	if err != nil {
+		return NewHttpResponse(500, "Internal Server Error", "500 Internal Server Error")
+	}
+
+	return resp
+}
+
+// Ejecuta el manejador de la ruta o genera la respuesta de error correspondiente.
+func (server *HttpServer) route(handler *Handler, pathMatched bool, request *HttpRequest) *HttpResponse {
+	if server.proxied(request) {
+		// Solicitud para otro servidor → proxy de reenvío
+		resp, err := server.Proxy(request)
+		if err != nil || resp == nil {
+			resp = NewHttpResponse(500, "Internal Server Error", "500 Internal Server Error")
+		}
+		return resp
+	}
+
+	if handler != nil {
 		// Método y ruta coinciden → ejecutar handler
-		resp, err := handler.Handle(request)
-		if err != nil {
-			resp = &HttpResponse{
-				StatusCode: 500,
-				StatusText: "Internal Server Error",
-				Headers:    map[string]string{},
-				Body:       "500 Internal Server Error",
-			}
+		resp, err := handler.chain()(request)
+		if err != nil || resp == nil {
+			resp = NewHttpResponse(500, "Internal Server Error", "500 Internal Server Error")
 		}
-		_ = resp.WriteResponse(conn)
-		return nil
+		return resp
+	}
+
+	if pathMatched && request.Method == "OPTIONS" {
+		// OPTIONS sobre una ruta conocida → métodos permitidos
+		methods := allowedMethods(server.handlersFor(request), request.Target.Path)
+		return NewHttpResponse(204, "No Content", "").SetHeader("Allow", strings.Join(methods, ", "))
+	}
+
+	if server.misdirected(request) {
+		// Ningún host virtual atiende este Host y no hay host por defecto → 421
+		return NewHttpResponse(421, "Misdirected Request", "").Text("421 Misdirected Request")
 	}
-	
+
 	if pathMatched {
 		// Ruta conocida + método incorrecto → 400 Bad Request
-		_ = BadRequest().Text("Bad method").WriteResponse(conn)
-		return nil
+		return BadRequest().Text("Bad method")
 	}
-	
+
 	// Ruta desconocida → 404 Not Found
-	_ = NotFound().Text("404 Not Found").WriteResponse(conn)
-	return nil
-}
\ No newline at end of file
+	return NotFound().Text("404 Not Found")
+}
+
+// Indica si la solicitud va dirigida al proxy de reenvío: CONNECT o un
+// target en forma absoluta ("GET http://example.com/ HTTP/1.1").
+func (server *HttpServer) proxied(request *HttpRequest) bool {
+	if server.Proxy == nil {
+		return false
+	}
+	return request.Method == "CONNECT" || request.Target.IsAbs()
+}
+
+// Devuelve los métodos registrados para una ruta, incluido OPTIONS.
+func (server *HttpServer) AllowedMethods(path string) []string {
+	return allowedMethods(server.Handlers, path)
+}
+
+// Devuelve los métodos de una tabla de rutas para una ruta, incluido OPTIONS.
+func allowedMethods(handlers []*Handler, path string) []string {
+	methods := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, handler := range handlers {
+		if MatchPath(path, handler.Path) && !seen[handler.Method] {
+			seen[handler.Method] = true
+			methods = append(methods, handler.Method)
+		}
+	}
+
+	if len(methods) > 0 && !seen["OPTIONS"] {
+		methods = append(methods, "OPTIONS")
+	}
+
+	return methods
+}
@@ -1,7 +1,9 @@
 package core
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"math/rand/v2"
 	"net"
 	"reflect"
@@ -208,3 +210,75 @@ func TestStartUnknownError(t *testing.T) {
 
 	server.Stop()
 }
+
+func TestStopClosesIdleConnections(t *testing.T) {
+	for _, pipelining := range []int{1, 4} {
+		t.Run(fmt.Sprintf("TestStopClosesIdleConnections %d", pipelining), func(t *testing.T) {
+			// Arrange: una conexión persistente esperando la siguiente solicitud
+			server := NewHttpServer()
+			server.Pipelining = pipelining
+			server.Get("/n", func(request *HttpRequest) (*HttpResponse, error) {
+				return Ok().Text("ok"), nil
+			})
+			conn := dialPipeline(t, server)
+			fmt.Fprint(conn, "GET /n HTTP/1.1\r\nHost: x\r\n\r\n")
+			reader := bufio.NewReader(conn)
+			readPipelinedBody(t, reader)
+
+			// Act
+			server.Stop()
+
+			// Assert: se cierra sin esperar a IdleTimeout
+			conn.SetDeadline(time.Now().Add(2 * time.Second))
+			if rest, err := io.ReadAll(reader); err != nil || len(rest) != 0 {
+				t.Errorf("Expected connection closed, not %q (%v)", rest, err)
+			}
+		})
+	}
+}
+
+func TestStopClosesActiveConnections(t *testing.T) {
+	for _, pipelining := range []int{1, 4} {
+		t.Run(fmt.Sprintf("TestStopClosesActiveConnections %d", pipelining), func(t *testing.T) {
+			// Arrange: una solicitud en curso en una conexión persistente
+			server := NewHttpServer()
+			server.Pipelining = pipelining
+			started := make(chan struct{})
+			release := make(chan struct{})
+			server.Get("/slow", func(request *HttpRequest) (*HttpResponse, error) {
+				close(started)
+				<-release
+				return Ok().Text("slow"), nil
+			})
+			conn := dialPipeline(t, server)
+			fmt.Fprint(conn, "GET /slow HTTP/1.1\r\nHost: x\r\n\r\n")
+			reader := bufio.NewReader(conn)
+			<-started
+
+			// Act
+			server.Stop()
+			close(release)
+
+			// Assert: la solicitud se responde con "Connection: close" y la conexión se cierra
+			status, _ := reader.ReadString('\n')
+			if status != "HTTP/1.1 200 OK\r\n" {
+				t.Fatalf("Expected 200, not %q", status)
+			}
+			closed := false
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == "\r\n" {
+					break
+				}
+				closed = closed || line == "Connection: close\r\n"
+			}
+			if !closed {
+				t.Error("Expected Connection: close")
+			}
+			io.ReadFull(reader, make([]byte, len("slow")))
+			if rest, err := io.ReadAll(reader); err != nil || len(rest) != 0 {
+				t.Errorf("Expected connection closed, not %q (%v)", rest, err)
+			}
+		})
+	}
+}
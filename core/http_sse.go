@@ -0,0 +1,212 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error devuelto al enviar un evento cuando el cliente ya se desconectó.
+var ErrStreamClosed = errors.New("event stream closed")
+
+// Intervalo por defecto entre latidos de un flujo de eventos.
+const DefaultHeartbeat = 15 * time.Second
+
+// Un evento de Server-Sent Events.
+type Event struct {
+	ID    string        // Campo id: (el cliente lo reenvía en Last-Event-ID al reconectar)
+	Event string        // Campo event: (vacío usa el tipo "message")
+	Data  string        // Campo data: (una línea data: por cada línea)
+	Retry time.Duration // Campo retry: espera sugerida antes de reconectar (0 lo omite)
+}
+
+// Serializa el evento en el formato text/event-stream.
+func (event Event) bytes() []byte {
+	var builder strings.Builder
+	if event.ID != "" {
+		builder.WriteString("id: " + sanitizeEventField(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		builder.WriteString("event: " + sanitizeEventField(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		builder.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	data := strings.ReplaceAll(strings.ReplaceAll(event.Data, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		builder.WriteString("data: " + line + "\n")
+	}
+	builder.WriteString("\n")
+	return []byte(builder.String())
+}
+
+// Quita los saltos de línea, que terminarían el campo antes de tiempo.
+func sanitizeEventField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// EventStream atiende una ruta con Server-Sent Events. Handler recibe un
+// EventWriter y envía eventos hasta terminar, hasta que el cliente se
+// desconecta o hasta que el servidor se detiene (Stop):
+//
+//	stream := core.NewEventStream(func(request *core.HttpRequest, events *core.EventWriter) {
+//		for i := 0; ; i++ {
+//			if events.Send(core.Event{ID: strconv.Itoa(i), Data: "tick"}) != nil {
+//				return
+//			}
+//			time.Sleep(time.Second)
+//		}
+//	})
+//	server.Get("/events", stream.Handle)
+type EventStream struct {
+	Heartbeat time.Duration // Intervalo entre comentarios de latido (0 los desactiva)
+	Handler   func(request *HttpRequest, events *EventWriter)
+}
+
+// Crea un EventStream con el latido por defecto.
+func NewEventStream(handler func(request *HttpRequest, events *EventWriter)) *EventStream {
+	return &EventStream{Heartbeat: DefaultHeartbeat, Handler: handler}
+}
+
+// Handle responde a la solicitud con el flujo de eventos.
+func (stream *EventStream) Handle(request *HttpRequest) (*HttpResponse, error) {
+	return stream.Response(request), nil
+}
+
+// Response crea la respuesta text/event-stream. El manejador empieza a
+// ejecutarse cuando el servidor comienza a enviar el cuerpo, así que no
+// se ejecuta si la respuesta se descarta (por ejemplo, en HEAD).
+func (stream *EventStream) Response(request *HttpRequest) *HttpResponse {
+	reader, writer := io.Pipe()
+	events := &EventWriter{
+		LastEventID: request.Header("Last-Event-ID"),
+		writer:      writer,
+		done:        make(chan struct{}),
+	}
+	body := &eventStreamBody{reader: reader, events: events}
+	body.start = func() {
+		go func() {
+			defer writer.Close()
+			defer events.stop()
+			stream.Handler(request, events)
+		}()
+		if stream.Heartbeat > 0 {
+			go events.heartbeat(stream.Heartbeat)
+		}
+		if request.serverDone != nil {
+			go func() {
+				select {
+				case <-request.serverDone:
+					// Termina el cuerpo aunque el manejador no vigile Done.
+					events.stop()
+					writer.Close()
+				case <-events.done:
+				}
+			}()
+		}
+	}
+
+	return Ok().
+		SetContentType("text/event-stream; charset=utf-8").
+		SetHeader("Cache-Control", "no-cache").
+		SetHeader("X-Accel-Buffering", "no").
+		SetBodyReader(body, -1)
+}
+
+// Cuerpo de la respuesta: lee lo que escribe el EventWriter. Al cerrarse
+// (fin de la respuesta o error al escribir en la conexión) detiene el flujo.
+type eventStreamBody struct {
+	reader  *io.PipeReader
+	events  *EventWriter
+	start   func()
+	started sync.Once
+}
+
+func (body *eventStreamBody) Read(p []byte) (int, error) {
+	body.started.Do(body.start)
+	return body.reader.Read(p)
+}
+
+func (body *eventStreamBody) Close() error {
+	body.events.stop()
+	return body.reader.CloseWithError(ErrStreamClosed)
+}
+
+// EventWriter envía eventos al cliente. Cada evento se escribe en la
+// conexión en cuanto se envía. Sus métodos pueden usarse concurrentemente.
+type EventWriter struct {
+	LastEventID string // Valor de la cabecera Last-Event-ID al reconectar (vacío si no hay)
+
+	writer   *io.PipeWriter
+	mu       sync.Mutex
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Envía un evento.
+func (events *EventWriter) Send(event Event) error {
+	return events.write(event.bytes())
+}
+
+// Envía value serializado como JSON en el campo data:.
+func (events *EventWriter) SendJSON(id, name string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return events.Send(Event{ID: id, Event: name, Data: string(data)})
+}
+
+// Envía un comentario, que el cliente ignora (útil como latido).
+func (events *EventWriter) Comment(text string) error {
+	return events.write([]byte(": " + sanitizeEventField(text) + "\n\n"))
+}
+
+// Done se cierra cuando el cliente se desconecta, el servidor se detiene o
+// el flujo termina.
+func (events *EventWriter) Done() <-chan struct{} {
+	return events.done
+}
+
+func (events *EventWriter) write(data []byte) error {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+
+	select {
+	case <-events.done:
+		return ErrStreamClosed
+	default:
+	}
+
+	if _, err := events.writer.Write(data); err != nil {
+		events.stop()
+		return ErrStreamClosed
+	}
+	return nil
+}
+
+// Envía latidos periódicos para mantener viva la conexión y detectar la
+// desconexión del cliente aunque el manejador no envíe eventos.
+func (events *EventWriter) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-events.done:
+			return
+		case <-ticker.C:
+			if events.Comment("ping") != nil {
+				return
+			}
+		}
+	}
+}
+
+func (events *EventWriter) stop() {
+	events.stopOnce.Do(func() { close(events.done) })
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventBytes(t *testing.T) {
+	cases := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{Data: "hello"}, "data: hello\n\n"},
+		{Event{ID: "7", Event: "progress", Data: "a\nb"}, "id: 7\nevent: progress\ndata: a\ndata: b\n\n"},
+		{Event{Data: "x", Retry: 2 * time.Second}, "retry: 2000\ndata: x\n\n"},
+		// Los saltos de línea en id y event no pueden inyectar campos
+		{Event{ID: "1\ndata: evil", Data: "ok"}, "id: 1data: evil\ndata: ok\n\n"},
+		{Event{Data: "a\r\nb\rc"}, "data: a\ndata: b\ndata: c\n\n"},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("TestEventBytes %d", i), func(t *testing.T) {
+			if got := string(c.event.bytes()); got != c.expected {
+				t.Errorf("Expected %q, not %q", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestEventStreamThroughServer(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	server.Compression = DefaultCompression()
+
+	stream := NewEventStream(func(request *HttpRequest, events *EventWriter) {
+		events.Send(Event{ID: "resume", Data: events.LastEventID})
+		events.SendJSON("2", "progress", map[string]int{"done": 1})
+	})
+	server.Get("/events", stream.Handle)
+
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+
+	go server.Handle(conn2)
+
+	// Act
+	go fmt.Fprint(conn1, "GET /events HTTP/1.1\r\nHost: x\r\nAccept-Encoding: gzip\r\nLast-Event-ID: 41\r\nConnection: close\r\n\r\n")
+
+	var response bytes.Buffer
+	response.ReadFrom(conn1)
+
+	// Assert
+	head, body, _ := strings.Cut(response.String(), "\r\n\r\n")
+
+	for _, header := range []string{"Content-Type: text/event-stream; charset=utf-8", "Cache-Control: no-cache", "Transfer-Encoding: chunked"} {
+		if !strings.Contains(head, header) {
+			t.Errorf("Expected header %q in %q", header, head)
+		}
+	}
+	if strings.Contains(head, "Content-Encoding") {
+		t.Errorf("Expected event stream not to be compressed, %q", head)
+	}
+
+	decoded, err := io.ReadAll(newChunkedReader(bufio.NewReader(strings.NewReader(body))))
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+
+	expected := "id: resume\ndata: 41\n\nid: 2\nevent: progress\ndata: {\"done\":1}\n\n"
+	if string(decoded) != expected {
+		t.Errorf("Expected events %q, not %q", expected, decoded)
+	}
+}
+
+func TestEventStreamClientDisconnect(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+
+	stopped := make(chan struct{})
+	stream := NewEventStream(func(request *HttpRequest, events *EventWriter) {
+		// No envía eventos: solo el latido detecta la desconexión
+		<-events.Done()
+		close(stopped)
+	})
+	stream.Heartbeat = 10 * time.Millisecond
+	server.Get("/events", stream.Handle)
+
+	conn1, conn2 := net.Pipe()
+
+	go server.Handle(conn2)
+	go fmt.Fprint(conn1, "GET /events HTTP/1.1\r\nHost: x\r\n\r\n")
+
+	// Act
+	reader := bufio.NewReader(conn1)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected heartbeat, %v", err)
+		}
+		if strings.HasPrefix(line, ": ping") {
+			break
+		}
+	}
+	conn1.Close()
+
+	// Assert
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected handler to stop after the client disconnected")
+	}
+}
+
+func TestEventStreamHead(t *testing.T) {
+	// Arrange
+	started := false
+	stream := NewEventStream(func(request *HttpRequest, events *EventWriter) {
+		started = true
+	})
+	request := NewHttpRequest("HEAD", nil, map[string]string{}, "")
+
+	// Act
+	var buffer bytes.Buffer
+	stream.Response(request).write(&buffer, false)
+
+	// Assert
+	if started {
+		t.Errorf("Expected handler not to run for a response without body")
+	}
+}
+
+func TestEventStreamServerStop(t *testing.T) {
+	// Arrange: un manejador que no vigila Done y un latido que no llega
+	server := NewHttpServer()
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	stream := NewEventStream(func(request *HttpRequest, events *EventWriter) {
+		events.Send(Event{Data: "first"})
+		<-release
+	})
+	server.Get("/events", stream.Handle)
+	conn := dialPipeline(t, server)
+	fmt.Fprint(conn, "GET /events HTTP/1.1\r\nHost: x\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected first event, %v", err)
+		}
+		if strings.HasPrefix(line, "data: first") {
+			break
+		}
+	}
+
+	// Act
+	server.Stop()
+
+	// Assert: el flujo termina con el último chunk y la conexión se cierra
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Expected stream to end after Stop, %v", err)
+	}
+	if !strings.HasSuffix(string(rest), "0\r\n\r\n") {
+		t.Errorf("Expected last chunk, not %q", rest)
+	}
+}
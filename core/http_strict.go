@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Indica si la cadena es un token de RFC 9110 (sección 5.6.2): el formato de
+// los métodos y de los nombres de cabecera.
+func isToken(value string) bool {
+	if value == "" {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// Indica si el valor de una cabecera no contiene caracteres de control
+// (se admiten el tabulador y los bytes obs-text, 0x80-0xFF).
+func validFieldValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c < 0x20 && c != '\t') || c == 0x7F {
+			return false
+		}
+	}
+	return true
+}
+
+// Indica si el target de la línea de inicio tiene la forma que admite el
+// método: autoridad para CONNECT, "*" para OPTIONS y, en general, una ruta
+// ("/...") o una URL absoluta (RFC 9112, sección 3.2).
+func validTarget(method, target string) bool {
+	if target == "" || !validFieldValue(target) || strings.ContainsAny(target, " \t") {
+		return false
+	}
+	switch {
+	case method == "CONNECT":
+		return !strings.Contains(target, "/")
+	case target == "*":
+		return method == "OPTIONS"
+	}
+	return strings.HasPrefix(target, "/") || strings.HasPrefix(strings.ToLower(target), "http://") || strings.HasPrefix(strings.ToLower(target), "https://")
+}
+
+// Parsea las líneas de cabecera con las reglas estrictas.
+func parseStrictHeaders(lines []string) (map[string]string, error) {
+	headers := make(map[string]string)
+
+	for _, line := range lines {
+		// Una línea que empieza con espacio continúa la anterior (obs-fold)
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			return nil, fmt.Errorf("%w: obsolete line folding", ErrMalformedRequest)
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: header line without colon", ErrMalformedRequest)
+		}
+		// Un espacio antes de ":" hace que el nombre no sea un token
+		if !isToken(name) {
+			return nil, fmt.Errorf("%w: invalid header name %q", ErrMalformedRequest, name)
+		}
+		value = strings.Trim(value, " \t")
+		if !validFieldValue(value) {
+			return nil, fmt.Errorf("%w: invalid value for header %s", ErrMalformedRequest, name)
+		}
+
+		key, exists := FindHeader(headers, name)
+		if !exists {
+			headers[name] = value
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(name, "Content-Length"), strings.EqualFold(name, "Host"):
+			return nil, fmt.Errorf("%w: duplicate %s header", ErrMalformedRequest, name)
+		case strings.EqualFold(name, "Cookie"):
+			headers[key] += "; " + value
+		default:
+			headers[key] += ", " + value
+		}
+	}
+
+	return headers, nil
+}
+
+// Comprueba que el final del cuerpo se pueda determinar sin ambigüedad
+// (RFC 9112, sección 6.3).
+func checkFraming(request *HttpRequest) error {
+	_, hasLength := FindHeader(request.Headers, "Content-Length")
+	_, hasEncoding := FindHeader(request.Headers, "Transfer-Encoding")
+
+	if hasLength {
+		length := request.Header("Content-Length")
+		if length == "" || strings.Trim(length, "0123456789") != "" || len(length) > 18 {
+			return fmt.Errorf("%w: invalid content length %q", ErrMalformedRequest, length)
+		}
+	}
+
+	if !hasEncoding {
+		return nil
+	}
+	if request.Version == "HTTP/1.0" {
+		return fmt.Errorf("%w: transfer-encoding in HTTP/1.0 request", ErrMalformedRequest)
+	}
+	if hasLength {
+		return fmt.Errorf("%w: both transfer-encoding and content-length", ErrMalformedRequest)
+	}
+	if !strings.EqualFold(request.Header("Transfer-Encoding"), "chunked") {
+		return fmt.Errorf("%w: unsupported transfer-encoding %q", ErrMalformedRequest, request.Header("Transfer-Encoding"))
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+var RejectStrictRequestTests = []string{
+	// espacios de más en la línea de inicio
+	"GET  / HTTP/1.1\r\nHost: x\r\n\r\n",
+	"GET / HTTP/1.1 \r\nHost: x\r\n\r\n",
+	// target que no es una ruta
+	"GET index.html HTTP/1.1\r\nHost: x\r\n\r\n",
+	// "*" fuera de OPTIONS
+	"GET * HTTP/1.1\r\nHost: x\r\n\r\n",
+	// espacio antes de ":"
+	"GET / HTTP/1.1\r\nHost : x\r\n\r\n",
+	// línea sin ":"
+	"GET / HTTP/1.1\r\nHost: x\r\nInvalid\r\n\r\n",
+	// nombre con caracteres no válidos
+	"GET / HTTP/1.1\r\nHost: x\r\nX(y): 1\r\n\r\n",
+	// obs-fold
+	"GET / HTTP/1.1\r\nHost: x\r\nX-Long: a\r\n b\r\n\r\n",
+	// carácter de control en el valor
+	"GET / HTTP/1.1\r\nHost: x\r\nX-Bad: a\x00b\r\n\r\n",
+	// Content-Length repetido (aunque coincida)
+	"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 4\r\ncontent-length: 4\r\n\r\n",
+	// Content-Length no numérico
+	"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: +4\r\n\r\n",
+	"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 4, 4\r\n\r\n",
+	// Host repetido
+	"GET / HTTP/1.1\r\nHost: a\r\nHost: b\r\n\r\n",
+	// Transfer-Encoding y Content-Length
+	"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\nContent-Length: 4\r\n\r\n",
+	// Transfer-Encoding distinto de chunked
+	"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: gzip, chunked\r\n\r\n",
+	"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\nTransfer-Encoding: identity\r\n\r\n",
+	// Transfer-Encoding en HTTP/1.0
+	"POST / HTTP/1.0\r\nTransfer-Encoding: chunked\r\n\r\n",
+}
+
+func TestParseRequestStrictReject(t *testing.T) {
+	for i, input := range RejectStrictRequestTests {
+		t.Run(fmt.Sprintf("TestParseRequestStrictReject %d", i), func(t *testing.T) {
+			// Act
+			request, err := ParseRequestStrict(input)
+
+			// Assert
+			if request != nil {
+				t.Errorf("Expected no request, not %v", request)
+			}
+			if !errors.Is(err, ErrMalformedRequest) {
+				t.Errorf("Expected ErrMalformedRequest for %q, not %v", input, err)
+			}
+		})
+	}
+}
+
+func TestParseRequestStrictPass(t *testing.T) {
+	// Arrange
+	input := "OPTIONS * HTTP/1.1\r\nHost: x\r\nAccept: text/html\r\naccept: application/json\r\n" +
+		"Cookie: a=1\r\nCookie: b=2\r\nX-Tab:\tvalue\t\r\n\r\n"
+
+	// Act
+	request, err := ParseRequestStrict(input)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	if request.Header("Accept") != "text/html, application/json" {
+		t.Errorf("Expected combined Accept, not %q", request.Header("Accept"))
+	}
+	if request.Header("Cookie") != "a=1; b=2" {
+		t.Errorf("Expected cookies joined with ;, not %q", request.Header("Cookie"))
+	}
+	if request.Header("X-Tab") != "value" {
+		t.Errorf("Expected value without surrounding whitespace, not %q", request.Header("X-Tab"))
+	}
+}
+
+func TestParseRequestLenientChunked(t *testing.T) {
+	// Act: el modo tolerante acepta ambas cabeceras pero descarta Content-Length
+	request, err := ParseRequest("POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\nContent-Length: 4\r\nBroken\r\n\r\n")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, %v", err)
+	}
+	if request.Header("Content-Length") != "" {
+		t.Errorf("Expected Content-Length removed, not %q", request.Header("Content-Length"))
+	}
+}
+
+func TestReadRequestHeadStrictBareLF(t *testing.T) {
+	// Act
+	_, err := ReadRequestHeadStrict(bufio.NewReader(strings.NewReader("GET / HTTP/1.1\nHost: x\r\n\r\n")))
+
+	// Assert
+	if !errors.Is(err, ErrMalformedRequest) {
+		t.Errorf("Expected ErrMalformedRequest, not %v", err)
+	}
+}
+
+func TestChunkSizeWithSign(t *testing.T) {
+	// Arrange
+	request, _ := ParseRequest("POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n")
+
+	// Act
+	err := ParseBody(request, bufio.NewReader(strings.NewReader("+4\r\ndata\r\n0\r\n\r\n")))
+
+	// Assert
+	if err == nil {
+		t.Error("Expected error for a signed chunk size")
+	}
+}
+
+func TestStrictParsingServer(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	server.StrictParsing = true
+	server.Post("/", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text(request.Body), nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Act: una solicitud válida y, en la misma conexión, un intento de smuggling
+	fmt.Fprint(conn, "POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 2\r\n\r\nok"+
+		"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 6\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\nX")
+	reader := bufio.NewReader(conn)
+
+	// Assert
+	if status, _ := reader.ReadString('\n'); status != "HTTP/1.1 200 OK\r\n" {
+		t.Fatalf("Expected 200 for the valid request, not %q", status)
+	}
+	readPipelinedBody(t, reader)
+	if status, _ := reader.ReadString('\n'); status != "HTTP/1.1 400 Bad Request\r\n" {
+		t.Errorf("Expected 400 for the ambiguous request, not %q", status)
+	}
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Errorf("Expected connection closed, not %v", err)
+	}
+}
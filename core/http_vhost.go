@@ -0,0 +1,140 @@
+package core
+
+import (
+	"net"
+	"strings"
+)
+
+// Representa un host virtual: una tabla de rutas y middlewares propios que
+// atienden las solicitudes cuyo Host coincide con Pattern. Pattern es un nombre
+// exacto ("api.example.test") o un comodín ("*.example.test") que cubre
+// cualquier subdominio, pero no el dominio en sí.
+type VirtualHost struct {
+	Pattern     string       // Nombre o comodín del host, en minúsculas
+	Handlers    []*Handler   // Rutas del host
+	Middlewares []Middleware // Middlewares del host, después de los globales
+}
+
+// Devuelve el host virtual con el patrón dado, creándolo si no existe.
+// Las solicitudes cuyo Host no coincide con ningún host virtual usan las rutas
+// del propio servidor; si el servidor no tiene rutas propias, responde 421.
+func (server *HttpServer) Host(pattern string) *VirtualHost {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	for _, host := range server.Hosts {
+		if host.Pattern == pattern {
+			return host
+		}
+	}
+
+	host := &VirtualHost{Pattern: pattern, Handlers: []*Handler{}}
+	server.Hosts = append(server.Hosts, host)
+	return host
+}
+
+// Agrega un nuevo manejador al host virtual.
+func (host *VirtualHost) AddHandler(method, path string, handle Handle) *Handler {
+	handler := &Handler{
+		Method: method,
+		Path:   path,
+		Handle: handle,
+	}
+
+	host.Handlers = append(host.Handlers, handler)
+
+	return handler
+}
+
+// Añade middlewares que se aplican a todas las solicitudes del host virtual.
+func (host *VirtualHost) Use(middlewares ...Middleware) *VirtualHost {
+	host.Middlewares = append(host.Middlewares, middlewares...)
+	return host
+}
+
+// Un atajo para agregar un manejador para el método GET.
+func (host *VirtualHost) Get(path string, handle Handle) *Handler {
+	return host.AddHandler("GET", path, handle)
+}
+
+// Un atajo para agregar un manejador para el método HEAD.
+func (host *VirtualHost) Head(path string, handle Handle) *Handler {
+	return host.AddHandler("HEAD", path, handle)
+}
+
+// Un atajo para agregar un manejador para el método POST.
+func (host *VirtualHost) Post(path string, handle Handle) *Handler {
+	return host.AddHandler("POST", path, handle)
+}
+
+// Un atajo para agregar un manejador para el método PUT.
+func (host *VirtualHost) Put(path string, handle Handle) *Handler {
+	return host.AddHandler("PUT", path, handle)
+}
+
+// Un atajo para agregar un manejador para el método DELETE.
+func (host *VirtualHost) Delete(path string, handle Handle) *Handler {
+	return host.AddHandler("DELETE", path, handle)
+}
+
+// Indica si el nombre de host (sin puerto, en minúsculas) coincide con el patrón.
+func (host *VirtualHost) matches(name string) bool {
+	if suffix, ok := strings.CutPrefix(host.Pattern, "*"); ok {
+		return len(name) > len(suffix) && strings.HasSuffix(name, suffix)
+	}
+	return name == host.Pattern
+}
+
+// Devuelve el nombre de host de la solicitud, sin puerto y en minúsculas.
+// Una URL absoluta en la línea de solicitud tiene prioridad sobre la cabecera Host.
+func RequestHost(request *HttpRequest) string {
+	host := request.Target.Host
+	if host == "" {
+		host = request.Host
+	}
+	if host == "" {
+		host = request.Header("Host")
+	}
+
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+
+	return strings.ToLower(strings.TrimSuffix(strings.Trim(host, "[]"), "."))
+}
+
+// Devuelve el host virtual de la solicitud, o nil si corresponde al host por defecto.
+// Un nombre exacto tiene prioridad sobre un comodín, y el comodín más largo sobre los demás.
+func (server *HttpServer) hostFor(request *HttpRequest) *VirtualHost {
+	if len(server.Hosts) == 0 {
+		return nil
+	}
+
+	name := RequestHost(request)
+	var best *VirtualHost
+	for _, host := range server.Hosts {
+		if !host.matches(name) {
+			continue
+		}
+		if host.Pattern == name {
+			return host
+		}
+		if best == nil || len(host.Pattern) > len(best.Pattern) {
+			best = host
+		}
+	}
+
+	return best
+}
+
+// Devuelve la tabla de rutas que atiende la solicitud.
+func (server *HttpServer) handlersFor(request *HttpRequest) []*Handler {
+	if host := server.hostFor(request); host != nil {
+		return host.Handlers
+	}
+	return server.Handlers
+}
+
+// Indica si la solicitud no corresponde a ningún host virtual y el servidor
+// no tiene rutas propias que sirvan de host por defecto.
+func (server *HttpServer) misdirected(request *HttpRequest) bool {
+	return len(server.Hosts) > 0 && len(server.Handlers) == 0 && server.hostFor(request) == nil
+}
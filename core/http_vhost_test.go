@@ -0,0 +1,90 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// Envía una solicitud cruda al servidor y devuelve la respuesta completa.
+func vhostRoundTrip(server *HttpServer, raw string) string {
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+
+	go server.Handle(conn2)
+	go conn1.Write([]byte(raw))
+
+	var response bytes.Buffer
+	response.ReadFrom(conn1)
+	return response.String()
+}
+
+func TestVirtualHostRouting(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	text := func(body string) Handle {
+		return func(request *HttpRequest) (*HttpResponse, error) {
+			return Ok().Text(body), nil
+		}
+	}
+	server.Get("/", text("default"))
+	server.Host("api.example.test").Get("/", text("api"))
+	server.Host("*.example.test").Get("/", text("wildcard"))
+	server.Host("*.eu.example.test").Get("/", text("eu"))
+	server.Host("static.example.test").Use(func(next Handle) Handle {
+		return func(request *HttpRequest) (*HttpResponse, error) {
+			response, err := next(request)
+			return response.SetHeader("X-Host", "static"), err
+		}
+	}).Get("/files", text("static"))
+	server.SortHandlers()
+
+	cases := []struct {
+		raw  string
+		want string
+	}{
+		{"GET / HTTP/1.1\r\nHost: api.example.test\r\nConnection: close\r\n\r\n", "api"},
+		{"GET / HTTP/1.1\r\nHost: API.Example.Test:8080\r\nConnection: close\r\n\r\n", "api"},
+		{"GET / HTTP/1.1\r\nHost: www.example.test\r\nConnection: close\r\n\r\n", "wildcard"},
+		{"GET / HTTP/1.1\r\nHost: a.eu.example.test\r\nConnection: close\r\n\r\n", "eu"},
+		{"GET / HTTP/1.1\r\nHost: example.test\r\nConnection: close\r\n\r\n", "default"},
+		{"GET / HTTP/1.0\r\n\r\n", "default"},
+		{"GET http://api.example.test/ HTTP/1.1\r\nHost: other.test\r\nConnection: close\r\n\r\n", "api"},
+		{"GET /files HTTP/1.1\r\nHost: static.example.test\r\nConnection: close\r\n\r\n", "X-Host: static"},
+		{"GET / HTTP/1.1\r\nHost: static.example.test\r\nConnection: close\r\n\r\n", "404 Not Found"},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("TestVirtualHostRouting %d", i), func(t *testing.T) {
+			// Act
+			response := vhostRoundTrip(server, c.raw)
+
+			// Assert
+			if !strings.Contains(response, c.want) {
+				t.Errorf("Expected %q in response, not %q", c.want, response)
+			}
+		})
+	}
+}
+
+func TestVirtualHostMissingAndMisdirected(t *testing.T) {
+	// Arrange
+	server := NewHttpServer()
+	server.Host("api.example.test").Get("/", func(request *HttpRequest) (*HttpResponse, error) {
+		return Ok().Text("api"), nil
+	})
+
+	// Act
+	missing := vhostRoundTrip(server, "GET / HTTP/1.1\r\n\r\n")
+	misdirected := vhostRoundTrip(server, "GET / HTTP/1.1\r\nHost: other.test\r\nConnection: close\r\n\r\n")
+
+	// Assert
+	if !strings.HasPrefix(missing, "HTTP/1.0 400") || !strings.Contains(missing, "missing host header") {
+		t.Errorf("Expected 400 for HTTP/1.1 without Host, not %q", missing)
+	}
+	if !strings.HasPrefix(misdirected, "HTTP/1.1 421 Misdirected Request") {
+		t.Errorf("Expected 421 for unknown host, not %q", misdirected)
+	}
+}
@@ -0,0 +1,76 @@
+package core
+
+import "log/slog"
+
+// Representa la identidad autenticada que realiza una solicitud.
+type Principal struct {
+	Name   string         // Identificador del usuario o cliente
+	Scheme string         // Mecanismo de autenticación ("basic", "bearer", "jwt")
+	Roles  []string       // Roles asignados (ej. "admin")
+	Scopes []string       // Permisos concedidos por el token (ej. "files:write")
+	Claims map[string]any // Claims del token JWT, si se usó uno
+}
+
+// Indica si el principal tiene el rol dado.
+func (principal *Principal) HasRole(role string) bool {
+	if principal == nil {
+		return false
+	}
+	for _, r := range principal.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Indica si el principal tiene el permiso (scope) dado.
+func (principal *Principal) HasScope(scope string) bool {
+	if principal == nil {
+		return false
+	}
+	for _, s := range principal.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Comprueba que el principal de la solicitud cumpla los requisitos de una ruta:
+// al menos uno de los roles y todos los permisos. Devuelve nil si se autoriza,
+// 401 si no hay principal autenticado o 403 si no tiene permiso. Las denegaciones
+// quedan registradas en el log de auditoría.
+func Authorize(request *HttpRequest, roles, scopes []string) *HttpResponse {
+	if len(roles) == 0 && len(scopes) == 0 {
+		return nil
+	}
+
+	principal := request.Principal
+	if principal == nil {
+		slog.Warn("Authorization denied", "reason", "unauthenticated", "method", request.Method, "path", request.Target.Path)
+		return Unauthorized().Text("authentication required")
+	}
+
+	allowed := len(roles) == 0
+	for _, role := range roles {
+		if principal.HasRole(role) {
+			allowed = true
+			break
+		}
+	}
+
+	for _, scope := range scopes {
+		if !principal.HasScope(scope) {
+			allowed = false
+		}
+	}
+
+	if !allowed {
+		slog.Warn("Authorization denied", "reason", "forbidden", "principal", principal.Name, "scheme", principal.Scheme,
+			"method", request.Method, "path", request.Target.Path, "roles", roles, "scopes", scopes)
+		return Forbidden().Text("forbidden")
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package coretest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Client envía solicitudes a un servidor, cada una por una conexión nueva,
+// y devuelve la primera respuesta. Los errores de red hacen fallar la prueba.
+type Client struct {
+	Addr    string        // Dirección "host:puerto" del servidor
+	Timeout time.Duration // Tiempo máximo para enviar la solicitud y leer la respuesta
+}
+
+// Crea un cliente para la dirección dada.
+func NewClient(addr string) *Client {
+	return &Client{Addr: addr, Timeout: 5 * time.Second}
+}
+
+// Envía la solicitud tal cual, sin añadir ni corregir nada, lo que permite
+// probar solicitudes mal formadas. El método se toma de la primera palabra.
+func (client *Client) Raw(t testing.TB, request string) *Response {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", client.Addr, client.Timeout)
+	if err != nil {
+		t.Fatalf("Expected connection to %s, not %v", client.Addr, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(client.Timeout))
+
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatalf("Expected request to be sent, not %v", err)
+	}
+
+	method, _, _ := strings.Cut(strings.TrimLeft(request, "\r\n"), " ")
+	response, err := readResponse(bufio.NewReader(conn), method)
+	if err != nil {
+		t.Fatalf("Expected a response, not %v", err)
+	}
+	return response
+}
+
+// Envía una solicitud construida con core.NewHttpRequest o NewRequest.
+// Añade Host, Content-Length y "Connection: close" si faltan; la versión
+// por defecto es HTTP/1.1.
+func (client *Client) Do(t testing.TB, request *core.HttpRequest) *Response {
+	t.Helper()
+	return client.Raw(t, client.format(request))
+}
+
+// Envía una solicitud GET a target ("/ruta?consulta").
+func (client *Client) Get(t testing.TB, target string) *Response {
+	t.Helper()
+	return client.Do(t, NewRequest("GET", target, ""))
+}
+
+// Envía una solicitud con cuerpo.
+func (client *Client) Send(t testing.TB, method, target, body string) *Response {
+	t.Helper()
+	return client.Do(t, NewRequest(method, target, body))
+}
+
+// Serializa la solicitud en formato HTTP/1.x.
+func (client *Client) format(request *core.HttpRequest) string {
+	version := request.Version
+	if version == "" {
+		version = "HTTP/1.1"
+	}
+
+	headers := make(map[string]string, len(request.Headers)+3)
+	for key, value := range request.Headers {
+		headers[key] = value
+	}
+	body := request.BodyBytes()
+	if request.Header("Host") == "" {
+		headers["Host"] = client.Addr
+	}
+	if request.Header("Content-Length") == "" && request.Header("Transfer-Encoding") == "" && (len(body) > 0 || request.Method == "POST") {
+		headers["Content-Length"] = strconv.Itoa(len(body))
+	}
+	if request.Header("Connection") == "" {
+		headers["Connection"] = "close"
+	}
+
+	// Cabeceras ordenadas para que la solicitud sea reproducible.
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	target := request.Target.RequestURI()
+	if request.Method == "CONNECT" {
+		target = request.Target.Host
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s %s %s\r\n", request.Method, target, version)
+	for _, key := range keys {
+		fmt.Fprintf(&builder, "%s: %s\r\n", key, headers[key])
+	}
+	builder.WriteString("\r\n")
+	builder.Write(body)
+	return builder.String()
+}
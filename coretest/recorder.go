@@ -0,0 +1,76 @@
+package coretest
+
+import (
+	"bufio"
+	"bytes"
+	"net/url"
+	"strconv"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Crea una solicitud HTTP/1.1 para llamar a un manejador sin conexión.
+// target puede incluir la consulta ("/fibonacci?num=7"); la solicitud llega
+// de 192.0.2.1:1234 con Host example.com. Entra en pánico si target no es
+// una URL válida.
+func NewRequest(method, target, body string) *core.HttpRequest {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		panic("coretest: bad target " + strconv.Quote(target) + ": " + err.Error())
+	}
+
+	headers := map[string]string{"Host": "example.com"}
+	if body != "" {
+		headers["Content-Length"] = strconv.Itoa(len(body))
+	}
+
+	request := core.NewHttpRequest(method, parsed, headers, body)
+	request.Version = "HTTP/1.1"
+	request.RawBody = []byte(body)
+	request.RemoteAddr = "192.0.2.1:1234"
+	request.Scheme = "http"
+	request.Host = "example.com"
+	return request
+}
+
+// Recorder llama a un manejador (con sus middlewares) sin servidor ni red y
+// devuelve la respuesta tal como se enviaría por la conexión.
+type Recorder struct {
+	Handle core.Handle
+}
+
+// Crea un Recorder para el manejador, envuelto con los middlewares dados
+// (el primero es el más externo, como en core.Chain).
+func NewRecorder(handle core.Handle, middlewares ...core.Middleware) *Recorder {
+	return &Recorder{Handle: core.Chain(handle, middlewares...)}
+}
+
+// Ejecuta el manejador con la solicitud. Como el servidor, responde 500 si
+// el manejador devuelve un error (disponible en Err) o ninguna respuesta.
+func (recorder *Recorder) Do(request *core.HttpRequest) *Response {
+	resp, err := recorder.Handle(request)
+	if err != nil || resp == nil {
+		resp = core.NewHttpResponse(500, "Internal Server Error", "500 Internal Server Error")
+	}
+	if request.Version == "HTTP/1.1" {
+		resp.Version = "HTTP/1.1"
+	}
+
+	// Se serializa y se vuelve a leer para obtener lo que vería el cliente
+	// (Content-Length, cuerpos de un lector, sin cuerpo en 204/304/HEAD).
+	var buffer bytes.Buffer
+	if _, writeErr := resp.WriteTo(&buffer); writeErr != nil {
+		return &Response{StatusCode: 500, StatusText: "Internal Server Error", Headers: map[string]string{}, Err: writeErr}
+	}
+	response, readErr := readResponse(bufio.NewReader(&buffer), request.Method)
+	if readErr != nil {
+		return &Response{StatusCode: 500, StatusText: "Internal Server Error", Headers: map[string]string{}, Err: readErr}
+	}
+	response.Err = err
+	return response
+}
+
+// Ejecuta el manejador con una solicitud GET al target dado.
+func (recorder *Recorder) Get(target string) *Response {
+	return recorder.Do(NewRequest("GET", target, ""))
+}
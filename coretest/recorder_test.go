@@ -0,0 +1,127 @@
+package coretest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+func TestNewRequest(t *testing.T) {
+	// Act
+	request := NewRequest("POST", "/echo?x=1", "body")
+
+	// Assert
+	if request.Target.Path != "/echo" || request.Target.Query().Get("x") != "1" {
+		t.Errorf("Expected target /echo?x=1, not %v", request.Target)
+	}
+	if request.Version != "HTTP/1.1" || request.Header("Content-Length") != "4" || request.Body != "body" {
+		t.Errorf("Expected HTTP/1.1 request with body, not %+v", request)
+	}
+	if request.ClientIP() != "192.0.2.1" {
+		t.Errorf("Expected client 192.0.2.1, not %s", request.ClientIP())
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	// Arrange
+	recorder := NewRecorder(func(request *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok().Text(strings.ToUpper(request.Target.Query().Get("text"))), nil
+	})
+
+	// Act
+	response := recorder.Get("/upper?text=hola")
+
+	// Assert
+	response.AssertStatus(t, 200).
+		AssertHeader(t, "Content-Type", "text/plain").
+		AssertHeader(t, "Content-Length", "4").
+		AssertBody(t, "HOLA")
+	if response.Version != "HTTP/1.1" {
+		t.Errorf("Expected HTTP/1.1, not %s", response.Version)
+	}
+}
+
+func TestRecorderMiddleware(t *testing.T) {
+	// Arrange
+	deny := func(next core.Handle) core.Handle {
+		return func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			if request.Header("Authorization") == "" {
+				return core.Unauthorized().Text("unauthorized"), nil
+			}
+			return next(request)
+		}
+	}
+	recorder := NewRecorder(func(request *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok().Text("secret"), nil
+	}, deny)
+	request := NewRequest("GET", "/private", "")
+	request.Headers["Authorization"] = "Bearer token"
+
+	// Act & Assert
+	recorder.Get("/private").AssertStatus(t, 401).AssertBody(t, "unauthorized")
+	recorder.Do(request).AssertStatus(t, 200).AssertBody(t, "secret")
+}
+
+func TestRecorderError(t *testing.T) {
+	// Arrange
+	failure := errors.New("failure")
+	recorder := NewRecorder(func(request *core.HttpRequest) (*core.HttpResponse, error) {
+		return nil, failure
+	})
+
+	// Act
+	response := recorder.Get("/")
+
+	// Assert
+	response.AssertStatus(t, 500)
+	if !errors.Is(response.Err, failure) {
+		t.Errorf("Expected handler error, not %v", response.Err)
+	}
+}
+
+func TestRecorderStreamedAndHead(t *testing.T) {
+	// Arrange
+	recorder := NewRecorder(func(request *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok().SetBodyReader(strings.NewReader("streamed"), -1), nil
+	})
+
+	// Act
+	get := recorder.Get("/")
+	head := recorder.Do(NewRequest("HEAD", "/", ""))
+
+	// Assert
+	get.AssertStatus(t, 200).AssertBody(t, "streamed")
+	head.AssertStatus(t, 200).AssertBody(t, "")
+}
+
+// Prueba falsa que registra los fallos en lugar de marcar la prueba real.
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.failures = append(f.failures, format)
+}
+
+func TestResponseAssertionsFail(t *testing.T) {
+	// Arrange
+	fake := &fakeT{}
+	response := &Response{StatusCode: 404, Headers: map[string]string{"X-A": "1"}, Body: "missing"}
+
+	// Act
+	response.AssertStatus(fake, 200).
+		AssertHeader(fake, "x-a", "2").
+		AssertHeader(fake, "X-B", "").
+		AssertBody(fake, "found").
+		AssertBodyContains(fake, "miss")
+
+	// Assert
+	if len(fake.failures) != 3 {
+		t.Errorf("Expected 3 failures, not %d: %v", len(fake.failures), fake.failures)
+	}
+}
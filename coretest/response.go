@@ -0,0 +1,101 @@
+package coretest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Respuesta recibida por Recorder o Client, con el cuerpo ya leído.
+// Los métodos Assert* marcan la prueba como fallida y devuelven la misma
+// respuesta para encadenarlos.
+type Response struct {
+	Version    string            // Versión de la línea de estado (HTTP/1.0 o HTTP/1.1)
+	StatusCode int               // Código de estado
+	StatusText string            // Texto de estado
+	Headers    map[string]string // Cabeceras recibidas (Content-Length incluida, Transfer-Encoding no)
+	SetCookies []string          // Valores de las cabeceras Set-Cookie, en orden
+	Body       string            // Cuerpo completo (vacío en respuestas a HEAD)
+	Err        error             // Error devuelto por el manejador (solo con Recorder)
+}
+
+// Lee una respuesta completa. method es el de la solicitud (HEAD no tiene cuerpo).
+func readResponse(reader *bufio.Reader, method string) (*Response, error) {
+	resp, err := core.ReadResponse(reader, method)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{
+		Version:    resp.Version,
+		StatusCode: resp.StatusCode,
+		StatusText: resp.StatusText,
+		Headers:    resp.Headers,
+		SetCookies: resp.SetCookies,
+	}
+	// ReadResponse descarta Content-Length; se recupera para poder comprobarla.
+	if resp.BodyReader != nil && resp.ContentLength >= 0 {
+		response.Headers["Content-Length"] = strconv.FormatInt(resp.ContentLength, 10)
+	}
+
+	if resp.BodyReader != nil {
+		body, err := io.ReadAll(resp.BodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("can't read body: %w", err)
+		}
+		response.Body = string(body)
+	}
+
+	return response, nil
+}
+
+// Devuelve el valor de una cabecera sin distinguir mayúsculas y minúsculas.
+func (response *Response) Header(key string) string {
+	for k, v := range response.Headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// Comprueba el código de estado.
+func (response *Response) AssertStatus(t testing.TB, code int) *Response {
+	t.Helper()
+	if response.StatusCode != code {
+		t.Errorf("Expected status %d, not %d %s (%q)", code, response.StatusCode, response.StatusText, response.Body)
+	}
+	return response
+}
+
+// Comprueba el valor de una cabecera; un valor vacío exige que no esté.
+func (response *Response) AssertHeader(t testing.TB, key, value string) *Response {
+	t.Helper()
+	if actual := response.Header(key); actual != value {
+		t.Errorf("Expected %s to be %q, not %q", key, value, actual)
+	}
+	return response
+}
+
+// Comprueba el cuerpo completo.
+func (response *Response) AssertBody(t testing.TB, body string) *Response {
+	t.Helper()
+	if response.Body != body {
+		t.Errorf("Expected body to be %q, not %q", body, response.Body)
+	}
+	return response
+}
+
+// Comprueba que el cuerpo contenga el texto dado.
+func (response *Response) AssertBodyContains(t testing.TB, text string) *Response {
+	t.Helper()
+	if !strings.Contains(response.Body, text) {
+		t.Errorf("Expected body to contain %q, not %q", text, response.Body)
+	}
+	return response
+}
@@ -0,0 +1,97 @@
+package coretest
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Server atiende un core.HttpServer en un puerto efímero de 127.0.0.1, en
+// el mismo proceso que la prueba. Varias pruebas pueden usar servidores
+// distintos a la vez.
+type Server struct {
+	Addr   string           // Dirección "127.0.0.1:puerto"
+	URL    string           // URL base, "http://127.0.0.1:puerto"
+	Server *core.HttpServer // Servidor atendido
+
+	ready     chan struct{}
+	done      chan error
+	closeOnce sync.Once
+}
+
+// Listener que avisa cuando el servidor empieza a aceptar conexiones.
+type readyListener struct {
+	net.Listener
+	once  sync.Once
+	ready chan struct{}
+}
+
+func (ln *readyListener) Accept() (net.Conn, error) {
+	ln.once.Do(func() { close(ln.ready) })
+	return ln.Listener.Accept()
+}
+
+// Inicia el servidor en un puerto efímero sin esperar a que esté listo
+// (ver Ready). Hay que detenerlo con Close.
+func Start(server *core.HttpServer) (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Addr:   ln.Addr().String(),
+		URL:    "http://" + ln.Addr().String(),
+		Server: server,
+		ready:  make(chan struct{}),
+		done:   make(chan error, 1),
+	}
+	go func() {
+		s.done <- server.Serve(&readyListener{Listener: ln, ready: s.ready})
+	}()
+
+	return s, nil
+}
+
+// Inicia el servidor, espera a que esté listo y lo detiene al terminar la prueba.
+func NewServer(t testing.TB, server *core.HttpServer) *Server {
+	t.Helper()
+	s, err := Start(server)
+	if err != nil {
+		t.Fatalf("Expected server to start, not %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	select {
+	case <-s.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected server to be ready within 5s")
+	}
+	return s
+}
+
+// Devuelve un canal que se cierra cuando el servidor acepta conexiones.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Detiene el servidor y espera a que deje de aceptar conexiones.
+// Devuelve el error con el que terminó Serve, si lo hubo.
+func (s *Server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		// Stop usa el listener que Serve asigna antes de aceptar.
+		<-s.ready
+		s.Server.Stop()
+		err = <-s.done
+	})
+	return err
+}
+
+// Crea un cliente para el servidor.
+func (s *Server) Client() *Client {
+	return NewClient(s.Addr)
+}
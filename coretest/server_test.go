@@ -0,0 +1,104 @@
+package coretest
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Servidor con una ruta GET /hello y un POST /echo.
+func newTestServer() *core.HttpServer {
+	server := core.NewHttpServer()
+	server.Get("/hello", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok().Text("hello " + request.Header("Host")), nil
+	})
+	server.Post("/echo", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok().Text(request.Body), nil
+	})
+	return server
+}
+
+func TestServer(t *testing.T) {
+	// Arrange
+	t.Parallel()
+	server := NewServer(t, newTestServer())
+	client := server.Client()
+
+	// Act & Assert
+	client.Get(t, "/hello").AssertStatus(t, 200).AssertBody(t, "hello example.com").AssertHeader(t, "Connection", "close")
+	client.Send(t, "POST", "/echo", "data").AssertStatus(t, 200).AssertBody(t, "data")
+	client.Send(t, "POST", "/echo", "").AssertStatus(t, 200).AssertBody(t, "")
+	client.Get(t, "/missing").AssertStatus(t, 404)
+	if server.URL != "http://"+server.Addr {
+		t.Errorf("Expected URL for %s, not %s", server.Addr, server.URL)
+	}
+}
+
+func TestServerRaw(t *testing.T) {
+	// Arrange
+	t.Parallel()
+	client := NewServer(t, newTestServer()).Client()
+
+	// Act & Assert
+	client.Raw(t, "GET /hello HTTP/1.0\r\n\r\n").AssertStatus(t, 200).AssertBody(t, "hello ")
+	client.Raw(t, "HEAD /hello HTTP/1.1\r\nHost: x\r\n\r\n").AssertStatus(t, 400)
+	client.Raw(t, "GET /hello HTTP/1.1\r\n\r\n").AssertStatus(t, 400).AssertBody(t, "missing host header")
+	client.Raw(t, "BREW /hello HTTP/1.1\r\nHost: x\r\n\r\n").AssertStatus(t, 400)
+}
+
+func TestServersInParallel(t *testing.T) {
+	// Arrange: cada servidor tiene su propio puerto
+	t.Parallel()
+	first := NewServer(t, newTestServer())
+	second := NewServer(t, newTestServer())
+
+	// Assert
+	if first.Addr == second.Addr {
+		t.Errorf("Expected different addresses, not %s twice", first.Addr)
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	// Arrange
+	server, err := Start(newTestServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-server.Ready()
+
+	// Act
+	err = server.Close()
+
+	// Assert
+	if err != nil {
+		t.Errorf("Expected no error, %v", err)
+	}
+	if err := server.Close(); err != nil {
+		t.Errorf("Expected second Close to do nothing, not %v", err)
+	}
+	if conn, err := net.Dial("tcp", server.Addr); err == nil {
+		conn.Close()
+		t.Error("Expected connection refused after Close")
+	}
+}
+
+func TestClientFormat(t *testing.T) {
+	// Arrange
+	client := NewClient("127.0.0.1:1")
+	request := NewRequest("PUT", "/files/a.txt?x=1", "abc")
+	request.Headers["Connection"] = "keep-alive"
+
+	// Act
+	raw := client.format(request)
+
+	// Assert
+	expected := "PUT /files/a.txt?x=1 HTTP/1.1\r\nConnection: keep-alive\r\nContent-Length: 3\r\nHost: example.com\r\n\r\nabc"
+	if raw != expected {
+		t.Errorf("Expected %q, not %q", expected, raw)
+	}
+	if raw := client.format(core.NewHttpRequest("GET", request.Target, map[string]string{}, "")); raw != fmt.Sprintf("GET /files/a.txt?x=1 HTTP/1.1\r\nConnection: close\r\nHost: %s\r\n\r\n", client.Addr) {
+		t.Errorf("Expected Host and Connection added, not %q", raw)
+	}
+}
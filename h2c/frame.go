@@ -0,0 +1,184 @@
+package h2c
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Tipos de trama (RFC 9113, sección 6).
+const (
+	frameData         = 0x0
+	frameHeaders      = 0x1
+	framePriority     = 0x2
+	frameRSTStream    = 0x3
+	frameSettings     = 0x4
+	framePushPromise  = 0x5
+	framePing         = 0x6
+	frameGoAway       = 0x7
+	frameWindowUpdate = 0x8
+	frameContinuation = 0x9
+)
+
+// Banderas de las tramas.
+const (
+	flagEndStream  = 0x1
+	flagAck        = 0x1
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+)
+
+// Parámetros de SETTINGS (RFC 9113, sección 6.5.2).
+const (
+	settingHeaderTableSize      = 0x1
+	settingEnablePush           = 0x2
+	settingMaxConcurrentStreams = 0x3
+	settingInitialWindowSize    = 0x4
+	settingMaxFrameSize         = 0x5
+	settingMaxHeaderListSize    = 0x6
+)
+
+// Valores por defecto y límites del protocolo.
+const (
+	defaultWindowSize    = 65535
+	defaultMaxFrameSize  = 16384
+	maxAllowedFrameSize  = 1<<24 - 1
+	maxWindowSize        = 1<<31 - 1
+	defaultHeaderTable   = 4096
+	frameHeaderLength    = 9
+	defaultMaxHeaderList = 1 << 16
+)
+
+// Códigos de error (RFC 9113, sección 7).
+type ErrCode uint32
+
+const (
+	ErrCodeNo                 ErrCode = 0x0
+	ErrCodeProtocol           ErrCode = 0x1
+	ErrCodeInternal           ErrCode = 0x2
+	ErrCodeFlowControl        ErrCode = 0x3
+	ErrCodeSettingsTimeout    ErrCode = 0x4
+	ErrCodeStreamClosed       ErrCode = 0x5
+	ErrCodeFrameSize          ErrCode = 0x6
+	ErrCodeRefusedStream      ErrCode = 0x7
+	ErrCodeCancel             ErrCode = 0x8
+	ErrCodeCompression        ErrCode = 0x9
+	ErrCodeConnect            ErrCode = 0xa
+	ErrCodeEnhanceYourCalm    ErrCode = 0xb
+	ErrCodeInadequateSecurity ErrCode = 0xc
+	ErrCodeHTTP11Required     ErrCode = 0xd
+)
+
+// Error de conexión: se envía GOAWAY con el código y se cierra la conexión.
+type ConnectionError struct {
+	Code   ErrCode
+	Reason string
+}
+
+func (err *ConnectionError) Error() string {
+	return fmt.Sprintf("http2 connection error %d: %s", err.Code, err.Reason)
+}
+
+// Error de stream: se envía RST_STREAM y la conexión sigue abierta.
+type StreamError struct {
+	StreamID uint32
+	Code     ErrCode
+	Reason   string
+}
+
+func (err *StreamError) Error() string {
+	return fmt.Sprintf("http2 stream %d error %d: %s", err.StreamID, err.Code, err.Reason)
+}
+
+// Una trama recibida.
+type frame struct {
+	Type     byte
+	Flags    byte
+	StreamID uint32
+	Payload  []byte
+}
+
+// Indica si la trama tiene la bandera.
+func (f *frame) has(flag byte) bool {
+	return f.Flags&flag != 0
+}
+
+// Lee una trama. Las tramas de más de maxSize bytes son un error FRAME_SIZE_ERROR.
+func readFrame(r io.Reader, maxSize uint32) (*frame, error) {
+	header := make([]byte, frameHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	length := uint32(header[0])<<16 | uint32(header[1])<<8 | uint32(header[2])
+	f := &frame{
+		Type:     header[3],
+		Flags:    header[4],
+		StreamID: binary.BigEndian.Uint32(header[5:9]) & 0x7FFFFFFF,
+	}
+	if length > maxSize {
+		return nil, &ConnectionError{ErrCodeFrameSize, "frame too large"}
+	}
+
+	f.Payload = make([]byte, length)
+	if _, err := io.ReadFull(r, f.Payload); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Serializa una trama.
+func appendFrame(buffer []byte, frameType, flags byte, streamID uint32, payload []byte) []byte {
+	length := len(payload)
+	buffer = append(buffer, byte(length>>16), byte(length>>8), byte(length), frameType, flags)
+	buffer = binary.BigEndian.AppendUint32(buffer, streamID&0x7FFFFFFF)
+	return append(buffer, payload...)
+}
+
+// Quita el relleno de una trama DATA o HEADERS con la bandera PADDED.
+func removePadding(f *frame) ([]byte, error) {
+	payload := f.Payload
+	if !f.has(flagPadded) {
+		return payload, nil
+	}
+	if len(payload) == 0 {
+		return nil, &ConnectionError{ErrCodeFrameSize, "missing pad length"}
+	}
+	padding := int(payload[0])
+	if padding >= len(payload) {
+		return nil, &ConnectionError{ErrCodeProtocol, "padding exceeds payload"}
+	}
+	return payload[1 : len(payload)-padding], nil
+}
+
+// Un parámetro de SETTINGS.
+type setting struct {
+	ID    uint16
+	Value uint32
+}
+
+// Parsea el contenido de una trama SETTINGS.
+func parseSettings(payload []byte) ([]setting, error) {
+	if len(payload)%6 != 0 {
+		return nil, &ConnectionError{ErrCodeFrameSize, "invalid settings length"}
+	}
+	settings := make([]setting, 0, len(payload)/6)
+	for i := 0; i < len(payload); i += 6 {
+		settings = append(settings, setting{
+			ID:    binary.BigEndian.Uint16(payload[i:]),
+			Value: binary.BigEndian.Uint32(payload[i+2:]),
+		})
+	}
+	return settings, nil
+}
+
+// Serializa parámetros de SETTINGS.
+func encodeSettings(settings []setting) []byte {
+	payload := make([]byte, 0, len(settings)*6)
+	for _, s := range settings {
+		payload = binary.BigEndian.AppendUint16(payload, s.ID)
+		payload = binary.BigEndian.AppendUint32(payload, s.Value)
+	}
+	return payload
+}
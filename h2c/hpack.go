@@ -0,0 +1,373 @@
+package h2c
+
+import "errors"
+
+// Error de compresión de cabeceras: obliga a cerrar la conexión con COMPRESSION_ERROR.
+var errCompression = errors.New("hpack: invalid header block")
+
+// Un campo de cabecera.
+type headerField struct {
+	Name  string
+	Value string
+}
+
+// Tamaño de una entrada de la tabla dinámica (RFC 7541, sección 4.1).
+func (field headerField) size() int {
+	return len(field.Name) + len(field.Value) + 32
+}
+
+// Tabla estática de HPACK (RFC 7541, apéndice A). El índice 1 es staticTable[0].
+var staticTable = []headerField{
+	{":authority", ""},
+	{":method", "GET"},
+	{":method", "POST"},
+	{":path", "/"},
+	{":path", "/index.html"},
+	{":scheme", "http"},
+	{":scheme", "https"},
+	{":status", "200"},
+	{":status", "204"},
+	{":status", "206"},
+	{":status", "304"},
+	{":status", "400"},
+	{":status", "404"},
+	{":status", "500"},
+	{"accept-charset", ""},
+	{"accept-encoding", "gzip, deflate"},
+	{"accept-language", ""},
+	{"accept-ranges", ""},
+	{"accept", ""},
+	{"access-control-allow-origin", ""},
+	{"age", ""},
+	{"allow", ""},
+	{"authorization", ""},
+	{"cache-control", ""},
+	{"content-disposition", ""},
+	{"content-encoding", ""},
+	{"content-language", ""},
+	{"content-length", ""},
+	{"content-location", ""},
+	{"content-range", ""},
+	{"content-type", ""},
+	{"cookie", ""},
+	{"date", ""},
+	{"etag", ""},
+	{"expect", ""},
+	{"expires", ""},
+	{"from", ""},
+	{"host", ""},
+	{"if-match", ""},
+	{"if-modified-since", ""},
+	{"if-none-match", ""},
+	{"if-range", ""},
+	{"if-unmodified-since", ""},
+	{"last-modified", ""},
+	{"link", ""},
+	{"location", ""},
+	{"max-forwards", ""},
+	{"proxy-authenticate", ""},
+	{"proxy-authorization", ""},
+	{"range", ""},
+	{"referer", ""},
+	{"refresh", ""},
+	{"retry-after", ""},
+	{"server", ""},
+	{"set-cookie", ""},
+	{"strict-transport-security", ""},
+	{"transfer-encoding", ""},
+	{"user-agent", ""},
+	{"vary", ""},
+	{"via", ""},
+	{"www-authenticate", ""},
+}
+
+// Decoder decodifica bloques de cabeceras. Mantiene la tabla dinámica, por lo
+// que los bloques deben decodificarse en el orden en que llegan.
+type decoder struct {
+	dynamic []headerField // La entrada más reciente va primero
+	size    int           // Tamaño actual de la tabla dinámica
+	maxSize int           // Tamaño máximo fijado por el codificador
+	limit   int           // Tamaño máximo anunciado (SETTINGS_HEADER_TABLE_SIZE)
+}
+
+func newDecoder(limit int) *decoder {
+	return &decoder{maxSize: limit, limit: limit}
+}
+
+// Devuelve la entrada de índice i (1-based) de la tabla estática o dinámica.
+func (d *decoder) entry(i uint64) (headerField, error) {
+	if i == 0 {
+		return headerField{}, errCompression
+	}
+	if i <= uint64(len(staticTable)) {
+		return staticTable[i-1], nil
+	}
+	i -= uint64(len(staticTable)) + 1
+	if i >= uint64(len(d.dynamic)) {
+		return headerField{}, errCompression
+	}
+	return d.dynamic[i], nil
+}
+
+// Añade una entrada a la tabla dinámica, desalojando las más antiguas.
+func (d *decoder) add(field headerField) {
+	d.dynamic = append([]headerField{field}, d.dynamic...)
+	d.size += field.size()
+	d.evict()
+}
+
+// Desaloja entradas hasta que la tabla cabe en maxSize.
+func (d *decoder) evict() {
+	for d.size > d.maxSize && len(d.dynamic) > 0 {
+		last := d.dynamic[len(d.dynamic)-1]
+		d.dynamic = d.dynamic[:len(d.dynamic)-1]
+		d.size -= last.size()
+	}
+}
+
+// Decodifica un bloque de cabeceras completo. Si los campos superan
+// maxListSize, el bloque se sigue decodificando para mantener la tabla
+// dinámica, pero los campos se descartan y tooLarge es true.
+func (d *decoder) decode(block []byte, maxListSize int) (fields []headerField, tooLarge bool, err error) {
+	fields = make([]headerField, 0)
+	listSize := 0
+	fieldSeen := false
+
+	for len(block) > 0 {
+		b := block[0]
+		var field headerField
+
+		switch {
+		case b&0x80 != 0:
+			// Campo indexado
+			var index uint64
+			index, block, err = readInt(block, 7)
+			if err != nil {
+				return nil, false, err
+			}
+			if field, err = d.entry(index); err != nil {
+				return nil, false, err
+			}
+		case b&0xC0 == 0x40:
+			// Literal con indexación incremental
+			if field, block, err = d.readLiteral(block, 6); err != nil {
+				return nil, false, err
+			}
+			d.add(field)
+		case b&0xE0 == 0x20:
+			// Actualización del tamaño de la tabla: solo al principio del bloque
+			if fieldSeen {
+				return nil, false, errCompression
+			}
+			var size uint64
+			size, block, err = readInt(block, 5)
+			if err != nil {
+				return nil, false, err
+			}
+			if size > uint64(d.limit) {
+				return nil, false, errCompression
+			}
+			d.maxSize = int(size)
+			d.evict()
+			continue
+		default:
+			// Literal sin indexación o nunca indexado
+			if field, block, err = d.readLiteral(block, 4); err != nil {
+				return nil, false, err
+			}
+		}
+
+		fieldSeen = true
+		listSize += field.size()
+		if maxListSize > 0 && listSize > maxListSize {
+			tooLarge, fields = true, nil
+		}
+		if !tooLarge {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, tooLarge, nil
+}
+
+// Lee un campo literal cuyo índice de nombre usa un prefijo de n bits.
+func (d *decoder) readLiteral(block []byte, n uint) (headerField, []byte, error) {
+	index, block, err := readInt(block, n)
+	if err != nil {
+		return headerField{}, nil, err
+	}
+
+	var field headerField
+	if index > 0 {
+		named, err := d.entry(index)
+		if err != nil {
+			return headerField{}, nil, err
+		}
+		field.Name = named.Name
+	} else if field.Name, block, err = readString(block); err != nil {
+		return headerField{}, nil, err
+	}
+
+	if field.Value, block, err = readString(block); err != nil {
+		return headerField{}, nil, err
+	}
+	return field, block, nil
+}
+
+// Lee un entero con prefijo de n bits (RFC 7541, sección 5.1).
+func readInt(block []byte, n uint) (uint64, []byte, error) {
+	if len(block) == 0 {
+		return 0, nil, errCompression
+	}
+
+	max := uint64(1)<<n - 1
+	value := uint64(block[0]) & max
+	block = block[1:]
+	if value < max {
+		return value, block, nil
+	}
+
+	for shift := uint(0); ; shift += 7 {
+		// Un entero de más de 8 bytes de continuación desbordaría
+		if len(block) == 0 || shift > 56 {
+			return 0, nil, errCompression
+		}
+		b := block[0]
+		block = block[1:]
+		value += uint64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return value, block, nil
+		}
+	}
+}
+
+// Lee una cadena con longitud de 7 bits de prefijo, opcionalmente en Huffman.
+func readString(block []byte) (string, []byte, error) {
+	if len(block) == 0 {
+		return "", nil, errCompression
+	}
+	huffman := block[0]&0x80 != 0
+
+	length, block, err := readInt(block, 7)
+	if err != nil {
+		return "", nil, err
+	}
+	if length > uint64(len(block)) {
+		return "", nil, errCompression
+	}
+
+	data := block[:length]
+	block = block[length:]
+	if !huffman {
+		return string(data), block, nil
+	}
+
+	decoded, err := huffmanDecode(data)
+	if err != nil {
+		return "", nil, err
+	}
+	return decoded, block, nil
+}
+
+// Clave de búsqueda de un código Huffman: longitud en bits y código.
+type huffmanKey struct {
+	length uint8
+	code   uint32
+}
+
+// Códigos Huffman por longitud y valor, construido a partir de huffmanCodes.
+var huffmanSymbols = func() map[huffmanKey]byte {
+	symbols := make(map[huffmanKey]byte, len(huffmanCodes))
+	for symbol, code := range huffmanCodes {
+		symbols[huffmanKey{huffmanLengths[symbol], code}] = byte(symbol)
+	}
+	return symbols
+}()
+
+// Decodifica una cadena Huffman. El relleno final debe ser de como mucho
+// 7 bits, todos a 1 (prefijo de EOS).
+func huffmanDecode(data []byte) (string, error) {
+	out := make([]byte, 0, len(data)*8/5)
+	var code uint32
+	var length uint8
+
+	for _, b := range data {
+		for bit := 7; bit >= 0; bit-- {
+			code = code<<1 | uint32(b>>uint(bit)&1)
+			length++
+			if symbol, ok := huffmanSymbols[huffmanKey{length, code}]; ok {
+				out = append(out, symbol)
+				code, length = 0, 0
+				continue
+			}
+			// El código más largo tiene 30 bits; EOS no puede aparecer
+			if length >= 30 {
+				return "", errCompression
+			}
+		}
+	}
+
+	if length > 7 || code != uint32(1)<<length-1 {
+		return "", errCompression
+	}
+	return string(out), nil
+}
+
+// Índices de la tabla estática por nombre y por nombre y valor.
+var (
+	staticByName  = make(map[string]int)
+	staticByField = make(map[headerField]int)
+)
+
+func init() {
+	for i, field := range staticTable {
+		if _, ok := staticByName[field.Name]; !ok {
+			staticByName[field.Name] = i + 1
+		}
+		staticByField[field] = i + 1
+	}
+}
+
+// Codifica un bloque de cabeceras. No usa la tabla dinámica ni Huffman, así
+// que el resultado no depende de bloques anteriores.
+func encodeHeaders(fields []headerField) []byte {
+	block := make([]byte, 0, 64)
+	for _, field := range fields {
+		if index, ok := staticByField[field]; ok {
+			block = appendInt(block, 0x80, 7, uint64(index))
+			continue
+		}
+
+		// Literal sin indexación (0000xxxx)
+		if index, ok := staticByName[field.Name]; ok {
+			block = appendInt(block, 0, 4, uint64(index))
+		} else {
+			block = append(block, 0)
+			block = appendString(block, field.Name)
+		}
+		block = appendString(block, field.Value)
+	}
+	return block
+}
+
+// Añade un entero con prefijo de n bits; first lleva los bits de tipo.
+func appendInt(block []byte, first byte, n uint, value uint64) []byte {
+	max := uint64(1)<<n - 1
+	if value < max {
+		return append(block, first|byte(value))
+	}
+
+	block = append(block, first|byte(max))
+	value -= max
+	for value >= 0x80 {
+		block = append(block, byte(value&0x7F)|0x80)
+		value >>= 7
+	}
+	return append(block, byte(value))
+}
+
+// Añade una cadena literal sin Huffman.
+func appendString(block []byte, value string) []byte {
+	block = appendInt(block, 0, 7, uint64(len(value)))
+	return append(block, value...)
+}
@@ -0,0 +1,133 @@
+package h2c
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeHex(t *testing.T, value string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(strings.ReplaceAll(value, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeRequestsWithHuffman(t *testing.T) {
+	// Arrange: RFC 7541, apéndice C.4 (tres solicitudes con la misma tabla dinámica)
+	d := newDecoder(defaultHeaderTable)
+	blocks := []string{
+		"8286 8441 8cf1 e3c2 e5f2 3a6b a0ab 90f4 ff",
+		"8286 84be 5886 a8eb 1064 9cbf",
+		"8287 85bf 4088 25a8 49e9 5ba9 7d7f 8925 a849 e95b b8e8 b4bf",
+	}
+	expected := [][]headerField{
+		{{":method", "GET"}, {":scheme", "http"}, {":path", "/"}, {":authority", "www.example.com"}},
+		{{":method", "GET"}, {":scheme", "http"}, {":path", "/"}, {":authority", "www.example.com"}, {"cache-control", "no-cache"}},
+		{{":method", "GET"}, {":scheme", "https"}, {":path", "/index.html"}, {":authority", "www.example.com"}, {"custom-key", "custom-value"}},
+	}
+
+	for i, block := range blocks {
+		// Act
+		fields, tooLarge, err := d.decode(decodeHex(t, block), 0)
+
+		// Assert
+		if err != nil || tooLarge {
+			t.Fatalf("Expected block %d to decode, not %v (too large: %v)", i, err, tooLarge)
+		}
+		if !reflect.DeepEqual(fields, expected[i]) {
+			t.Errorf("Expected %v, not %v", expected[i], fields)
+		}
+	}
+
+	if d.size != 164 || len(d.dynamic) != 3 {
+		t.Errorf("Expected dynamic table of 3 entries and 164 bytes, not %d and %d", len(d.dynamic), d.size)
+	}
+}
+
+func TestEncodeHeadersRoundTrip(t *testing.T) {
+	// Arrange
+	fields := []headerField{
+		{":status", "200"},
+		{":status", "418"},
+		{"content-type", "text/plain"},
+		{"x-custom", strings.Repeat("v", 300)},
+	}
+
+	// Act
+	block := encodeHeaders(fields)
+	decoded, _, err := newDecoder(defaultHeaderTable).decode(block, 0)
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, fields) {
+		t.Errorf("Expected %v, not %v", fields, decoded)
+	}
+	// ":status: 200" está completo en la tabla estática
+	if block[0] != 0x88 {
+		t.Errorf("Expected indexed :status 200, not %#x", block[0])
+	}
+}
+
+func TestDecodeHeaderListTooLarge(t *testing.T) {
+	// Arrange: el segundo campo se añade a la tabla dinámica aunque se descarte
+	d := newDecoder(defaultHeaderTable)
+	block := []byte{0x82}
+	block = append(block, 0x40)
+	block = appendString(block, "x-big")
+	block = appendString(block, strings.Repeat("a", 100))
+
+	// Act
+	fields, tooLarge, err := d.decode(block, 64)
+
+	// Assert
+	if err != nil || !tooLarge || fields != nil {
+		t.Fatalf("Expected a too large list without fields, not %v, %v, %v", fields, tooLarge, err)
+	}
+	if len(d.dynamic) != 1 || d.dynamic[0].Name != "x-big" {
+		t.Errorf("Expected the dynamic table to stay in sync, not %v", d.dynamic)
+	}
+}
+
+func TestDecodeInvalidBlocks(t *testing.T) {
+	blocks := []string{
+		"80",                               // Índice 0
+		"be",                               // Índice 62 con la tabla dinámica vacía
+		"3fe21f",                           // Tamaño de tabla mayor que el anunciado
+		"82 20",                            // Actualización de tamaño después de un campo
+		"40 85 ff",                         // Cadena más corta que su longitud
+		"40 81 fe 00",                      // Huffman con relleno distinto de EOS
+		"ff ff ff ff ff ff ff ff ff ff ff", // Entero desbordado
+	}
+
+	for i, block := range blocks {
+		t.Run(fmt.Sprintf("TestDecodeInvalidBlocks %d", i), func(t *testing.T) {
+			// Act
+			_, _, err := newDecoder(defaultHeaderTable).decode(decodeHex(t, block), 0)
+
+			// Assert
+			if err != errCompression {
+				t.Errorf("Expected compression error for %q, not %v", block, err)
+			}
+		})
+	}
+}
+
+func TestHuffmanDecode(t *testing.T) {
+	// Arrange: RFC 7541, C.4.1
+	data, _ := hex.DecodeString("f1e3c2e5f23a6ba0ab90f4ff")
+
+	// Act
+	decoded, err := huffmanDecode(data)
+
+	// Assert
+	if err != nil || decoded != "www.example.com" {
+		t.Errorf("Expected www.example.com, not %q (%v)", decoded, err)
+	}
+}
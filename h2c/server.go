@@ -0,0 +1,931 @@
+package h2c
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Error devuelto al leer o escribir en un stream cancelado o en una conexión cerrada.
+var errStreamClosed = errors.New("http2 stream closed")
+
+// Cabeceras propias de HTTP/1.x que no pueden aparecer en HTTP/2 (RFC 9113, sección 8.2.2).
+var connectionHeaders = map[string]bool{
+	"connection":        true,
+	"keep-alive":        true,
+	"proxy-connection":  true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+}
+
+// Server atiende conexiones HTTP/2 sin TLS (h2c). Cada stream se convierte
+// en un *core.HttpRequest y se entrega a Handler; la respuesta se envía en
+// tramas HEADERS y DATA respetando el control de flujo del cliente.
+//
+//	h2c.Enable(server) // usa server.Dispatch: mismas rutas y middlewares que HTTP/1.x
+type Server struct {
+	Handler              core.Handle
+	MaxConcurrentStreams uint32 // Streams abiertos a la vez por conexión (los demás se rechazan con REFUSED_STREAM)
+	MaxHeaderListSize    uint32 // Tamaño máximo de las cabeceras de una solicitud (431 si se supera)
+}
+
+// Crea un Server que atiende los streams con handler.
+func New(handler core.Handle) *Server {
+	return &Server{
+		Handler:              handler,
+		MaxConcurrentStreams: 100,
+		MaxHeaderListSize:    defaultMaxHeaderList,
+	}
+}
+
+// Enable activa h2c en el servidor HTTP: las conexiones que empiezan con el
+// prefacio de HTTP/2 o piden "Upgrade: h2c" se atienden con HTTP/2, y cada
+// stream pasa por server.Dispatch.
+func Enable(server *core.HttpServer) *Server {
+	h2 := New(func(request *core.HttpRequest) (*core.HttpResponse, error) {
+		return server.Dispatch(request), nil
+	})
+	server.HTTP2 = h2.ServeConn
+	return h2
+}
+
+// Estado de una conexión HTTP/2.
+type serverConn struct {
+	server     *Server
+	conn       net.Conn
+	reader     *bufio.Reader
+	remoteAddr string
+	decoder    *decoder // Solo lo usa la goroutine lectora
+
+	writeMu sync.Mutex // Serializa la escritura de tramas
+
+	mu            sync.Mutex // Protege los campos siguientes y los de cada stream
+	cond          *sync.Cond // Señala cambios en ventanas, datos recibidos y cierres
+	streams       map[uint32]*stream
+	lastStreamID  uint32 // Mayor identificador de stream abierto por el cliente
+	sendWindow    int64  // Ventana de envío de la conexión
+	initialWindow int64  // SETTINGS_INITIAL_WINDOW_SIZE del cliente
+	maxFrameSize  uint32 // SETTINGS_MAX_FRAME_SIZE del cliente
+	closed        bool
+
+	recvWindow int64 // Ventana de recepción de la conexión (goroutine lectora)
+
+	// Bloque de cabeceras en curso (HEADERS seguida de CONTINUATION)
+	headerStream    uint32
+	headerBlock     []byte
+	headerEndStream bool
+}
+
+// Un stream de la conexión.
+type stream struct {
+	id            uint32
+	sendWindow    int64        // Bytes que se pueden enviar
+	recvWindow    int64        // Bytes que el cliente puede enviar todavía
+	body          bytes.Buffer // Datos recibidos pendientes de leer
+	remoteClosed  bool         // El cliente envió END_STREAM
+	reset         bool         // Cancelado con RST_STREAM (por cualquiera de los lados)
+	contentLength int64        // content-length declarado (-1 si no hay)
+	received      int64        // Bytes de datos recibidos
+}
+
+// ServeConn atiende una conexión HTTP/2 hasta que el cliente la cierra o se
+// produce un error de conexión. reader puede contener bytes ya leídos. Si
+// upgrade no es nil, la conexión viene de "Upgrade: h2c" y esa solicitud
+// se responde en el stream 1.
+func (server *Server) ServeConn(conn net.Conn, reader *bufio.Reader, remoteAddr string, upgrade *core.HttpRequest) {
+	sc := &serverConn{
+		server:        server,
+		conn:          conn,
+		reader:        reader,
+		remoteAddr:    remoteAddr,
+		decoder:       newDecoder(defaultHeaderTable),
+		streams:       make(map[uint32]*stream),
+		sendWindow:    defaultWindowSize,
+		initialWindow: defaultWindowSize,
+		maxFrameSize:  defaultMaxFrameSize,
+		recvWindow:    defaultWindowSize,
+	}
+	sc.cond = sync.NewCond(&sc.mu)
+
+	err := sc.serve(upgrade)
+
+	var connErr *ConnectionError
+	if errors.As(err, &connErr) {
+		slog.Warn("HTTP/2 connection error", "address", remoteAddr, "error", err)
+		sc.goAway(connErr.Code, connErr.Reason)
+	}
+
+	sc.mu.Lock()
+	sc.closed = true
+	sc.cond.Broadcast()
+	sc.mu.Unlock()
+}
+
+// Envía el prefacio del servidor, lee el del cliente y procesa tramas.
+func (sc *serverConn) serve(upgrade *core.HttpRequest) error {
+	settings := []setting{
+		{settingMaxConcurrentStreams, sc.server.MaxConcurrentStreams},
+		{settingMaxHeaderListSize, sc.server.MaxHeaderListSize},
+	}
+	if err := sc.writeFrame(frameSettings, 0, 0, encodeSettings(settings)); err != nil {
+		return err
+	}
+
+	if upgrade != nil {
+		if err := sc.startUpgrade(upgrade); err != nil {
+			return err
+		}
+	}
+
+	preface := make([]byte, len(core.HTTP2Preface))
+	if _, err := io.ReadFull(sc.reader, preface); err != nil {
+		return err
+	}
+	if string(preface) != core.HTTP2Preface {
+		return &ConnectionError{ErrCodeProtocol, "invalid connection preface"}
+	}
+
+	for first := true; ; first = false {
+		f, err := readFrame(sc.reader, defaultMaxFrameSize)
+		if err != nil {
+			return err
+		}
+		// La primera trama del cliente debe ser SETTINGS
+		if first && (f.Type != frameSettings || f.has(flagAck)) {
+			return &ConnectionError{ErrCodeProtocol, "expected settings frame"}
+		}
+
+		err = sc.processFrame(f)
+		var streamErr *StreamError
+		if errors.As(err, &streamErr) {
+			sc.resetStream(streamErr.StreamID, streamErr.Code)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Abre el stream 1 con la solicitud que pidió el cambio de protocolo.
+func (sc *serverConn) startUpgrade(upgrade *core.HttpRequest) error {
+	encoded := strings.TrimRight(upgrade.Header("HTTP2-Settings"), "=")
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return &ConnectionError{ErrCodeProtocol, "invalid http2-settings header"}
+	}
+	settings, err := parseSettings(payload)
+	if err != nil {
+		return err
+	}
+	if err := sc.applySettings(settings); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"Upgrade", "Connection", "HTTP2-Settings"} {
+		for key := range upgrade.Headers {
+			if strings.EqualFold(key, name) {
+				delete(upgrade.Headers, key)
+			}
+		}
+	}
+	upgrade.Version = "HTTP/2.0"
+	upgrade.BodyReader = nil
+
+	st := &stream{id: 1, sendWindow: sc.initialWindow, remoteClosed: true, contentLength: -1}
+	sc.mu.Lock()
+	sc.streams[1] = st
+	sc.lastStreamID = 1
+	sc.mu.Unlock()
+
+	go sc.runStream(st, upgrade)
+	return nil
+}
+
+// Procesa una trama recibida.
+func (sc *serverConn) processFrame(f *frame) error {
+	// Entre HEADERS y el fin del bloque solo pueden llegar CONTINUATION del mismo stream
+	if sc.headerStream != 0 && (f.Type != frameContinuation || f.StreamID != sc.headerStream) {
+		return &ConnectionError{ErrCodeProtocol, "expected continuation frame"}
+	}
+
+	switch f.Type {
+	case frameData:
+		return sc.processData(f)
+	case frameHeaders:
+		return sc.processHeaders(f)
+	case frameContinuation:
+		if sc.headerStream == 0 {
+			return &ConnectionError{ErrCodeProtocol, "unexpected continuation frame"}
+		}
+		return sc.appendHeaderBlock(f.Payload, f.has(flagEndHeaders))
+	case framePriority:
+		if f.StreamID == 0 {
+			return &ConnectionError{ErrCodeProtocol, "priority on stream 0"}
+		}
+		if len(f.Payload) != 5 {
+			return &StreamError{f.StreamID, ErrCodeFrameSize, "invalid priority length"}
+		}
+		return nil
+	case frameRSTStream:
+		return sc.processReset(f)
+	case frameSettings:
+		return sc.processSettings(f)
+	case framePushPromise:
+		return &ConnectionError{ErrCodeProtocol, "push promise from client"}
+	case framePing:
+		if f.StreamID != 0 {
+			return &ConnectionError{ErrCodeProtocol, "ping on a stream"}
+		}
+		if len(f.Payload) != 8 {
+			return &ConnectionError{ErrCodeFrameSize, "invalid ping length"}
+		}
+		if f.has(flagAck) {
+			return nil
+		}
+		return sc.writeFrame(framePing, flagAck, 0, f.Payload)
+	case frameGoAway:
+		if f.StreamID != 0 {
+			return &ConnectionError{ErrCodeProtocol, "goaway on a stream"}
+		}
+		// El cliente no abrirá más streams; los abiertos terminan normalmente
+		return nil
+	case frameWindowUpdate:
+		return sc.processWindowUpdate(f)
+	}
+
+	// Los tipos desconocidos se ignoran
+	return nil
+}
+
+// Procesa una trama DATA: descuenta las ventanas y entrega los datos al stream.
+func (sc *serverConn) processData(f *frame) error {
+	if f.StreamID == 0 {
+		return &ConnectionError{ErrCodeProtocol, "data on stream 0"}
+	}
+
+	// La trama completa, con relleno, cuenta para el control de flujo
+	length := int64(len(f.Payload))
+	if length > sc.recvWindow {
+		return &ConnectionError{ErrCodeFlowControl, "connection window exceeded"}
+	}
+	// Los datos se guardan en el stream, limitado por su propia ventana,
+	// así que la ventana de la conexión se repone de inmediato.
+	if length > 0 {
+		if err := sc.writeWindowUpdate(0, uint32(length)); err != nil {
+			return err
+		}
+	}
+
+	data, err := removePadding(f)
+	if err != nil {
+		return err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	st := sc.streams[f.StreamID]
+	if st == nil {
+		if f.StreamID > sc.lastStreamID {
+			return &ConnectionError{ErrCodeProtocol, "data on idle stream"}
+		}
+		return &StreamError{f.StreamID, ErrCodeStreamClosed, "data on closed stream"}
+	}
+	if st.remoteClosed {
+		return &StreamError{f.StreamID, ErrCodeStreamClosed, "data after end of stream"}
+	}
+	if length > st.recvWindow {
+		return &StreamError{f.StreamID, ErrCodeFlowControl, "stream window exceeded"}
+	}
+	st.recvWindow -= length
+
+	// El relleno no llega al manejador: se devuelve a la ventana ahora
+	if padding := length - int64(len(data)); padding > 0 {
+		st.recvWindow += padding
+		go sc.writeWindowUpdate(st.id, uint32(padding))
+	}
+
+	st.body.Write(data)
+	st.received += int64(len(data))
+	if st.contentLength >= 0 && st.received > st.contentLength {
+		return &StreamError{f.StreamID, ErrCodeProtocol, "body longer than content-length"}
+	}
+
+	if f.has(flagEndStream) {
+		st.remoteClosed = true
+		if st.contentLength >= 0 && st.received != st.contentLength {
+			return &StreamError{f.StreamID, ErrCodeProtocol, "body shorter than content-length"}
+		}
+	}
+
+	sc.cond.Broadcast()
+	return nil
+}
+
+// Procesa una trama HEADERS: abre un stream nuevo o recibe los trailers de uno abierto.
+func (sc *serverConn) processHeaders(f *frame) error {
+	if f.StreamID == 0 {
+		return &ConnectionError{ErrCodeProtocol, "headers on stream 0"}
+	}
+
+	payload, err := removePadding(f)
+	if err != nil {
+		return err
+	}
+	if f.has(flagPriority) {
+		if len(payload) < 5 {
+			return &ConnectionError{ErrCodeFrameSize, "invalid priority length"}
+		}
+		payload = payload[5:]
+	}
+
+	sc.mu.Lock()
+	_, open := sc.streams[f.StreamID]
+	lastStreamID := sc.lastStreamID
+	sc.mu.Unlock()
+
+	if !open {
+		// Los streams del cliente son impares y crecientes
+		if f.StreamID%2 == 0 || f.StreamID <= lastStreamID {
+			return &ConnectionError{ErrCodeProtocol, "invalid stream identifier"}
+		}
+	} else if !f.has(flagEndStream) {
+		return &ConnectionError{ErrCodeProtocol, "trailers without end of stream"}
+	}
+
+	sc.headerStream = f.StreamID
+	sc.headerEndStream = f.has(flagEndStream)
+	sc.headerBlock = sc.headerBlock[:0]
+	return sc.appendHeaderBlock(payload, f.has(flagEndHeaders))
+}
+
+// Añade un fragmento al bloque de cabeceras y lo procesa al completarse.
+func (sc *serverConn) appendHeaderBlock(fragment []byte, end bool) error {
+	sc.headerBlock = append(sc.headerBlock, fragment...)
+	// Un bloque mucho mayor que el límite anunciado no se acepta
+	if len(sc.headerBlock) > 4*int(sc.server.MaxHeaderListSize) {
+		return &ConnectionError{ErrCodeEnhanceYourCalm, "header block too large"}
+	}
+	if !end {
+		return nil
+	}
+
+	id := sc.headerStream
+	sc.headerStream = 0
+
+	// El bloque se decodifica siempre para mantener la tabla dinámica sincronizada
+	fields, tooLarge, err := sc.decoder.decode(sc.headerBlock, int(sc.server.MaxHeaderListSize))
+	if err != nil {
+		return &ConnectionError{ErrCodeCompression, err.Error()}
+	}
+
+	sc.mu.Lock()
+	if st, ok := sc.streams[id]; ok {
+		// Tras END_STREAM el cliente no puede enviar más cabeceras (RFC 9113, sección 5.1)
+		if st.remoteClosed {
+			sc.mu.Unlock()
+			return &StreamError{id, ErrCodeStreamClosed, "headers after end of stream"}
+		}
+		// Trailers: se ignoran, pero terminan el cuerpo
+		st.remoteClosed = true
+		sc.cond.Broadcast()
+		sc.mu.Unlock()
+		return nil
+	}
+	sc.lastStreamID = id
+	active := len(sc.streams)
+	sc.mu.Unlock()
+
+	st := &stream{id: id, recvWindow: defaultWindowSize, remoteClosed: sc.headerEndStream, contentLength: -1}
+	var request *core.HttpRequest
+	if !tooLarge {
+		if request, err = sc.newRequest(st, fields); err != nil {
+			return &StreamError{id, ErrCodeProtocol, err.Error()}
+		}
+	}
+
+	if uint32(active) >= sc.server.MaxConcurrentStreams {
+		return &StreamError{id, ErrCodeRefusedStream, "too many concurrent streams"}
+	}
+
+	if tooLarge {
+		st.remoteClosed = true
+		sc.addStream(st)
+		go sc.finishStream(st, core.NewHttpResponse(431, "Request Header Fields Too Large", "").Text("request header fields too large"), "GET")
+		return nil
+	}
+
+	sc.addStream(st)
+	go sc.runStream(st, request)
+	return nil
+}
+
+// Registra un stream abierto con la ventana de envío inicial actual.
+func (sc *serverConn) addStream(st *stream) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	st.sendWindow = sc.initialWindow
+	sc.streams[st.id] = st
+}
+
+// Convierte las cabeceras de un stream en una solicitud (RFC 9113, sección 8.3.1).
+func (sc *serverConn) newRequest(st *stream, fields []headerField) (*core.HttpRequest, error) {
+	var method, scheme, authority, path string
+	headers := make(map[string]string)
+	regular := false
+
+	for _, field := range fields {
+		if strings.HasPrefix(field.Name, ":") {
+			if regular {
+				return nil, errors.New("pseudo-header after regular header")
+			}
+			var target *string
+			switch field.Name {
+			case ":method":
+				target = &method
+			case ":scheme":
+				target = &scheme
+			case ":authority":
+				target = &authority
+			case ":path":
+				target = &path
+			default:
+				return nil, errors.New("unknown pseudo-header " + field.Name)
+			}
+			if *target != "" {
+				return nil, errors.New("duplicated pseudo-header " + field.Name)
+			}
+			*target = field.Value
+			continue
+		}
+
+		regular = true
+		if field.Name != strings.ToLower(field.Name) {
+			return nil, errors.New("uppercase header name")
+		}
+		if connectionHeaders[field.Name] || (field.Name == "te" && field.Value != "trailers") {
+			return nil, errors.New("connection-specific header " + field.Name)
+		}
+
+		key := canonicalKey(field.Name)
+		if previous, ok := headers[key]; ok {
+			separator := ", "
+			if key == "Cookie" {
+				separator = "; "
+			}
+			headers[key] = previous + separator + field.Value
+		} else {
+			headers[key] = field.Value
+		}
+	}
+
+	if method == "" {
+		return nil, errors.New("missing :method")
+	}
+
+	var target *url.URL
+	if method == "CONNECT" {
+		if authority == "" || scheme != "" || path != "" {
+			return nil, errors.New("invalid connect request")
+		}
+		target = &url.URL{Host: authority}
+	} else {
+		if scheme == "" || path == "" {
+			return nil, errors.New("missing :scheme or :path")
+		}
+		var err error
+		if path == "*" {
+			target = &url.URL{Path: "*"}
+		} else if target, err = url.ParseRequestURI(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if authority != "" {
+		headers["Host"] = authority
+	}
+
+	if value, ok := headers["Content-Length"]; ok {
+		length, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || length < 0 {
+			return nil, errors.New("invalid content-length")
+		}
+		st.contentLength = length
+	}
+
+	request := core.NewHttpRequest(method, target, headers, "")
+	request.Version = "HTTP/2.0"
+	request.RemoteAddr = sc.remoteAddr
+	if !st.remoteClosed {
+		request.BodyReader = &streamBody{sc: sc, st: st}
+	}
+	return request, nil
+}
+
+// Convierte un nombre en minúsculas a la forma habitual ("content-type" → "Content-Type").
+func canonicalKey(name string) string {
+	parts := strings.Split(name, "-")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// Procesa RST_STREAM: cancela el stream.
+func (sc *serverConn) processReset(f *frame) error {
+	if f.StreamID == 0 {
+		return &ConnectionError{ErrCodeProtocol, "rst_stream on stream 0"}
+	}
+	if len(f.Payload) != 4 {
+		return &ConnectionError{ErrCodeFrameSize, "invalid rst_stream length"}
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if f.StreamID > sc.lastStreamID {
+		return &ConnectionError{ErrCodeProtocol, "rst_stream on idle stream"}
+	}
+	if st, ok := sc.streams[f.StreamID]; ok {
+		st.reset = true
+		delete(sc.streams, f.StreamID)
+		sc.cond.Broadcast()
+	}
+	return nil
+}
+
+// Procesa SETTINGS: aplica los parámetros y confirma con ACK.
+func (sc *serverConn) processSettings(f *frame) error {
+	if f.StreamID != 0 {
+		return &ConnectionError{ErrCodeProtocol, "settings on a stream"}
+	}
+	if f.has(flagAck) {
+		if len(f.Payload) != 0 {
+			return &ConnectionError{ErrCodeFrameSize, "settings ack with payload"}
+		}
+		return nil
+	}
+
+	settings, err := parseSettings(f.Payload)
+	if err != nil {
+		return err
+	}
+	if err := sc.applySettings(settings); err != nil {
+		return err
+	}
+	return sc.writeFrame(frameSettings, flagAck, 0, nil)
+}
+
+// Aplica los parámetros de SETTINGS del cliente.
+func (sc *serverConn) applySettings(settings []setting) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	for _, s := range settings {
+		switch s.ID {
+		case settingEnablePush:
+			if s.Value > 1 {
+				return &ConnectionError{ErrCodeProtocol, "invalid enable_push"}
+			}
+		case settingInitialWindowSize:
+			if s.Value > maxWindowSize {
+				return &ConnectionError{ErrCodeFlowControl, "initial window too large"}
+			}
+			// El cambio se aplica a las ventanas de todos los streams abiertos
+			delta := int64(s.Value) - sc.initialWindow
+			sc.initialWindow = int64(s.Value)
+			for _, st := range sc.streams {
+				st.sendWindow += delta
+				if st.sendWindow > maxWindowSize {
+					return &ConnectionError{ErrCodeFlowControl, "stream window overflow"}
+				}
+			}
+		case settingMaxFrameSize:
+			if s.Value < defaultMaxFrameSize || s.Value > maxAllowedFrameSize {
+				return &ConnectionError{ErrCodeProtocol, "invalid max_frame_size"}
+			}
+			sc.maxFrameSize = s.Value
+		}
+	}
+
+	sc.cond.Broadcast()
+	return nil
+}
+
+// Procesa WINDOW_UPDATE: amplía la ventana de envío de la conexión o de un stream.
+func (sc *serverConn) processWindowUpdate(f *frame) error {
+	if len(f.Payload) != 4 {
+		return &ConnectionError{ErrCodeFrameSize, "invalid window_update length"}
+	}
+	increment := int64(binary.BigEndian.Uint32(f.Payload) & 0x7FFFFFFF)
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if f.StreamID == 0 {
+		if increment == 0 {
+			return &ConnectionError{ErrCodeProtocol, "zero window increment"}
+		}
+		if sc.sendWindow+increment > maxWindowSize {
+			return &ConnectionError{ErrCodeFlowControl, "connection window overflow"}
+		}
+		sc.sendWindow += increment
+		sc.cond.Broadcast()
+		return nil
+	}
+
+	st, ok := sc.streams[f.StreamID]
+	if !ok {
+		if f.StreamID > sc.lastStreamID {
+			return &ConnectionError{ErrCodeProtocol, "window_update on idle stream"}
+		}
+		// Stream ya cerrado: se ignora
+		return nil
+	}
+	if increment == 0 {
+		return &StreamError{f.StreamID, ErrCodeProtocol, "zero window increment"}
+	}
+	if st.sendWindow+increment > maxWindowSize {
+		return &StreamError{f.StreamID, ErrCodeFlowControl, "stream window overflow"}
+	}
+	st.sendWindow += increment
+	sc.cond.Broadcast()
+	return nil
+}
+
+// Ejecuta el manejador del stream y envía su respuesta.
+func (sc *serverConn) runStream(st *stream, request *core.HttpRequest) {
+	response, err := sc.server.Handler(request)
+	if err != nil || response == nil {
+		response = core.NewHttpResponse(500, "Internal Server Error", "500 Internal Server Error")
+	}
+	// Tomar la conexión o cambiar de protocolo no tiene sentido en un stream
+	if response.Hijack != nil || response.Upgrade != nil {
+		response = core.NewHttpResponse(501, "Not Implemented", "").Text("not supported over http/2")
+	}
+	sc.finishStream(st, response, request.Method)
+}
+
+// Envía la respuesta y cierra el stream. Si el cliente no terminó de enviar
+// el cuerpo, lo cancela con RST_STREAM NO_ERROR (RFC 9113, sección 8.1).
+func (sc *serverConn) finishStream(st *stream, response *core.HttpResponse, method string) {
+	if err := sc.writeResponse(st, response, method); err != nil && !errors.Is(err, errStreamClosed) {
+		slog.Warn("HTTP/2 response failed", "address", sc.remoteAddr, "stream", st.id, "error", err)
+		sc.resetStream(st.id, ErrCodeInternal)
+		return
+	}
+
+	sc.mu.Lock()
+	remoteClosed := st.remoteClosed || st.reset
+	delete(sc.streams, st.id)
+	sc.mu.Unlock()
+
+	if !remoteClosed {
+		sc.resetStream(st.id, ErrCodeNo)
+	}
+}
+
+// Indica si el código de estado permite enviar un cuerpo.
+func allowsBody(code int) bool {
+	return !(code >= 100 && code < 200) && code != 204 && code != 304
+}
+
+// Escribe la respuesta: HEADERS (y CONTINUATION) y el cuerpo en tramas DATA.
+func (sc *serverConn) writeResponse(st *stream, response *core.HttpResponse, method string) error {
+	if closer, ok := response.BodyReader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	slog.Info("Response", "address", sc.remoteAddr, "stream", st.id, "status_code", response.StatusCode, "status_text", response.StatusText)
+
+	withBody := method != "HEAD" && allowsBody(response.StatusCode)
+	length := response.BodyLength()
+
+	fields := []headerField{{":status", strconv.Itoa(response.StatusCode)}}
+	keys := make([]string, 0, len(response.Headers))
+	for key := range response.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := strings.ToLower(key)
+		if connectionHeaders[name] || name == "content-length" {
+			continue
+		}
+		fields = append(fields, headerField{name, response.Headers[key]})
+	}
+	for _, cookie := range response.SetCookies {
+		fields = append(fields, headerField{"set-cookie", cookie})
+	}
+	if allowsBody(response.StatusCode) && length >= 0 {
+		fields = append(fields, headerField{"content-length", strconv.FormatInt(length, 10)})
+	}
+
+	endStream := !withBody || length == 0
+	if err := sc.writeHeaders(st, encodeHeaders(fields), endStream); err != nil {
+		return err
+	}
+	if endStream {
+		return nil
+	}
+
+	if !response.IsStreamed() {
+		return sc.writeData(st, response.BodyBytes(), true)
+	}
+
+	reader := response.BodyReader
+	if length >= 0 {
+		reader = io.LimitReader(reader, length)
+	}
+	buffer := make([]byte, defaultMaxFrameSize)
+	for {
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			if err := sc.writeData(st, buffer[:n], false); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return sc.writeData(st, nil, true)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Escribe un bloque de cabeceras dividido en HEADERS y CONTINUATION según el
+// tamaño máximo de trama del cliente. Las tramas se envían juntas para que no
+// se intercale ninguna otra.
+func (sc *serverConn) writeHeaders(st *stream, block []byte, endStream bool) error {
+	sc.mu.Lock()
+	maxSize := int(sc.maxFrameSize)
+	reset := st.reset || sc.closed
+	sc.mu.Unlock()
+	if reset {
+		return errStreamClosed
+	}
+
+	frames := make([]byte, 0, len(block)+frameHeaderLength)
+	frameType := byte(frameHeaders)
+	flags := byte(0)
+	if endStream {
+		flags |= flagEndStream
+	}
+	for {
+		chunk := block
+		if len(chunk) > maxSize {
+			chunk = chunk[:maxSize]
+		}
+		block = block[len(chunk):]
+		if len(block) == 0 {
+			flags |= flagEndHeaders
+		}
+		frames = appendFrame(frames, frameType, flags, st.id, chunk)
+		if len(block) == 0 {
+			break
+		}
+		frameType, flags = frameContinuation, 0
+	}
+	return sc.write(frames)
+}
+
+// Escribe datos en tramas DATA, esperando a que las ventanas de la conexión
+// y del stream lo permitan. Con end, la última trama lleva END_STREAM.
+func (sc *serverConn) writeData(st *stream, data []byte, end bool) error {
+	if len(data) == 0 {
+		if !end {
+			return nil
+		}
+		return sc.writeFrame(frameData, flagEndStream, st.id, nil)
+	}
+
+	for len(data) > 0 {
+		n, err := sc.reserve(st, len(data))
+		if err != nil {
+			return err
+		}
+		flags := byte(0)
+		if end && n == len(data) {
+			flags = flagEndStream
+		}
+		if err := sc.writeFrame(frameData, flags, st.id, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// Reserva hasta want bytes de las ventanas de envío, esperando si están agotadas.
+func (sc *serverConn) reserve(st *stream, want int) (int, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	for {
+		if sc.closed || st.reset {
+			return 0, errStreamClosed
+		}
+		n := min(int64(want), sc.sendWindow, st.sendWindow, int64(sc.maxFrameSize))
+		if n > 0 {
+			sc.sendWindow -= n
+			st.sendWindow -= n
+			return int(n), nil
+		}
+		sc.cond.Wait()
+	}
+}
+
+// Cancela un stream y envía RST_STREAM con el código dado.
+func (sc *serverConn) resetStream(id uint32, code ErrCode) {
+	sc.mu.Lock()
+	if st, ok := sc.streams[id]; ok {
+		st.reset = true
+		delete(sc.streams, id)
+		sc.cond.Broadcast()
+	}
+	sc.mu.Unlock()
+
+	sc.writeFrame(frameRSTStream, 0, id, binary.BigEndian.AppendUint32(nil, uint32(code)))
+}
+
+// Envía GOAWAY con el último stream procesado y el código de error.
+func (sc *serverConn) goAway(code ErrCode, reason string) {
+	sc.mu.Lock()
+	lastStreamID := sc.lastStreamID
+	sc.mu.Unlock()
+
+	payload := binary.BigEndian.AppendUint32(nil, lastStreamID)
+	payload = binary.BigEndian.AppendUint32(payload, uint32(code))
+	payload = append(payload, reason...)
+	sc.writeFrame(frameGoAway, 0, 0, payload)
+}
+
+// Envía WINDOW_UPDATE para la conexión (id 0) o un stream.
+func (sc *serverConn) writeWindowUpdate(id uint32, increment uint32) error {
+	return sc.writeFrame(frameWindowUpdate, 0, id, binary.BigEndian.AppendUint32(nil, increment))
+}
+
+// Escribe una trama.
+func (sc *serverConn) writeFrame(frameType, flags byte, id uint32, payload []byte) error {
+	return sc.write(appendFrame(nil, frameType, flags, id, payload))
+}
+
+// Escribe tramas ya serializadas en la conexión.
+func (sc *serverConn) write(frames []byte) error {
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+	_, err := sc.conn.Write(frames)
+	return err
+}
+
+// Cuerpo de la solicitud de un stream. Cada lectura devuelve al cliente la
+// ventana consumida con WINDOW_UPDATE.
+type streamBody struct {
+	sc *serverConn
+	st *stream
+}
+
+func (body *streamBody) Read(p []byte) (int, error) {
+	sc, st := body.sc, body.st
+
+	sc.mu.Lock()
+	for st.body.Len() == 0 && !st.remoteClosed && !st.reset && !sc.closed {
+		sc.cond.Wait()
+	}
+
+	if st.body.Len() > 0 {
+		n, _ := st.body.Read(p)
+		update := !st.remoteClosed && !st.reset
+		if update {
+			st.recvWindow += int64(n)
+		}
+		sc.mu.Unlock()
+
+		if update {
+			sc.writeWindowUpdate(st.id, uint32(n))
+		}
+		return n, nil
+	}
+
+	defer sc.mu.Unlock()
+	if st.reset || (sc.closed && !st.remoteClosed) {
+		return 0, errStreamClosed
+	}
+	return 0, io.EOF
+}
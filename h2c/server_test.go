@@ -0,0 +1,439 @@
+package h2c
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Cliente HTTP/2 mínimo para las pruebas.
+type testClient struct {
+	t       *testing.T
+	conn    net.Conn
+	reader  *bufio.Reader
+	decoder *decoder
+}
+
+// Respuesta recibida en un stream.
+type testResponse struct {
+	headers map[string]string
+	body    string
+}
+
+// Arranca un servidor con h2c activado y devuelve su dirección.
+func startServer(t *testing.T, configure func(server *core.HttpServer, h2 *Server)) string {
+	t.Helper()
+	server := core.NewHttpServer()
+	h2 := Enable(server)
+	configure(server, h2)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+	return ln.Addr().String()
+}
+
+// Conecta con prior knowledge: prefacio y SETTINGS del cliente.
+func dialClient(t *testing.T, address string, settings ...setting) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	client := &testClient{t: t, conn: conn, reader: bufio.NewReader(conn), decoder: newDecoder(defaultHeaderTable)}
+	client.write([]byte(core.HTTP2Preface))
+	client.writeFrame(frameSettings, 0, 0, encodeSettings(settings))
+	return client
+}
+
+func (client *testClient) write(data []byte) {
+	if _, err := client.conn.Write(data); err != nil {
+		client.t.Fatal(err)
+	}
+}
+
+func (client *testClient) writeFrame(frameType, flags byte, id uint32, payload []byte) {
+	client.write(appendFrame(nil, frameType, flags, id, payload))
+}
+
+// Envía una solicitud en un stream; con body vacío, HEADERS lleva END_STREAM.
+func (client *testClient) request(id uint32, method, path string, body string) {
+	block := encodeHeaders([]headerField{{":method", method}, {":scheme", "http"}, {":path", path}, {":authority", "example.com"}})
+	flags := byte(flagEndHeaders)
+	if body == "" {
+		flags |= flagEndStream
+	}
+	client.writeFrame(frameHeaders, flags, id, block)
+	if body != "" {
+		client.writeFrame(frameData, flagEndStream, id, []byte(body))
+	}
+}
+
+// Lee la siguiente trama que no sea de control (SETTINGS, WINDOW_UPDATE o PING).
+func (client *testClient) next() *frame {
+	client.t.Helper()
+	for {
+		f, err := readFrame(client.reader, maxAllowedFrameSize)
+		if err != nil {
+			client.t.Fatalf("Expected a frame, not %v", err)
+		}
+		switch f.Type {
+		case frameSettings, frameWindowUpdate, framePing:
+			continue
+		}
+		return f
+	}
+}
+
+// Lee respuestas hasta que count streams terminan. Devuelve el orden en que
+// terminaron y las respuestas por stream.
+func (client *testClient) responses(count int) ([]uint32, map[uint32]*testResponse) {
+	client.t.Helper()
+	order := make([]uint32, 0, count)
+	responses := make(map[uint32]*testResponse)
+
+	for len(order) < count {
+		f := client.next()
+		response := responses[f.StreamID]
+		if response == nil {
+			response = &testResponse{headers: make(map[string]string)}
+			responses[f.StreamID] = response
+		}
+
+		switch f.Type {
+		case frameHeaders, frameContinuation:
+			fields, _, err := client.decoder.decode(f.Payload, 0)
+			if err != nil {
+				client.t.Fatal(err)
+			}
+			for _, field := range fields {
+				response.headers[field.Name] = field.Value
+			}
+		case frameData:
+			response.body += string(f.Payload)
+		default:
+			client.t.Fatalf("Expected headers or data, not frame type %d", f.Type)
+		}
+
+		if f.has(flagEndStream) {
+			order = append(order, f.StreamID)
+		}
+	}
+	return order, responses
+}
+
+// Lee tramas hasta encontrar una del tipo dado.
+func (client *testClient) expect(frameType byte) *frame {
+	client.t.Helper()
+	for {
+		f := client.next()
+		if f.Type == frameType {
+			return f
+		}
+	}
+}
+
+func TestPriorKnowledge(t *testing.T) {
+	// Arrange
+	address := startServer(t, func(server *core.HttpServer, h2 *Server) {
+		hello := func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			return core.Ok().Text(request.Version + " " + request.Host + " " + request.Header("X-Test")), nil
+		}
+		server.Get("/hello", hello)
+		server.Head("/hello", hello)
+	})
+	client := dialClient(t, address)
+
+	// Act
+	block := encodeHeaders([]headerField{
+		{":method", "GET"}, {":scheme", "http"}, {":path", "/hello"}, {":authority", "example.com"}, {"x-test", "yes"},
+	})
+	client.writeFrame(frameHeaders, flagEndHeaders|flagEndStream, 1, block)
+	client.request(3, "HEAD", "/hello", "")
+	client.request(5, "GET", "/missing", "")
+	_, responses := client.responses(3)
+
+	// Assert
+	hello := responses[1]
+	if hello.headers[":status"] != "200" || hello.body != "HTTP/2.0 example.com yes" {
+		t.Errorf("Expected 200 with request data, not %s %q", hello.headers[":status"], hello.body)
+	}
+	if hello.headers["content-length"] != "24" {
+		t.Errorf("Expected content-length 24, not %q", hello.headers["content-length"])
+	}
+	if head := responses[3]; head.headers[":status"] != "200" || head.body != "" {
+		t.Errorf("Expected HEAD without body, not %s %q", head.headers[":status"], head.body)
+	}
+	if missing := responses[5]; missing.headers[":status"] != "404" {
+		t.Errorf("Expected 404, not %s", missing.headers[":status"])
+	}
+}
+
+func TestMultiplexing(t *testing.T) {
+	// Arrange: /slow espera hasta que /fast se haya respondido
+	release := make(chan struct{})
+	address := startServer(t, func(server *core.HttpServer, h2 *Server) {
+		server.Get("/slow", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			<-release
+			return core.Ok().Text("slow"), nil
+		})
+		server.Get("/fast", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			return core.Ok().Text("fast"), nil
+		})
+	})
+	client := dialClient(t, address)
+
+	// Act
+	client.request(1, "GET", "/slow", "")
+	client.request(3, "GET", "/fast", "")
+	first, responses := client.responses(1)
+	close(release)
+	second, more := client.responses(1)
+
+	// Assert
+	if first[0] != 3 || responses[3].body != "fast" {
+		t.Errorf("Expected stream 3 to finish first, not %v", first)
+	}
+	if second[0] != 1 || more[1].body != "slow" {
+		t.Errorf("Expected stream 1 afterwards, not %v", second)
+	}
+}
+
+func TestFlowControl(t *testing.T) {
+	// Arrange: el cliente solo admite 10 bytes por stream
+	address := startServer(t, func(server *core.HttpServer, h2 *Server) {
+		server.Get("/data", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			return core.Ok().Text(strings.Repeat("x", 25)), nil
+		})
+	})
+	client := dialClient(t, address, setting{settingInitialWindowSize, 10})
+
+	// Act
+	client.request(1, "GET", "/data", "")
+	client.expect(frameHeaders)
+	data := client.expect(frameData)
+
+	// Assert
+	if len(data.Payload) != 10 || data.has(flagEndStream) {
+		t.Fatalf("Expected 10 bytes limited by the window, not %d", len(data.Payload))
+	}
+
+	// Sin WINDOW_UPDATE el servidor no envía nada más
+	client.conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err := client.reader.Peek(1); err == nil {
+		t.Fatal("Expected no data while the window is exhausted")
+	}
+	client.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	client.writeFrame(frameWindowUpdate, 0, 1, binary.BigEndian.AppendUint32(nil, 15))
+	received := 0
+	for received < 15 {
+		data = client.expect(frameData)
+		received += len(data.Payload)
+	}
+	if received != 15 || !data.has(flagEndStream) {
+		t.Errorf("Expected the remaining 15 bytes and end of stream, not %d", received)
+	}
+}
+
+func TestRequestBody(t *testing.T) {
+	// Arrange
+	address := startServer(t, func(server *core.HttpServer, h2 *Server) {
+		server.Post("/echo", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			return core.Ok().Text(strings.ToUpper(request.Body)), nil
+		})
+	})
+	client := dialClient(t, address)
+
+	// Act
+	client.request(1, "POST", "/echo", "hello h2")
+	_, responses := client.responses(1)
+
+	// Assert
+	if responses[1].body != "HELLO H2" {
+		t.Errorf("Expected echoed body, not %q", responses[1].body)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	// Arrange
+	address := startServer(t, func(server *core.HttpServer, h2 *Server) {
+		server.Post("/echo", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			return core.Ok().Text(request.Version + " " + request.Body + " " + request.Header("Upgrade")), nil
+		})
+	})
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Act
+	fmt.Fprint(conn, "POST /echo HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade, HTTP2-Settings\r\n"+
+		"Upgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQAAP__\r\nContent-Length: 4\r\n\r\nbody")
+	reader := bufio.NewReader(conn)
+	status, _ := reader.ReadString('\n')
+	for line, _ := reader.ReadString('\n'); line != "\r\n" && line != ""; line, _ = reader.ReadString('\n') {
+	}
+
+	client := &testClient{t: t, conn: conn, reader: reader, decoder: newDecoder(defaultHeaderTable)}
+	client.write([]byte(core.HTTP2Preface))
+	client.writeFrame(frameSettings, 0, 0, nil)
+	_, responses := client.responses(1)
+
+	// Assert
+	if status != "HTTP/1.1 101 Switching Protocols\r\n" {
+		t.Fatalf("Expected 101, not %q", status)
+	}
+	if responses[1].body != "HTTP/2.0 body " {
+		t.Errorf("Expected the upgrade request answered on stream 1, not %q", responses[1].body)
+	}
+}
+
+func TestHTTP1StillServed(t *testing.T) {
+	// Arrange
+	address := startServer(t, func(server *core.HttpServer, h2 *Server) {
+		server.Get("/hello", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			return core.Ok().Text("hello"), nil
+		})
+	})
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Act
+	fmt.Fprint(conn, "GET /hello HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n")
+	data, _ := io.ReadAll(conn)
+
+	// Assert
+	if !strings.HasPrefix(string(data), "HTTP/1.1 200") || !strings.HasSuffix(string(data), "hello") {
+		t.Errorf("Expected HTTP/1.1 response, not %q", data)
+	}
+}
+
+func TestConnectionErrors(t *testing.T) {
+	tests := []struct {
+		frameType byte
+		flags     byte
+		id        uint32
+		payload   []byte
+		code      ErrCode
+	}{
+		// Stream par
+		{frameHeaders, flagEndHeaders | flagEndStream, 2, encodeHeaders([]headerField{{":method", "GET"}}), ErrCodeProtocol},
+		// Bloque HPACK inválido
+		{frameHeaders, flagEndHeaders | flagEndStream, 1, []byte{0x80}, ErrCodeCompression},
+		// DATA en un stream que no existe
+		{frameData, 0, 7, []byte("x"), ErrCodeProtocol},
+		// WINDOW_UPDATE de 0 en la conexión
+		{frameWindowUpdate, 0, 0, []byte{0, 0, 0, 0}, ErrCodeProtocol},
+		// PING de longitud incorrecta
+		{framePing, 0, 0, []byte{1}, ErrCodeFrameSize},
+		// CONTINUATION sin HEADERS
+		{frameContinuation, flagEndHeaders, 1, nil, ErrCodeProtocol},
+	}
+
+	address := startServer(t, func(server *core.HttpServer, h2 *Server) {})
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("TestConnectionErrors %d", i), func(t *testing.T) {
+			// Arrange
+			client := dialClient(t, address)
+
+			// Act
+			client.writeFrame(test.frameType, test.flags, test.id, test.payload)
+			goAway := client.expect(frameGoAway)
+
+			// Assert
+			if code := ErrCode(binary.BigEndian.Uint32(goAway.Payload[4:])); code != test.code {
+				t.Errorf("Expected error code %d, not %d", test.code, code)
+			}
+			if _, err := client.reader.ReadByte(); err != io.EOF {
+				t.Errorf("Expected connection closed, not %v", err)
+			}
+		})
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	// Arrange: un único stream a la vez
+	release := make(chan struct{})
+	defer close(release)
+	address := startServer(t, func(server *core.HttpServer, h2 *Server) {
+		h2.MaxConcurrentStreams = 1
+		server.Get("/wait", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			<-release
+			return core.Ok(), nil
+		})
+	})
+	client := dialClient(t, address)
+
+	// Act
+	client.request(1, "GET", "/wait", "")
+	client.request(3, "GET", "/wait", "")
+	refused := client.expect(frameRSTStream)
+	// Una cabecera en mayúsculas es una solicitud mal formada
+	client.writeFrame(frameHeaders, flagEndHeaders|flagEndStream, 5, encodeHeaders([]headerField{
+		{":method", "GET"}, {":scheme", "http"}, {":path", "/"}, {"X-Upper", "1"},
+	}))
+	malformed := client.expect(frameRSTStream)
+
+	// Assert
+	if refused.StreamID != 3 || ErrCode(binary.BigEndian.Uint32(refused.Payload)) != ErrCodeRefusedStream {
+		t.Errorf("Expected stream 3 refused, not stream %d code %x", refused.StreamID, refused.Payload)
+	}
+	if malformed.StreamID != 5 || ErrCode(binary.BigEndian.Uint32(malformed.Payload)) != ErrCodeProtocol {
+		t.Errorf("Expected stream 5 protocol error, not stream %d code %x", malformed.StreamID, malformed.Payload)
+	}
+
+	// La conexión sigue abierta
+	client.writeFrame(framePing, 0, 0, make([]byte, 8))
+	for {
+		f, err := readFrame(client.reader, maxAllowedFrameSize)
+		if err != nil {
+			t.Fatalf("Expected ping ack, not %v", err)
+		}
+		if f.Type == framePing && f.has(flagAck) {
+			break
+		}
+	}
+}
+
+func TestHeadersAfterEndStream(t *testing.T) {
+	// Arrange: un stream que sigue abierto porque el manejador no responde
+	release := make(chan struct{})
+	defer close(release)
+	address := startServer(t, func(server *core.HttpServer, h2 *Server) {
+		server.Get("/wait", func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			<-release
+			return core.Ok(), nil
+		})
+	})
+	client := dialClient(t, address)
+	client.request(1, "GET", "/wait", "")
+
+	// Act: el cliente ya cerró su lado del stream con la solicitud
+	client.writeFrame(frameHeaders, flagEndHeaders|flagEndStream, 1, encodeHeaders([]headerField{{"x-trailer", "1"}}))
+	reset := client.expect(frameRSTStream)
+
+	// Assert
+	if reset.StreamID != 1 || ErrCode(binary.BigEndian.Uint32(reset.Payload)) != ErrCodeStreamClosed {
+		t.Errorf("Expected stream 1 closed error, not stream %d code %x", reset.StreamID, reset.Payload)
+	}
+}
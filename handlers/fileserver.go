@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Tipos MIME que no siempre están en la tabla del sistema.
+var extraMimeTypes = map[string]string{
+	".txt":  "text/plain; charset=utf-8",
+	".md":   "text/markdown; charset=utf-8",
+	".csv":  "text/csv; charset=utf-8",
+	".log":  "text/plain; charset=utf-8",
+	".ico":  "image/x-icon",
+	".mp4":  "video/mp4",
+	".zip":  "application/zip",
+	".gz":   "application/gzip",
+	".woff": "font/woff",
+}
+
+// FileServer sirve archivos estáticos desde un directorio raíz.
+// Se monta en un prefijo, por ejemplo:
+//
+//	static := handlers.NewFileServer("./public")
+//	static.Prefix = "/static"
+//	server.Get("/static", static.Handle)
+type FileServer struct {
+	Root       string // Directorio raíz desde el que se sirven los archivos
+	Prefix     string // Prefijo de la ruta que se elimina antes de buscar el archivo
+	Index      string // Archivo servido para un directorio (vacío para desactivarlo)
+	Listing    bool   // Si es true, genera listados de directorios sin índice
+	ShowHidden bool   // Si es true, permite servir archivos que empiezan por "."
+}
+
+// Crea un FileServer para root con index.html como índice y sin listados.
+func NewFileServer(root string) *FileServer {
+	return &FileServer{
+		Root:  root,
+		Index: "index.html",
+	}
+}
+
+// Entrada de un listado de directorio en formato JSON.
+type dirEntry struct {
+	Name     string `json:"name"`
+	Dir      bool   `json:"dir"`
+	Size     int64  `json:"size"`
+	Modified string `json:"modified"`
+}
+
+// Handle atiende GET y HEAD sobre los archivos del directorio raíz.
+func (fileServer *FileServer) Handle(req *core.HttpRequest) (*core.HttpResponse, error) {
+	name, errResp := fileServer.relativePath(req.Target.Path)
+	if errResp != nil {
+		return errResp, nil
+	}
+
+	full, errResp := fileServer.resolve(name)
+	if errResp != nil {
+		return errResp, nil
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return core.NotFound().Text("404 Not Found"), nil
+	}
+
+	if !info.IsDir() {
+		return serveFile(req, full, info)
+	}
+
+	// Los directorios se sirven con "/" final para que los enlaces relativos funcionen.
+	if !strings.HasSuffix(req.Target.Path, "/") {
+		location := (&url.URL{Path: req.Target.Path + "/", RawQuery: req.Target.RawQuery}).String()
+		return core.NewHttpResponse(301, "Moved Permanently", "").SetHeader("Location", location), nil
+	}
+
+	if fileServer.Index != "" {
+		index := filepath.Join(full, fileServer.Index)
+		if indexInfo, err := os.Stat(index); err == nil && !indexInfo.IsDir() {
+			return serveFile(req, index, indexInfo)
+		}
+	}
+
+	if !fileServer.Listing {
+		return core.Forbidden().Text("directory listing is disabled"), nil
+	}
+
+	return fileServer.listDir(req, full)
+}
+
+// Convierte la ruta de la URL en una ruta relativa al directorio raíz.
+// Rechaza cualquier ruta que pueda salir de la raíz o apuntar a archivos ocultos.
+func (fileServer *FileServer) relativePath(urlPath string) (string, *core.HttpResponse) {
+	prefix := strings.TrimSuffix(fileServer.Prefix, "/")
+	if !strings.HasPrefix(urlPath, prefix) {
+		return "", core.NotFound().Text("404 Not Found")
+	}
+	rest := strings.TrimPrefix(urlPath, prefix)
+
+	// La ruta ya viene decodificada: se rechazan bytes nulos, separadores de Windows
+	// y ":" (unidades y flujos alternativos de NTFS) que filepath podría interpretar.
+	if strings.ContainsAny(rest, "\x00\\") || strings.Contains(rest, ":") {
+		return "", core.BadRequest().Text("invalid path")
+	}
+
+	segments := strings.Split(rest, "/")
+	for _, segment := range segments {
+		if segment == ".." {
+			return "", core.BadRequest().Text("invalid path")
+		}
+		if strings.HasPrefix(segment, ".") && segment != "." && !fileServer.ShowHidden {
+			return "", core.NotFound().Text("404 Not Found")
+		}
+	}
+
+	return path.Clean("/" + rest), nil
+}
+
+// Construye la ruta en disco y comprueba, tras resolver enlaces simbólicos,
+// que sigue dentro del directorio raíz.
+func (fileServer *FileServer) resolve(name string) (string, *core.HttpResponse) {
+	root, err := filepath.Abs(fileServer.Root)
+	if err != nil {
+		return "", core.NewHttpResponse(500, "Internal Server Error", "bad root")
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", core.NotFound().Text("404 Not Found")
+	}
+
+	full := filepath.Join(root, filepath.FromSlash(name))
+
+	resolved, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		return "", core.NotFound().Text("404 Not Found")
+	}
+
+	if !isWithin(root, resolved) {
+		return "", core.Forbidden().Text("path escapes root")
+	}
+
+	return resolved, nil
+}
+
+// Indica si path está dentro de root (o es root).
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel))
+}
+
+// Sirve un archivo regular en streaming, con soporte de rangos.
+func serveFile(req *core.HttpRequest, full string, info fs.FileInfo) (*core.HttpResponse, error) {
+	file, err := os.Open(full)
+	if err != nil {
+		return core.NotFound().Text("404 Not Found"), nil
+	}
+
+	contentType, err := ContentTypeOf(full, file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return core.ServeContent(req, contentType, info.ModTime(), file, info.Size()), nil
+}
+
+// ContentTypeOf determina el tipo de contenido por la extensión del archivo.
+// Si la extensión es desconocida, examina los primeros bytes para distinguir
+// texto de datos binarios y deja el lector en su posición inicial.
+func ContentTypeOf(name string, content io.ReadSeeker) (string, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if contentType, ok := extraMimeTypes[ext]; ok {
+		return contentType, nil
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType, nil
+	}
+
+	buffer := make([]byte, 512)
+	n, err := io.ReadFull(content, buffer)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return sniffContentType(buffer[:n]), nil
+}
+
+// Distingue texto UTF-8 de datos binarios a partir de una muestra.
+func sniffContentType(sample []byte) string {
+	// Un corte a mitad de una runa multibyte al final de la muestra no la invalida.
+	for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+
+	if len(sample) > 0 && !utf8.Valid(sample) {
+		return "application/octet-stream"
+	}
+
+	for _, b := range sample {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' {
+			return "application/octet-stream"
+		}
+	}
+
+	return "text/plain; charset=utf-8"
+}
+
+// Genera el listado de un directorio en HTML o, si el cliente lo pide, en JSON.
+func (fileServer *FileServer) listDir(req *core.HttpRequest, full string) (*core.HttpResponse, error) {
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]dirEntry, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") && !fileServer.ShowHidden {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, dirEntry{
+			Name:     entry.Name(),
+			Dir:      entry.IsDir(),
+			Size:     info.Size(),
+			Modified: info.ModTime().UTC().Format(time.RFC3339),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	if req.Target.Query().Get("format") == "json" || strings.Contains(req.Header("Accept"), "application/json") {
+		return core.Ok().JsonObj(struct {
+			Path    string     `json:"path"`
+			Entries []dirEntry `json:"entries"`
+		}{req.Target.Path, list}), nil
+	}
+
+	var builder strings.Builder
+	title := html.EscapeString(req.Target.Path)
+	fmt.Fprintf(&builder, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title></head><body>\n", title)
+	fmt.Fprintf(&builder, "<h1>%s</h1>\n<ul>\n", title)
+	for _, entry := range list {
+		name := entry.Name
+		if entry.Dir {
+			name += "/"
+		}
+		href := (&url.URL{Path: name}).String()
+		fmt.Fprintf(&builder, "<li><a href=\"./%s\">%s</a></li>\n", html.EscapeString(href), html.EscapeString(name))
+	}
+	builder.WriteString("</ul>\n</body></html>\n")
+
+	return core.Ok().SetContentType("text/html; charset=utf-8").SetBody(builder.String()), nil
+}
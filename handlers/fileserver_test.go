@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// newStaticRoot crea un directorio temporal con algunos archivos de prueba.
+func newStaticRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "docs"), 0o755)
+	os.MkdirAll(filepath.Join(root, "site"), 0o755)
+	os.WriteFile(filepath.Join(root, "hello.txt"), []byte("hola"), 0o644)
+	os.WriteFile(filepath.Join(root, "style.css"), []byte("body{}"), 0o644)
+	os.WriteFile(filepath.Join(root, "blob"), []byte{0x00, 0x01, 0x02}, 0o644)
+	os.WriteFile(filepath.Join(root, ".secret"), []byte("s"), 0o644)
+	os.WriteFile(filepath.Join(root, "docs", "a.json"), []byte("{}"), 0o644)
+	os.WriteFile(filepath.Join(root, "site", "index.html"), []byte("<h1>index</h1>"), 0o644)
+	return root
+}
+
+// readBody lee el cuerpo de la respuesta, sea en memoria o en streaming.
+func readBody(t *testing.T, res *core.HttpResponse) string {
+	t.Helper()
+	if !res.IsStreamed() {
+		return string(res.BodyBytes())
+	}
+	defer res.BodyReader.(io.Closer).Close()
+	data, err := io.ReadAll(io.LimitReader(res.BodyReader, res.ContentLength))
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(data)
+}
+
+func TestFileServerServesFiles(t *testing.T) {
+	fs := NewFileServer(newStaticRoot(t))
+	fs.Prefix = "/static"
+
+	cases := []struct {
+		path, contentType, body string
+	}{
+		{"/static/hello.txt", "text/plain; charset=utf-8", "hola"},
+		{"/static/style.css", "text/css; charset=utf-8", "body{}"},
+		{"/static/blob", "application/octet-stream", "\x00\x01\x02"},
+		{"/static/docs/a.json", "application/json", "{}"},
+		{"/static/site/", "text/html; charset=utf-8", "<h1>index</h1>"},
+	}
+	for _, c := range cases {
+		res, err := fs.Handle(makeReq("GET", c.path))
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", c.path, err)
+		}
+		if res.StatusCode != 200 {
+			t.Fatalf("%s: want 200, got %d", c.path, res.StatusCode)
+		}
+		if ct := res.Headers["Content-Type"]; ct != c.contentType {
+			t.Errorf("%s: Content-Type got %q, want %q", c.path, ct, c.contentType)
+		}
+		if body := readBody(t, res); body != c.body {
+			t.Errorf("%s: body got %q, want %q", c.path, body, c.body)
+		}
+	}
+}
+
+func TestFileServerRejectsTraversal(t *testing.T) {
+	root := newStaticRoot(t)
+	outside := filepath.Join(filepath.Dir(root), "outside.txt")
+	os.WriteFile(outside, []byte("x"), 0o644)
+	defer os.Remove(outside)
+	os.Symlink(outside, filepath.Join(root, "link.txt"))
+
+	fs := NewFileServer(root)
+	fs.Prefix = "/static"
+
+	cases := []struct {
+		path   string
+		status int
+	}{
+		{"/static/../outside.txt", 400},
+		{"/static/docs/../../outside.txt", 400},
+		{"/static/..\\outside.txt", 400},
+		{"/static/C:/Windows", 400},
+		{"/static/hello.txt\x00.png", 400},
+		{"/static/.secret", 404},
+		{"/static/link.txt", 403},
+		{"/static/missing.txt", 404},
+	}
+	for _, c := range cases {
+		// La ruta se asigna ya decodificada, como la entrega el parser
+		req := makeReq("GET", "/")
+		req.Target.Path = c.path
+		res, _ := fs.Handle(req)
+		if res.StatusCode != c.status {
+			t.Errorf("%q: want %d, got %d", c.path, c.status, res.StatusCode)
+		}
+	}
+}
+
+func TestFileServerDirectories(t *testing.T) {
+	fs := NewFileServer(newStaticRoot(t))
+	fs.Prefix = "/static"
+
+	// Sin "/" final redirige
+	res, _ := fs.Handle(makeReq("GET", "/static/docs"))
+	if res.StatusCode != 301 || res.Headers["Location"] != "/static/docs/" {
+		t.Errorf("want 301 to /static/docs/, got %d %q", res.StatusCode, res.Headers["Location"])
+	}
+
+	// Listado desactivado por defecto
+	res, _ = fs.Handle(makeReq("GET", "/static/docs/"))
+	if res.StatusCode != 403 {
+		t.Errorf("want 403 without listing, got %d", res.StatusCode)
+	}
+
+	fs.Listing = true
+
+	// Listado HTML
+	res, _ = fs.Handle(makeReq("GET", "/static/"))
+	if res.StatusCode != 200 || !strings.Contains(res.Body, `<a href="./docs/">docs/</a>`) {
+		t.Errorf("unexpected HTML listing: %d %q", res.StatusCode, res.Body)
+	}
+	if strings.Contains(res.Body, ".secret") {
+		t.Errorf("listing must hide dotfiles: %q", res.Body)
+	}
+
+	// Listado JSON
+	res, _ = fs.Handle(makeReq("GET", "/static/docs/?format=json"))
+	var listing struct {
+		Path    string `json:"path"`
+		Entries []struct {
+			Name string `json:"name"`
+			Dir  bool   `json:"dir"`
+			Size int64  `json:"size"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(res.Body), &listing); err != nil {
+		t.Fatalf("invalid JSON listing: %v", err)
+	}
+	if listing.Path != "/static/docs/" || len(listing.Entries) != 1 || listing.Entries[0].Name != "a.json" || listing.Entries[0].Size != 2 {
+		t.Errorf("unexpected JSON listing: %+v", listing)
+	}
+}
+
+func TestFileServerRange(t *testing.T) {
+	fs := NewFileServer(newStaticRoot(t))
+	fs.Prefix = "/static"
+
+	req := makeReq("GET", "/static/hello.txt")
+	req.Headers["Range"] = "bytes=1-2"
+	res, _ := fs.Handle(req)
+	if res.StatusCode != 206 || res.Headers["Content-Range"] != "bytes 1-2/4" {
+		t.Fatalf("want 206 bytes 1-2/4, got %d %q", res.StatusCode, res.Headers["Content-Range"])
+	}
+	if body := readBody(t, res); body != "ol" {
+		t.Errorf("want body %q, got %q", "ol", body)
+	}
+}
@@ -1,5 +1,6 @@
 // integration/constants.go
 package integration
 
-// addr es la dirección en la que arranca el servidor para los tests de integración.
-const addr = "localhost:8080"
+// addr es la dirección del servidor de los tests de integración; TestMain
+// lo arranca en un puerto efímero.
+var addr string
@@ -0,0 +1,26 @@
+// integration/main_test.go
+package integration
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/KateGF/Http-Server-Project-SO/app"
+	"github.com/KateGF/Http-Server-Project-SO/coretest"
+)
+
+// Atiende el mismo servidor que main.go, con todas sus rutas.
+func TestMain(m *testing.M) {
+	server, err := coretest.Start(app.NewServer())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "can't start server:", err)
+		os.Exit(1)
+	}
+	<-server.Ready()
+	addr = server.Addr
+
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
@@ -3,53 +3,36 @@ package main
 
 import (
 	"fmt"
-	"io"
-	"net"
 	"os"
 	"strings"
 	"testing"
-	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/app"
+	"github.com/KateGF/Http-Server-Project-SO/coretest"
 )
 
-// rawReq abre un socket, envía un request HTTP/1.0 válido (con Content-Length: 0 si aplica),
+// rawReq envía un request HTTP/1.0 válido (con Content-Length: 0 si aplica)
 // y devuelve únicamente el body de la respuesta.
-func rawReq(t *testing.T, method, path string) string {
+func rawReq(t *testing.T, client *coretest.Client, method, path string) string {
 	t.Helper()
-	conn, err := net.Dial("tcp", "localhost:8080")
-	if err != nil {
-		t.Fatalf("dial error: %v", err)
-	}
-	defer conn.Close()
 
 	// Monta la petición
+	request := fmt.Sprintf("%s %s HTTP/1.0\r\n\r\n", method, path)
 	if method == "POST" || method == "DELETE" {
-		fmt.Fprintf(conn, "%s %s HTTP/1.0\r\nContent-Length: 0\r\n\r\n", method, path)
-	} else {
-		fmt.Fprintf(conn, "%s %s HTTP/1.0\r\n\r\n", method, path)
+		request = fmt.Sprintf("%s %s HTTP/1.0\r\nContent-Length: 0\r\n\r\n", method, path)
 	}
 
-	// Lee toda la respuesta
-	respData, err := io.ReadAll(conn)
-	if err != nil {
-		t.Fatalf("read error: %v", err)
-	}
-
-	// Separa headers y body
-	parts := strings.SplitN(string(respData), "\r\n\r\n", 2)
-	if len(parts) < 2 {
-		t.Fatalf("respuesta inválida:\n%s", respData)
-	}
-	return parts[1]
+	return client.Raw(t, request).Body
 }
 
-func startServer(t *testing.T) {
+// Arranca el servidor de main.go en un puerto efímero y devuelve un cliente para él.
+func startServer(t *testing.T) *coretest.Client {
 	t.Helper()
-	go main() // arranca tu main.go
-	time.Sleep(100 * time.Millisecond)
+	return coretest.NewServer(t, app.NewServer()).Client()
 }
 
 func TestIntegrationEndpoints(t *testing.T) {
-	startServer(t)
+	client := startServer(t)
 
 	// 1) Endpoints de consulta
 	checks := []struct {
@@ -61,7 +44,7 @@ func TestIntegrationEndpoints(t *testing.T) {
 		{"GET", "/hash?text=abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
 	}
 	for _, c := range checks {
-		body := rawReq(t, c.method, c.path)
+		body := rawReq(t, client, c.method, c.path)
 		if body != c.want {
 			t.Errorf("%s %s: got %q, want %q", c.method, c.path, body, c.want)
 		}
@@ -73,7 +56,7 @@ func TestIntegrationEndpoints(t *testing.T) {
 
 	// createfile
 	createPath := fmt.Sprintf("/createfile?name=%s&content=X&repeat=4", fname)
-	body := rawReq(t, "POST", createPath)
+	body := rawReq(t, client, "POST", createPath)
 	if !strings.Contains(body, "File created successfully") {
 		t.Errorf("CreateFile: unexpected body %q", body)
 	}
@@ -84,7 +67,7 @@ func TestIntegrationEndpoints(t *testing.T) {
 
 	// deletefile
 	deletePath := fmt.Sprintf("/deletefile?name=%s", fname)
-	body = rawReq(t, "DELETE", deletePath)
+	body = rawReq(t, client, "DELETE", deletePath)
 	if !strings.Contains(body, "File deleted successfully") {
 		t.Errorf("DeleteFile: unexpected body %q", body)
 	}
@@ -92,3 +75,16 @@ func TestIntegrationEndpoints(t *testing.T) {
 		t.Errorf("DeleteFile: file still exists")
 	}
 }
+
+func TestIntegrationRateLimitPerRoute(t *testing.T) {
+	client := startServer(t)
+
+	// Agota el límite de /simulate
+	for i := 0; i < 10; i++ {
+		client.Get(t, "/simulate?seconds=0&task=x").AssertStatus(t, 200)
+	}
+	client.Get(t, "/simulate?seconds=0&task=x").AssertStatus(t, 429)
+
+	// /loadtest tiene su propio límite
+	client.Get(t, "/loadtest?tasks=1&sleep=0").AssertStatus(t, 200)
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Error devuelto por un Authenticator cuando las credenciales de su esquema son incorrectas.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Authenticator valida las credenciales de un esquema de la cabecera Authorization.
+type Authenticator interface {
+	// Scheme devuelve el esquema de Authorization que atiende ("Basic", "Bearer").
+	Scheme() string
+	// Authenticate valida las credenciales (lo que sigue al esquema) y devuelve
+	// el principal, o ErrInvalidCredentials si no son válidas.
+	Authenticate(credentials string) (*core.Principal, error)
+}
+
+// Auth combina varios Authenticator en un middleware.
+// Si ninguno acepta las credenciales responde 401 con WWW-Authenticate.
+//
+//	auth := middleware.NewAuth("files", basic, tokens, jwt)
+//	server.Delete("/deletefile", service.DeleteFileHandler).Use(auth.Middleware())
+type Auth struct {
+	Realm          string          // Dominio de protección anunciado en WWW-Authenticate
+	Authenticators []Authenticator // Mecanismos aceptados, en orden
+	Optional       bool            // Si es true, las solicitudes sin Authorization continúan como anónimas
+}
+
+// Crea un Auth obligatorio con los mecanismos dados.
+func NewAuth(realm string, authenticators ...Authenticator) *Auth {
+	return &Auth{
+		Realm:          realm,
+		Authenticators: authenticators,
+	}
+}
+
+// Extrae el esquema y las credenciales de la cabecera Authorization.
+func parseAuthorization(header string) (string, string) {
+	scheme, credentials, _ := strings.Cut(strings.TrimSpace(header), " ")
+	return scheme, strings.TrimSpace(credentials)
+}
+
+// Autentica la solicitud. Devuelve nil sin error si no hay credenciales.
+func (auth *Auth) Authenticate(request *core.HttpRequest) (*core.Principal, error) {
+	header := request.Header("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+
+	scheme, credentials := parseAuthorization(header)
+	for _, authenticator := range auth.Authenticators {
+		if !strings.EqualFold(authenticator.Scheme(), scheme) {
+			continue
+		}
+
+		principal, err := authenticator.Authenticate(credentials)
+		if errors.Is(err, ErrInvalidCredentials) {
+			// Otro mecanismo del mismo esquema puede aceptarlas (ej. token estático o JWT)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return principal, nil
+	}
+
+	return nil, ErrInvalidCredentials
+}
+
+// Construye la respuesta 401 con un desafío por cada esquema configurado.
+func (auth *Auth) challenge(invalid bool) *core.HttpResponse {
+	challenges := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, authenticator := range auth.Authenticators {
+		scheme := authenticator.Scheme()
+		if seen[scheme] {
+			continue
+		}
+		seen[scheme] = true
+
+		challenge := fmt.Sprintf("%s realm=%q", scheme, auth.Realm)
+		if invalid && scheme == "Bearer" {
+			challenge += `, error="invalid_token"`
+		}
+		if scheme == "Basic" {
+			challenge += `, charset="UTF-8"`
+		}
+		challenges = append(challenges, challenge)
+	}
+
+	return core.Unauthorized().
+		SetHeader("WWW-Authenticate", strings.Join(challenges, ", ")).
+		Text("authentication required")
+}
+
+// Middleware devuelve el middleware que autentica la solicitud y asigna
+// HttpRequest.Principal.
+func (auth *Auth) Middleware() core.Middleware {
+	return func(next core.Handle) core.Handle {
+		return func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			principal, err := auth.Authenticate(request)
+			if errors.Is(err, ErrInvalidCredentials) {
+				slog.Warn("Authentication failed", "path", request.Target.Path, "method", request.Method)
+				return auth.challenge(true), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if principal == nil && !auth.Optional {
+				return auth.challenge(false), nil
+			}
+
+			request.Principal = principal
+			return next(request)
+		}
+	}
+}
+
+// BearerTokens autentica tokens estáticos enviados como "Authorization: Bearer <token>".
+type BearerTokens struct {
+	Tokens map[string]string // Token → nombre del principal
+}
+
+// Crea un BearerTokens a partir de pares token → nombre.
+// Los tokens vacíos se descartan: aceptarían "Authorization: Bearer " sin token.
+func NewBearerTokens(tokens map[string]string) *BearerTokens {
+	valid := make(map[string]string, len(tokens))
+	for token, name := range tokens {
+		if token != "" {
+			valid[token] = name
+		}
+	}
+	return &BearerTokens{Tokens: valid}
+}
+
+func (bearer *BearerTokens) Scheme() string {
+	return "Bearer"
+}
+
+func (bearer *BearerTokens) Authenticate(credentials string) (*core.Principal, error) {
+	if credentials == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Recorre todos los tokens con comparación en tiempo constante
+	var name string
+	found := false
+	for token, principal := range bearer.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(credentials)) == 1 {
+			name, found = principal, true
+		}
+	}
+
+	if !found {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &core.Principal{Name: name, Scheme: "bearer"}, nil
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// whoami responde con el nombre del principal autenticado.
+func whoami(req *core.HttpRequest) (*core.HttpResponse, error) {
+	if req.Principal == nil {
+		return core.Ok().Text("anonymous"), nil
+	}
+	return core.Ok().Text(req.Principal.Name), nil
+}
+
+func basicHeader(user, password string) map[string]string {
+	return map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))}
+}
+
+func TestBasicAuth(t *testing.T) {
+	basic := NewBasicAuth()
+	basic.SetPassword("admin", "s3cret")
+	handle := NewAuth("files", basic).Middleware()(whoami)
+
+	cases := []struct {
+		headers map[string]string
+		code    int
+	}{
+		{basicHeader("admin", "s3cret"), 200},
+		{basicHeader("admin", "wrong"), 401},
+		{basicHeader("nobody", "s3cret"), 401},
+		{map[string]string{"Authorization": "Basic !!!"}, 401},
+		{map[string]string{}, 401},
+	}
+	for i, c := range cases {
+		res, err := handle(makeReq("DELETE", "/deletefile", c.headers))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error %v", i, err)
+		}
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: Expected %d, not %d", i, c.code, res.StatusCode)
+		}
+		if c.code == 401 && !strings.HasPrefix(res.Headers["WWW-Authenticate"], `Basic realm="files"`) {
+			t.Errorf("case %d: Expected Basic challenge, not %q", i, res.Headers["WWW-Authenticate"])
+		}
+	}
+
+	res, _ := handle(makeReq("DELETE", "/deletefile", basicHeader("admin", "s3cret")))
+	if res.Body != "admin" {
+		t.Errorf("Expected principal admin, not %q", res.Body)
+	}
+}
+
+func TestLoadHtpasswd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	content := "# usuarios\n\nadmin:" + HashPassword("s3cret") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	basic, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("Expected no error, not %v", err)
+	}
+	if !basic.Verify("admin", "s3cret") || basic.Verify("admin", "other") {
+		t.Errorf("Expected only the right password to verify")
+	}
+
+	os.WriteFile(path, []byte("admin-without-hash\n"), 0600)
+	if _, err := LoadHtpasswd(path); err == nil {
+		t.Errorf("Expected error for malformed entry")
+	}
+}
+
+func TestBearerAndOptionalAuth(t *testing.T) {
+	auth := NewAuth("api", NewBearerTokens(map[string]string{"tok-123": "ci"}))
+	handle := auth.Middleware()(whoami)
+
+	res, _ := handle(makeReq("GET", "/", map[string]string{"Authorization": "Bearer tok-123"}))
+	if res.StatusCode != 200 || res.Body != "ci" {
+		t.Errorf("Expected 200 ci, not %d %q", res.StatusCode, res.Body)
+	}
+
+	res, _ = handle(makeReq("GET", "/", map[string]string{"Authorization": "Bearer nope"}))
+	if res.StatusCode != 401 || res.Headers["WWW-Authenticate"] != `Bearer realm="api", error="invalid_token"` {
+		t.Errorf("Expected 401 invalid_token, not %d %q", res.StatusCode, res.Headers["WWW-Authenticate"])
+	}
+
+	// Opcional: sin credenciales continúa como anónimo, con credenciales inválidas no
+	auth.Optional = true
+	res, _ = handle(makeReq("GET", "/", map[string]string{}))
+	if res.StatusCode != 200 || res.Body != "anonymous" {
+		t.Errorf("Expected anonymous access, not %d %q", res.StatusCode, res.Body)
+	}
+	res, _ = handle(makeReq("GET", "/", map[string]string{"Authorization": "Bearer nope"}))
+	if res.StatusCode != 401 {
+		t.Errorf("Expected 401 for invalid token, not %d", res.StatusCode)
+	}
+}
+
+func TestBearerEmptyToken(t *testing.T) {
+	// Un token vacío (p. ej. de AUTH_TOKENS="tok-123=ci,") no debe aceptar "Bearer " sin token
+	auth := NewAuth("api", NewBearerTokens(map[string]string{"tok-123": "ci", "": "token"}))
+	handle := auth.Middleware()(whoami)
+
+	for _, header := range []string{"Bearer ", "Bearer"} {
+		res, _ := handle(makeReq("GET", "/", map[string]string{"Authorization": header}))
+		if res.StatusCode != 401 {
+			t.Errorf("Expected 401 for %q, not %d %q", header, res.StatusCode, res.Body)
+		}
+	}
+
+	// También si el mapa se asigna directamente
+	bearer := &BearerTokens{Tokens: map[string]string{"": "token"}}
+	if principal, err := bearer.Authenticate(""); err == nil {
+		t.Errorf("Expected error for empty credentials, not %v", principal)
+	}
+}
+
+func TestJWTAuth(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	jwt := NewJWTAuth([]byte("secret"))
+	jwt.Audience = "files"
+	jwt.now = func() time.Time { return now }
+
+	sign := func(claims map[string]any) string {
+		token, err := jwt.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := sign(map[string]any{"sub": "kate", "aud": []string{"files", "other"}, "exp": now.Add(time.Hour).Unix(), "scope": "files:read files:write", "roles": []string{"admin"}})
+
+	principal, err := jwt.Authenticate(valid)
+	if err != nil {
+		t.Fatalf("Expected valid token, not %v", err)
+	}
+	if principal.Name != "kate" || !principal.HasScope("files:write") || !principal.HasRole("admin") {
+		t.Errorf("Unexpected principal %+v", principal)
+	}
+
+	other := NewJWTAuth([]byte("other"))
+	forged, _ := other.Sign(map[string]any{"sub": "kate", "aud": "files", "exp": now.Add(time.Hour).Unix()})
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + strings.Split(valid, ".")[1] + "."
+
+	invalid := []string{
+		sign(map[string]any{"sub": "kate", "aud": "files", "exp": now.Add(-time.Hour).Unix()}),
+		sign(map[string]any{"sub": "kate", "aud": "files", "exp": now.Add(time.Hour).Unix(), "nbf": now.Add(time.Hour).Unix()}),
+		sign(map[string]any{"sub": "kate", "aud": "elsewhere", "exp": now.Add(time.Hour).Unix()}),
+		sign(map[string]any{"sub": "kate", "aud": "files"}),
+		forged,
+		none,
+		"not-a-token",
+	}
+	for i, token := range invalid {
+		if _, err := jwt.Authenticate(token); err != ErrInvalidCredentials {
+			t.Errorf("case %d: Expected ErrInvalidCredentials, not %v", i, err)
+		}
+	}
+
+	// Dentro de la tolerancia de reloj
+	expired := sign(map[string]any{"sub": "kate", "aud": "files", "exp": now.Add(-10 * time.Second).Unix()})
+	if _, err := jwt.Authenticate(expired); err != nil {
+		t.Errorf("Expected token within leeway to be valid, not %v", err)
+	}
+}
+
+func TestAuthThroughServer(t *testing.T) {
+	server := core.NewHttpServer()
+	tokens := NewBearerTokens(map[string]string{"tok-123": "ci"})
+	server.Delete("/deletefile", whoami).Use(NewAuth("files", tokens).Middleware())
+
+	code, headers := roundTrip(t, server, "DELETE /deletefile HTTP/1.0\r\n\r\n")
+	if code != 401 || headers["WWW-Authenticate"] != `Bearer realm="files"` {
+		t.Errorf("Expected 401 with challenge, not %d %v", code, headers)
+	}
+
+	code, _ = roundTrip(t, server, "DELETE /deletefile HTTP/1.0\r\nAuthorization: Bearer tok-123\r\n\r\n")
+	if code != 200 {
+		t.Errorf("Expected 200, not %d", code)
+	}
+}
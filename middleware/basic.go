@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// BasicAuth autentica "Authorization: Basic" contra usuarios con contraseñas
+// guardadas como SHA-256 con sal, en un archivo estilo htpasswd:
+//
+//	# usuario:sha256$<sal en hex>$<hex de sha256(sal + contraseña)>
+//	admin:sha256$8f1c...$5e88...
+type BasicAuth struct {
+	Users map[string]string // Usuario → hash en formato "sha256$sal$hash"
+}
+
+// Crea un BasicAuth vacío.
+func NewBasicAuth() *BasicAuth {
+	return &BasicAuth{Users: make(map[string]string)}
+}
+
+// Carga un archivo htpasswd. Ignora líneas vacías y comentarios (#).
+func LoadHtpasswd(path string) (*BasicAuth, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	basic := NewBasicAuth()
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" || len(strings.Split(hash, "$")) != 3 {
+			return nil, fmt.Errorf("%s:%d: malformed htpasswd entry", path, number)
+		}
+		basic.Users[user] = hash
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return basic, nil
+}
+
+// Genera el hash "sha256$sal$hash" de una contraseña con una sal aleatoria.
+func HashPassword(password string) string {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	return hashWithSalt(hex.EncodeToString(salt), password)
+}
+
+// Calcula el hash de la contraseña con la sal dada (en hex).
+func hashWithSalt(salt, password string) string {
+	sum := sha256.Sum256([]byte(salt + password))
+	return "sha256$" + salt + "$" + hex.EncodeToString(sum[:])
+}
+
+// Añade o reemplaza un usuario con la contraseña dada.
+func (basic *BasicAuth) SetPassword(user, password string) {
+	basic.Users[user] = HashPassword(password)
+}
+
+// Comprueba la contraseña de un usuario en tiempo constante.
+func (basic *BasicAuth) Verify(user, password string) bool {
+	stored, ok := basic.Users[user]
+	if !ok {
+		// Calcula un hash igualmente para no revelar qué usuarios existen
+		hashWithSalt("", password)
+		return false
+	}
+
+	parts := strings.Split(stored, "$")
+	if len(parts) != 3 || parts[0] != "sha256" {
+		return false
+	}
+
+	computed := hashWithSalt(parts[1], password)
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(stored)) == 1
+}
+
+func (basic *BasicAuth) Scheme() string {
+	return "Basic"
+}
+
+func (basic *BasicAuth) Authenticate(credentials string) (*core.Principal, error) {
+	decoded, err := base64.StdEncoding.DecodeString(credentials)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, password, ok := strings.Cut(string(decoded), ":")
+	if !ok || !basic.Verify(user, password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &core.Principal{Name: user, Scheme: "basic"}, nil
+}
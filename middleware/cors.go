@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// CORS configura el intercambio de recursos entre orígenes.
+// Se instala como middleware global para que responda también a las
+// solicitudes de verificación previa (preflight) de todas las rutas:
+//
+//	cors := middleware.NewCORS("https://dashboard.example.com")
+//	server.Use(cors.Middleware())
+type CORS struct {
+	AllowedOrigins   []string         // Orígenes exactos, "*" o con comodín ("https://*.example.com")
+	OriginPatterns   []*regexp.Regexp // Expresiones regulares de orígenes permitidos
+	AllowedMethods   []string         // Métodos permitidos en solicitudes entre orígenes
+	AllowedHeaders   []string         // Cabeceras que el cliente puede enviar ("*" admite cualquiera)
+	ExposedHeaders   []string         // Cabeceras de la respuesta visibles para el navegador
+	AllowCredentials bool             // Permite cookies y cabeceras de autenticación
+	MaxAge           time.Duration    // Tiempo que el navegador puede cachear la verificación previa
+}
+
+// Crea una configuración CORS para los orígenes dados con los métodos
+// GET, HEAD y POST y sin cabeceras adicionales.
+func NewCORS(origins ...string) *CORS {
+	return &CORS{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"GET", "HEAD", "POST"},
+	}
+}
+
+// Indica si el origen está permitido.
+func (cors *CORS) originAllowed(origin string) bool {
+	for _, allowed := range cors.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok {
+			// El comodín debe cubrir al menos un carácter
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+				strings.HasSuffix(strings.ToLower(origin), strings.ToLower(suffix)) {
+				return true
+			}
+		}
+	}
+
+	for _, pattern := range cors.OriginPatterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Indica si el método está permitido.
+func (cors *CORS) methodAllowed(method string) bool {
+	for _, allowed := range cors.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Indica si todas las cabeceras pedidas están permitidas.
+func (cors *CORS) headersAllowed(requested []string) bool {
+	for _, header := range requested {
+		found := false
+		for _, allowed := range cors.AllowedHeaders {
+			if allowed == "*" || strings.EqualFold(allowed, header) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Devuelve el valor de Access-Control-Allow-Origin para el origen.
+// Con credenciales nunca se usa "*", sino el origen concreto.
+func (cors *CORS) allowOrigin(origin string) string {
+	if !cors.AllowCredentials {
+		for _, allowed := range cors.AllowedOrigins {
+			if allowed == "*" {
+				return "*"
+			}
+		}
+	}
+	return origin
+}
+
+// Separa una lista de cabeceras separadas por comas.
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Middleware devuelve el middleware que aplica la configuración CORS.
+func (cors *CORS) Middleware() core.Middleware {
+	return func(next core.Handle) core.Handle {
+		return func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			origin := request.Header("Origin")
+			if origin == "" {
+				return next(request)
+			}
+
+			requestedMethod := request.Header("Access-Control-Request-Method")
+			if request.Method == "OPTIONS" && requestedMethod != "" {
+				return cors.preflight(next, request, origin, requestedMethod)
+			}
+
+			response, err := next(request)
+			if err != nil || response == nil {
+				return response, err
+			}
+
+			response.AddVary("Origin")
+			if !cors.originAllowed(origin) {
+				return response, nil
+			}
+
+			response.SetHeader("Access-Control-Allow-Origin", cors.allowOrigin(origin))
+			if cors.AllowCredentials {
+				response.SetHeader("Access-Control-Allow-Credentials", "true")
+			}
+			if len(cors.ExposedHeaders) > 0 {
+				response.SetHeader("Access-Control-Expose-Headers", strings.Join(cors.ExposedHeaders, ", "))
+			}
+
+			return response, nil
+		}
+	}
+}
+
+// Responde a una solicitud de verificación previa. La ruta debe existir:
+// next responde a OPTIONS con 204 y Allow en las rutas registradas.
+func (cors *CORS) preflight(next core.Handle, request *core.HttpRequest, origin, requestedMethod string) (*core.HttpResponse, error) {
+	response, err := next(request)
+	if err != nil || response == nil {
+		return response, err
+	}
+
+	response.AddVary("Origin")
+	response.AddVary("Access-Control-Request-Method")
+	response.AddVary("Access-Control-Request-Headers")
+
+	// Ruta inexistente u OPTIONS rechazado: se devuelve tal cual, sin permisos CORS
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response, nil
+	}
+
+	requestedHeaders := splitList(request.Header("Access-Control-Request-Headers"))
+	if !cors.originAllowed(origin) || !cors.methodAllowed(requestedMethod) || !cors.headersAllowed(requestedHeaders) {
+		return response, nil
+	}
+
+	response.SetHeader("Access-Control-Allow-Origin", cors.allowOrigin(origin))
+	response.SetHeader("Access-Control-Allow-Methods", strings.Join(cors.AllowedMethods, ", "))
+	if len(requestedHeaders) > 0 {
+		response.SetHeader("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+	}
+	if cors.AllowCredentials {
+		response.SetHeader("Access-Control-Allow-Credentials", "true")
+	}
+	if cors.MaxAge > 0 {
+		response.SetHeader("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge.Seconds())))
+	}
+
+	return response, nil
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// makeReq construye un core.HttpRequest para pruebas.
+func makeReq(method, path string, headers map[string]string) *core.HttpRequest {
+	u, _ := url.Parse(path)
+	return core.NewHttpRequest(method, u, headers, "")
+}
+
+// roundTrip envía una solicitud cruda al servidor por una conexión en memoria
+// y devuelve el código de estado y las cabeceras de la respuesta.
+func roundTrip(t *testing.T, server *core.HttpServer, raw string) (int, map[string]string) {
+	t.Helper()
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+
+	go server.Handle(conn2)
+	go fmt.Fprint(conn1, raw)
+
+	reader := bufio.NewReader(conn1)
+	status, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read status: %v", err)
+	}
+	var code int
+	fmt.Sscanf(status, "HTTP/1.0 %d", &code)
+
+	headers := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil || line == "\r\n" {
+			break
+		}
+		key, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+		headers[key] = value
+	}
+	return code, headers
+}
+
+func TestCORSOriginMatching(t *testing.T) {
+	cors := NewCORS("https://app.example.test", "https://*.example.org")
+	cors.OriginPatterns = []*regexp.Regexp{regexp.MustCompile(`^http://localhost:\d+$`)}
+
+	cases := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.test", true},
+		{"https://APP.example.test", true},
+		{"https://other.example.test", false},
+		{"https://a.example.org", true},
+		{"https://.example.org", false},
+		{"https://example.org", false},
+		{"http://localhost:3000", true},
+		{"http://localhost", false},
+	}
+	for _, c := range cases {
+		if got := cors.originAllowed(c.origin); got != c.want {
+			t.Errorf("originAllowed(%q) = %v; want %v", c.origin, got, c.want)
+		}
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	cors := NewCORS("https://app.example.test")
+	cors.ExposedHeaders = []string{"X-Total"}
+	handle := cors.Middleware()(func(req *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok().Text("ok"), nil
+	})
+
+	// Origen permitido
+	res, _ := handle(makeReq("GET", "/status", map[string]string{"Origin": "https://app.example.test"}))
+	if res.Headers["Access-Control-Allow-Origin"] != "https://app.example.test" {
+		t.Errorf("want allow origin, got %v", res.Headers)
+	}
+	if res.Headers["Access-Control-Expose-Headers"] != "X-Total" || res.Headers["Vary"] != "Origin" {
+		t.Errorf("want expose headers and Vary, got %v", res.Headers)
+	}
+
+	// Origen no permitido: sin cabeceras CORS
+	res, _ = handle(makeReq("GET", "/status", map[string]string{"Origin": "https://evil.test"}))
+	if _, ok := res.Headers["Access-Control-Allow-Origin"]; ok {
+		t.Errorf("want no allow origin for disallowed origin, got %v", res.Headers)
+	}
+
+	// Sin Origin: no es una solicitud CORS
+	res, _ = handle(makeReq("GET", "/status", map[string]string{}))
+	if _, ok := res.Headers["Vary"]; ok {
+		t.Errorf("want untouched response without Origin, got %v", res.Headers)
+	}
+}
+
+func TestCORSWildcardWithCredentials(t *testing.T) {
+	cors := NewCORS("*")
+	handle := cors.Middleware()(func(req *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok(), nil
+	})
+
+	res, _ := handle(makeReq("GET", "/", map[string]string{"Origin": "https://a.test"}))
+	if res.Headers["Access-Control-Allow-Origin"] != "*" {
+		t.Errorf("want *, got %q", res.Headers["Access-Control-Allow-Origin"])
+	}
+
+	cors.AllowCredentials = true
+	res, _ = handle(makeReq("GET", "/", map[string]string{"Origin": "https://a.test"}))
+	if res.Headers["Access-Control-Allow-Origin"] != "https://a.test" || res.Headers["Access-Control-Allow-Credentials"] != "true" {
+		t.Errorf("want reflected origin with credentials, got %v", res.Headers)
+	}
+}
+
+func TestCORSPreflightThroughServer(t *testing.T) {
+	server := core.NewHttpServer()
+	cors := NewCORS("https://app.example.test")
+	cors.AllowedMethods = []string{"GET", "DELETE"}
+	cors.AllowedHeaders = []string{"Authorization", "Content-Type"}
+	cors.MaxAge = 10 * time.Minute
+	server.Use(cors.Middleware())
+
+	server.Get("/status", func(req *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok(), nil
+	})
+	server.Delete("/deletefile", func(req *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok(), nil
+	})
+
+	preflight := func(path, method, headers string) string {
+		return fmt.Sprintf("OPTIONS %s HTTP/1.0\r\nOrigin: https://app.example.test\r\nAccess-Control-Request-Method: %s\r\nAccess-Control-Request-Headers: %s\r\n\r\n", path, method, headers)
+	}
+
+	// Ruta registrada, método y cabeceras permitidos
+	code, headers := roundTrip(t, server, preflight("/deletefile", "DELETE", "authorization"))
+	if code != 204 {
+		t.Fatalf("want 204, got %d", code)
+	}
+	if headers["Access-Control-Allow-Origin"] != "https://app.example.test" ||
+		headers["Access-Control-Allow-Methods"] != "GET, DELETE" ||
+		headers["Access-Control-Allow-Headers"] != "authorization" ||
+		headers["Access-Control-Max-Age"] != "600" ||
+		headers["Allow"] != "DELETE, OPTIONS" {
+		t.Errorf("unexpected preflight headers: %v", headers)
+	}
+
+	// Cabecera no permitida
+	_, headers = roundTrip(t, server, preflight("/status", "GET", "X-Secret"))
+	if _, ok := headers["Access-Control-Allow-Origin"]; ok {
+		t.Errorf("want no CORS approval for disallowed header, got %v", headers)
+	}
+
+	// Ruta inexistente
+	code, headers = roundTrip(t, server, preflight("/nope", "GET", ""))
+	if code != 404 {
+		t.Errorf("want 404 for unknown route, got %d", code)
+	}
+	if _, ok := headers["Access-Control-Allow-Origin"]; ok {
+		t.Errorf("want no CORS approval for unknown route, got %v", headers)
+	}
+
+	// Solicitud real desde el navegador
+	code, headers = roundTrip(t, server, "GET /status HTTP/1.0\r\nOrigin: https://app.example.test\r\n\r\n")
+	if code != 200 || headers["Access-Control-Allow-Origin"] != "https://app.example.test" {
+		t.Errorf("want 200 with CORS headers, got %d %v", code, headers)
+	}
+}
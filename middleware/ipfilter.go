@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Redes privadas y de loopback (RFC 1918, RFC 4193).
+var InternalNetworks = []string{"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7"}
+
+// IPFilter permite o rechaza solicitudes según la IP del cliente.
+// Deny tiene prioridad; si Allow no está vacía, la IP debe estar en ella.
+//
+//	internal, _ := middleware.NewIPFilter(middleware.InternalNetworks...)
+//	server.Delete("/deletefile", service.DeleteFileHandler).Use(internal.Middleware())
+//
+// La IP del cliente es la de request.ClientIP(): detrás de un proxy, se
+// configura con HttpServer.TrustedProxies.
+type IPFilter struct {
+	Allow []*net.IPNet // Redes permitidas (vacía permite todas)
+	Deny  []*net.IPNet // Redes rechazadas
+}
+
+// Crea un IPFilter que solo permite las redes dadas (CIDR o IP sueltas).
+func NewIPFilter(allow ...string) (*IPFilter, error) {
+	networks, err := ParseCIDRs(allow...)
+	if err != nil {
+		return nil, err
+	}
+	return &IPFilter{Allow: networks}, nil
+}
+
+// Convierte una lista de CIDR ("10.0.0.0/8") o IP ("192.168.1.5") en redes.
+func ParseCIDRs(cidrs ...string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip address %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q", cidr)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Indica si la IP pertenece a alguna de las redes.
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Devuelve la IP del cliente, ya resuelta por el servidor a partir de los
+// proxies de confianza (ver core.HttpRequest.ClientIP).
+func (filter *IPFilter) ClientIP(request *core.HttpRequest) net.IP {
+	return net.ParseIP(request.ClientIP())
+}
+
+// Indica si la IP puede acceder.
+func (filter *IPFilter) Allowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if containsIP(filter.Deny, ip) {
+		return false
+	}
+	return len(filter.Allow) == 0 || containsIP(filter.Allow, ip)
+}
+
+// Middleware devuelve el middleware que responde 403 a las IP no permitidas
+// y registra la decisión.
+func (filter *IPFilter) Middleware() core.Middleware {
+	return func(next core.Handle) core.Handle {
+		return func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			ip := filter.ClientIP(request)
+			if !filter.Allowed(ip) {
+				slog.Warn("IP access denied", "ip", ip.String(), "address", request.RemoteAddr, "method", request.Method, "path", request.Target.Path)
+				return core.Forbidden().Text("forbidden"), nil
+			}
+
+			slog.Debug("IP access allowed", "ip", ip.String(), "method", request.Method, "path", request.Target.Path)
+			return next(request)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"testing"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	networks, err := ParseCIDRs("10.0.0.0/8", " 192.168.1.5 ", "::1", "")
+	if err != nil {
+		t.Fatalf("Expected no error, not %v", err)
+	}
+	if len(networks) != 3 || networks[1].String() != "192.168.1.5/32" || networks[2].String() != "::1/128" {
+		t.Errorf("Unexpected networks %v", networks)
+	}
+
+	for _, bad := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if _, err := ParseCIDRs(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestIPFilterAllowDeny(t *testing.T) {
+	filter, _ := NewIPFilter(InternalNetworks...)
+	filter.Deny, _ = ParseCIDRs("10.0.0.66")
+	handle := filter.Middleware()(whoami)
+
+	cases := []struct {
+		addr string
+		code int
+	}{
+		{"127.0.0.1:5000", 200},
+		{"10.1.2.3:5000", 200},
+		{"[::1]:5000", 200},
+		{"10.0.0.66:5000", 403},
+		{"8.8.8.8:5000", 403},
+		{"pipe", 403},
+	}
+	for i, c := range cases {
+		req := makeReq("DELETE", "/deletefile", map[string]string{})
+		req.RemoteAddr = c.addr
+		res, _ := handle(req)
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: Expected %d, not %d", i, c.code, res.StatusCode)
+		}
+	}
+}
+
+func TestIPFilterForwardedFor(t *testing.T) {
+	filter, _ := NewIPFilter("192.168.0.0/16")
+
+	cases := []struct {
+		addr, realIP, forwarded, want string
+	}{
+		// X-Forwarded-For no se interpreta aquí: lo resuelve el servidor en RealIP
+		{"10.0.0.1:1", "", "192.168.1.1", "10.0.0.1"},
+		// IP indicada por un proxy de confianza
+		{"10.0.0.1:1", "192.168.1.1", "192.168.1.1", "192.168.1.1"},
+		{"8.8.8.8:1", "", "", "8.8.8.8"},
+	}
+	for i, c := range cases {
+		req := makeReq("GET", "/", map[string]string{"X-Forwarded-For": c.forwarded})
+		req.RemoteAddr = c.addr
+		req.RealIP = c.realIP
+		if got := filter.ClientIP(req).String(); got != c.want {
+			t.Errorf("case %d: Expected %s, not %s", i, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// JWTAuth valida tokens JWT firmados con HMAC-SHA256 (HS256) enviados como
+// "Authorization: Bearer <token>". Comprueba exp, nbf, aud e iss.
+type JWTAuth struct {
+	Secret   []byte           // Clave compartida de la firma
+	Audience string           // Audiencia exigida en "aud" (vacío para no comprobarla)
+	Issuer   string           // Emisor exigido en "iss" (vacío para no comprobarlo)
+	Leeway   time.Duration    // Tolerancia de reloj para exp y nbf
+	now      func() time.Time // Reloj, reemplazable en pruebas
+}
+
+// Crea un JWTAuth con la clave dada y una tolerancia de 30 segundos.
+func NewJWTAuth(secret []byte) *JWTAuth {
+	return &JWTAuth{
+		Secret: secret,
+		Leeway: 30 * time.Second,
+		now:    time.Now,
+	}
+}
+
+func (jwt *JWTAuth) Scheme() string {
+	return "Bearer"
+}
+
+// Firma las claims dadas como un token HS256.
+func (jwt *JWTAuth) Sign(claims map[string]any) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(jwt.sign(signingInput)), nil
+}
+
+// Calcula la firma HMAC-SHA256 de la entrada.
+func (jwt *JWTAuth) sign(input string) []byte {
+	mac := hmac.New(sha256.New, jwt.Secret)
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
+
+func (jwt *JWTAuth) Authenticate(credentials string) (*core.Principal, error) {
+	parts := strings.Split(credentials, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Solo se acepta HS256: rechaza "none" y algoritmos asimétricos
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidCredentials
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, jwt.sign(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidCredentials
+	}
+
+	claims := make(map[string]any)
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !jwt.validClaims(claims) {
+		return nil, ErrInvalidCredentials
+	}
+
+	principal := &core.Principal{Scheme: "jwt", Claims: claims}
+	principal.Name, _ = claims["sub"].(string)
+	principal.Roles = stringList(claims["roles"])
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = strings.Fields(scope)
+	} else {
+		principal.Scopes = stringList(claims["scp"])
+	}
+
+	return principal, nil
+}
+
+// Comprueba las claims temporales, la audiencia y el emisor.
+func (jwt *JWTAuth) validClaims(claims map[string]any) bool {
+	now := jwt.now()
+
+	// exp es obligatorio: un token sin caducidad no se acepta
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(jwt.Leeway)) {
+		return false
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwt.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return false
+	}
+
+	if jwt.Audience != "" {
+		found := false
+		for _, aud := range stringList(claims["aud"]) {
+			if aud == jwt.Audience {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if jwt.Issuer != "" && claims["iss"] != jwt.Issuer {
+		return false
+	}
+
+	return true
+}
+
+// Decodifica un segmento base64url de JSON.
+func decodeSegment(segment string, value any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+// Convierte una claim que puede ser una cadena o una lista de cadenas.
+func stringList(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+		return items
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Policy asigna roles a los principales autenticados. Se instala después del
+// middleware de autenticación para que las rutas con RequireRoles vean los roles.
+// Los principales se identifican por el esquema con el que se autenticaron
+// y su nombre ("basic:kate", "bearer:ci", "jwt:alice"), para que un usuario
+// Basic, un token y un JWT con el mismo nombre no compartan roles.
+// El archivo de políticas tiene una línea por principal:
+//
+//	# esquema:principal: roles separados por comas
+//	basic:kate: admin, ops
+//	bearer:ci: deployer
+type Policy struct {
+	Roles map[string][]string // "esquema:nombre" del principal → roles
+}
+
+// Crea una Policy vacía.
+func NewPolicy() *Policy {
+	return &Policy{Roles: make(map[string][]string)}
+}
+
+// Carga un archivo de políticas. Ignora líneas vacías y comentarios (#).
+func LoadPolicy(path string) (*Policy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	policy := NewPolicy()
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		scheme, rest, _ := strings.Cut(line, ":")
+		name, roles, ok := strings.Cut(rest, ":")
+		scheme, name = strings.TrimSpace(scheme), strings.TrimSpace(name)
+		if !ok || scheme == "" || name == "" {
+			return nil, fmt.Errorf("%s:%d: malformed policy entry, expected scheme:principal: roles", path, number)
+		}
+		policy.Grant(scheme+":"+name, splitList(roles)...)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Añade roles a un principal, identificado como "esquema:nombre" (p. ej. "jwt:alice").
+func (policy *Policy) Grant(principal string, roles ...string) {
+	policy.Roles[principal] = append(policy.Roles[principal], roles...)
+}
+
+// Middleware devuelve el middleware que añade al principal de la solicitud
+// los roles que le asigna la política, sin repetir los que ya tenga.
+func (policy *Policy) Middleware() core.Middleware {
+	return func(next core.Handle) core.Handle {
+		return func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			if principal := request.Principal; principal != nil {
+				for _, role := range policy.Roles[principal.Scheme+":"+principal.Name] {
+					if !principal.HasRole(role) {
+						principal.Roles = append(principal.Roles, role)
+					}
+				}
+			}
+			return next(request)
+		}
+	}
+}
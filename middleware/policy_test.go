@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy")
+	os.WriteFile(path, []byte("# roles\nbasic:kate: admin, ops\n\nbearer:ci: deployer\nbearer:ci: ops\n"), 0600)
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("Expected no error, not %v", err)
+	}
+	if len(policy.Roles["basic:kate"]) != 2 || len(policy.Roles["bearer:ci"]) != 2 {
+		t.Errorf("Unexpected roles %v", policy.Roles)
+	}
+
+	for _, entry := range []string{"no-colon\n", "kate: admin\n", ":kate: admin\n"} {
+		os.WriteFile(path, []byte(entry), 0600)
+		if _, err := LoadPolicy(path); err == nil {
+			t.Errorf("Expected error for malformed entry %q", entry)
+		}
+	}
+}
+
+func TestRolesThroughServer(t *testing.T) {
+	server := core.NewHttpServer()
+	tokens := NewBearerTokens(map[string]string{"tok-admin": "kate", "tok-user": "ci"})
+	basic := NewBasicAuth()
+	basic.Users["kate"] = HashPassword("secret")
+	policy := NewPolicy()
+	policy.Grant("bearer:kate", "admin")
+
+	auth := NewAuth("files", tokens, basic)
+	auth.Optional = true
+	server.Use(auth.Middleware(), policy.Middleware())
+
+	server.Delete("/deletefile", whoami).RequireRoles("admin")
+	server.Get("/reverse", whoami)
+
+	cases := []struct {
+		raw  string
+		code int
+	}{
+		{"DELETE /deletefile HTTP/1.0\r\nAuthorization: Bearer tok-admin\r\n\r\n", 200},
+		{"DELETE /deletefile HTTP/1.0\r\nAuthorization: Bearer tok-user\r\n\r\n", 403},
+		{"DELETE /deletefile HTTP/1.0\r\n\r\n", 401},
+		// Un usuario Basic con el mismo nombre no recibe los roles del token
+		{"DELETE /deletefile HTTP/1.0\r\nAuthorization: Basic a2F0ZTpzZWNyZXQ=\r\n\r\n", 403},
+		{"GET /reverse HTTP/1.0\r\n\r\n", 200},
+		{"GET /reverse HTTP/1.0\r\nAuthorization: Bearer tok-user\r\n\r\n", 200},
+	}
+	for i, c := range cases {
+		if code, _ := roundTrip(t, server, c.raw); code != c.code {
+			t.Errorf("case %d: Expected %d, not %d", i, c.code, code)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// KeyFunc devuelve la clave del bucket al que se cobra una solicitud.
+type KeyFunc func(request *core.HttpRequest) string
+
+// Agrupa las solicitudes por IP del cliente.
+func KeyByIP(request *core.HttpRequest) string {
+	return "ip:" + request.ClientIP()
+}
+
+// Agrupa las solicitudes por principal autenticado, o por IP si es anónima.
+func KeyByPrincipal(request *core.HttpRequest) string {
+	if request.Principal != nil {
+		return "principal:" + request.Principal.Name
+	}
+	return KeyByIP(request)
+}
+
+// Agrupa todas las solicitudes de una misma ruta, sin importar el cliente.
+func KeyByRoute(request *core.HttpRequest) string {
+	return "route:" + request.Method + " " + request.Target.Path
+}
+
+// Estado de un token bucket.
+type bucket struct {
+	tokens float64   // Tokens disponibles
+	last   time.Time // Última recarga
+}
+
+// RateLimit limita las solicitudes con token buckets: cada clave dispone de
+// Burst tokens que se recargan a Rate por segundo. Sin tokens responde
+// 429 con Retry-After. Se puede usar global o por ruta; las rutas que
+// comparten un RateLimit comparten también sus buckets:
+//
+//	heavy, _ := middleware.NewRateLimit(5, time.Minute)
+//	server.Get("/loadtest", advanced.LoadTestHandler).Use(heavy.Middleware())
+type RateLimit struct {
+	Rate  float64 // Tokens recargados por segundo
+	Burst int     // Capacidad del bucket (solicitudes seguidas permitidas)
+	Key   KeyFunc // Clave del bucket (KeyByIP por defecto)
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time // Reloj, reemplazable en pruebas
+}
+
+// Crea un límite de requests solicitudes por intervalo per, por IP del cliente.
+// Devuelve error si requests o per no son positivos.
+func NewRateLimit(requests int, per time.Duration) (*RateLimit, error) {
+	if requests <= 0 {
+		return nil, fmt.Errorf("invalid rate limit requests %d", requests)
+	}
+	if per <= 0 {
+		return nil, fmt.Errorf("invalid rate limit interval %s", per)
+	}
+
+	return &RateLimit{
+		Rate:    float64(requests) / per.Seconds(),
+		Burst:   requests,
+		Key:     KeyByIP,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}, nil
+}
+
+// Tiempo que tarda un bucket vacío en llenarse.
+func (limit *RateLimit) refillTime() time.Duration {
+	return time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second))
+}
+
+// Cobra un token a la clave. Devuelve si se permite, los tokens restantes y
+// cuánto falta para el próximo token (si se rechaza) o para llenar el bucket.
+func (limit *RateLimit) take(key string) (bool, int, time.Duration) {
+	limit.mu.Lock()
+	defer limit.mu.Unlock()
+
+	now := limit.now()
+	limit.sweep(now)
+
+	b, ok := limit.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		limit.buckets[key] = b
+	}
+
+	// Recarga según el tiempo transcurrido
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		return false, 0, wait
+	}
+
+	b.tokens--
+	full := time.Duration((float64(limit.Burst) - b.tokens) / limit.Rate * float64(time.Second))
+	return true, int(b.tokens), full
+}
+
+// Elimina los buckets de clientes inactivos. Un bucket que ya se habría
+// llenado equivale a uno nuevo, así que puede descartarse sin cambiar el límite.
+// Se ejecuta como mucho una vez por intervalo de recarga.
+func (limit *RateLimit) sweep(now time.Time) {
+	idle := limit.refillTime()
+	if now.Sub(limit.lastSweep) < idle {
+		return
+	}
+	limit.lastSweep = now
+
+	for key, b := range limit.buckets {
+		if now.Sub(b.last) >= idle {
+			delete(limit.buckets, key)
+		}
+	}
+}
+
+// Redondea hacia arriba a segundos, con un mínimo de 1.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
+
+// Middleware devuelve el middleware que aplica el límite y añade las
+// cabeceras RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset y RateLimit-Policy.
+func (limit *RateLimit) Middleware() core.Middleware {
+	policy := fmt.Sprintf("%d;w=%d", limit.Burst, int(math.Ceil(limit.refillTime().Seconds())))
+
+	return func(next core.Handle) core.Handle {
+		return func(request *core.HttpRequest) (*core.HttpResponse, error) {
+			allowed, remaining, reset := limit.take(limit.Key(request))
+
+			if !allowed {
+				return core.NewHttpResponse(429, "Too Many Requests", "").
+					SetHeader("Retry-After", ceilSeconds(reset)).
+					SetHeader("RateLimit-Limit", strconv.Itoa(limit.Burst)).
+					SetHeader("RateLimit-Remaining", "0").
+					SetHeader("RateLimit-Reset", ceilSeconds(reset)).
+					SetHeader("RateLimit-Policy", policy).
+					Text("too many requests"), nil
+			}
+
+			response, err := next(request)
+			if err != nil || response == nil {
+				return response, err
+			}
+
+			return response.
+				SetHeader("RateLimit-Limit", strconv.Itoa(limit.Burst)).
+				SetHeader("RateLimit-Remaining", strconv.Itoa(remaining)).
+				SetHeader("RateLimit-Reset", ceilSeconds(reset)).
+				SetHeader("RateLimit-Policy", policy), nil
+		}
+	}
+}
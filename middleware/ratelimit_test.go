@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limit, _ := NewRateLimit(2, time.Minute)
+	limit.now = func() time.Time { return now }
+	handle := limit.Middleware()(whoami)
+
+	request := func(addr string) *core.HttpResponse {
+		req := makeReq("GET", "/loadtest", map[string]string{})
+		req.RemoteAddr = addr
+		res, _ := handle(req)
+		return res
+	}
+
+	// Dos solicitudes permitidas, la tercera rechazada
+	res := request("10.0.0.1:5000")
+	if res.StatusCode != 200 || res.Headers["RateLimit-Remaining"] != "1" || res.Headers["RateLimit-Limit"] != "2" {
+		t.Errorf("Expected 200 with 1 remaining, not %d %v", res.StatusCode, res.Headers)
+	}
+	request("10.0.0.1:5001")
+	res = request("10.0.0.1:5002")
+	if res.StatusCode != 429 || res.Headers["Retry-After"] != "30" || res.Headers["RateLimit-Policy"] != "2;w=60" {
+		t.Errorf("Expected 429 with Retry-After 30, not %d %v", res.StatusCode, res.Headers)
+	}
+
+	// Otro cliente tiene su propio bucket
+	if res = request("10.0.0.2:5000"); res.StatusCode != 200 {
+		t.Errorf("Expected 200 for another client, not %d", res.StatusCode)
+	}
+
+	// Tras 30 segundos se recarga un token
+	now = now.Add(30 * time.Second)
+	if res = request("10.0.0.1:5003"); res.StatusCode != 200 {
+		t.Errorf("Expected 200 after refill, not %d", res.StatusCode)
+	}
+	if res = request("10.0.0.1:5004"); res.StatusCode != 429 {
+		t.Errorf("Expected 429 again, not %d", res.StatusCode)
+	}
+}
+
+func TestRateLimitSweepsIdleBuckets(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limit, _ := NewRateLimit(10, time.Second)
+	limit.now = func() time.Time { return now }
+
+	limit.take("a")
+	limit.take("b")
+	if len(limit.buckets) != 2 {
+		t.Fatalf("Expected 2 buckets, not %d", len(limit.buckets))
+	}
+
+	now = now.Add(2 * time.Second)
+	limit.take("c")
+	if len(limit.buckets) != 1 {
+		t.Errorf("Expected idle buckets to be removed, not %d left", len(limit.buckets))
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	req := makeReq("POST", "/simulate", map[string]string{})
+	req.RemoteAddr = "[::1]:4000"
+
+	if key := KeyByIP(req); key != "ip:::1" {
+		t.Errorf("Expected ip:::1, not %q", key)
+	}
+	if key := KeyByPrincipal(req); key != "ip:::1" {
+		t.Errorf("Expected anonymous principal keyed by IP, not %q", key)
+	}
+	req.Principal = &core.Principal{Name: "kate"}
+	if key := KeyByPrincipal(req); key != "principal:kate" {
+		t.Errorf("Expected principal:kate, not %q", key)
+	}
+	if key := KeyByRoute(req); key != "route:POST /simulate" {
+		t.Errorf("Expected route key, not %q", key)
+	}
+}
+
+func TestNewRateLimitRejectsInvalid(t *testing.T) {
+	cases := []struct {
+		requests int
+		per      time.Duration
+	}{
+		{0, time.Minute},
+		{-1, time.Minute},
+		{10, 0},
+		{10, -time.Second},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("TestNewRateLimitRejectsInvalid %d", i), func(t *testing.T) {
+			if limit, err := NewRateLimit(c.requests, c.per); err == nil {
+				t.Errorf("Expected error, not %+v", limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// ForwardProxy es un proxy de reenvío: abre túneles con CONNECT y reenvía
+// las solicitudes HTTP con target absoluto. Solo se permiten los destinos
+// de Allow, con el formato "host:puerto" donde el host puede ser un nombre,
+// "*.dominio" (subdominios), una red CIDR o "*", y el puerto un número o "*":
+//
+//	forward := proxy.NewForwardProxy("*.example.com:443", "10.0.0.0/8:*")
+//	server.Proxy = forward.Handle
+type ForwardProxy struct {
+	Allow         []string      // Destinos permitidos (vacío rechaza todos)
+	DialTimeout   time.Duration // Tiempo máximo para conectar con el destino
+	HeaderTimeout time.Duration // Tiempo máximo de espera de la cabecera de la respuesta
+}
+
+// Crea un ForwardProxy que permite los destinos dados.
+func NewForwardProxy(allow ...string) *ForwardProxy {
+	return &ForwardProxy{
+		Allow:         allow,
+		DialTimeout:   5 * time.Second,
+		HeaderTimeout: 30 * time.Second,
+	}
+}
+
+// Indica si el destino "host:puerto" está permitido.
+func (forward *ForwardProxy) Allowed(address string) bool {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	host = strings.ToLower(host)
+
+	for _, rule := range forward.Allow {
+		ruleHost, rulePort, err := net.SplitHostPort(strings.TrimSpace(rule))
+		if err != nil {
+			continue
+		}
+		if rulePort != "*" && rulePort != port {
+			continue
+		}
+		if matchHost(strings.ToLower(ruleHost), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compara un host con el de una regla: "*", "*.dominio", CIDR o nombre exacto.
+func matchHost(rule, host string) bool {
+	switch {
+	case rule == "*":
+		return true
+	case strings.HasPrefix(rule, "*."):
+		return strings.HasSuffix(host, rule[1:])
+	case strings.Contains(rule, "/"):
+		_, network, err := net.ParseCIDR(rule)
+		ip := net.ParseIP(host)
+		return err == nil && ip != nil && network.Contains(ip)
+	}
+	return rule == host
+}
+
+// Handle atiende una solicitud CONNECT o con target absoluto. Responde 403 si
+// el destino no está permitido y 502 si no se puede conectar con él.
+func (forward *ForwardProxy) Handle(request *core.HttpRequest) (*core.HttpResponse, error) {
+	target := request.Target
+	if request.Method != "CONNECT" && target.Scheme != "http" {
+		return core.BadRequest().Text("only http targets can be forwarded"), nil
+	}
+
+	address := target.Host
+	if target.Port() == "" {
+		address = net.JoinHostPort(target.Hostname(), "80")
+	}
+
+	if !forward.Allowed(address) {
+		slog.Warn("Proxy destination denied", "client", request.ClientIP(), "method", request.Method, "destination", address)
+		return core.Forbidden().Text("destination not allowed"), nil
+	}
+
+	upstream, err := net.DialTimeout("tcp", address, forward.DialTimeout)
+	if err != nil {
+		slog.Warn("Proxy dial failed", "destination", address, "error", err)
+		return core.NewHttpResponse(502, "Bad Gateway", "").Text("bad gateway"), nil
+	}
+
+	if request.Method == "CONNECT" {
+		return core.Hijack(func(conn net.Conn, reader *bufio.Reader) {
+			tunnel(conn, reader, upstream, address)
+		}), nil
+	}
+
+	// Reutiliza el reenvío del proxy inverso con el destino como único upstream
+	reverse := &ReverseProxy{HeaderTimeout: forward.HeaderTimeout}
+	destination := &Upstream{URL: &url.URL{Scheme: "http", Host: target.Host}}
+	response, err := reverse.roundTrip(destination, upstream, request)
+	if err != nil {
+		upstream.Close()
+		slog.Warn("Proxy request failed", "destination", address, "error", err)
+		return core.NewHttpResponse(502, "Bad Gateway", "").Text("bad gateway"), nil
+	}
+	return response, nil
+}
+
+// Confirma el túnel y copia bytes en ambos sentidos hasta que uno de los
+// extremos cierra; entonces cierra los dos.
+func tunnel(conn net.Conn, reader *bufio.Reader, upstream net.Conn, address string) {
+	defer conn.Close()
+	defer upstream.Close()
+
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+	slog.Info("Tunnel opened", "destination", address)
+
+	var once sync.Once
+	done := make(chan struct{})
+	stop := func() { once.Do(func() { close(done) }) }
+
+	go func() {
+		// El reader puede tener ya bytes del cliente enviados tras la cabecera
+		io.Copy(upstream, reader)
+		stop()
+	}()
+	go func() {
+		io.Copy(conn, upstream)
+		stop()
+	}()
+
+	<-done
+	slog.Info("Tunnel closed", "destination", address)
+}
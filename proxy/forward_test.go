@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+func TestForwardAllowed(t *testing.T) {
+	forward := NewForwardProxy("*.example.com:443", "api.test:*", "10.0.0.0/8:22")
+
+	cases := []struct {
+		address string
+		allowed bool
+	}{
+		{"www.example.com:443", true},
+		{"a.b.example.com:443", true},
+		{"example.com:443", false},
+		{"www.example.com:80", false},
+		{"API.test:8080", true},
+		{"10.1.2.3:22", true},
+		{"10.1.2.3:23", false},
+		{"11.0.0.1:22", false},
+		{"evil.com:443", false},
+		{"no-port", false},
+	}
+	for _, c := range cases {
+		if got := forward.Allowed(c.address); got != c.allowed {
+			t.Errorf("Allowed(%q): expected %v, not %v", c.address, c.allowed, got)
+		}
+	}
+
+	if NewForwardProxy().Allowed("example.com:443") {
+		t.Errorf("Expected an empty allowlist to deny every destination")
+	}
+}
+
+// newForwardFront crea un servidor en modo proxy de reenvío.
+func newForwardFront(t *testing.T, forward *ForwardProxy) string {
+	front := core.NewHttpServer()
+	front.Proxy = forward.Handle
+	front.Get("/local", func(req *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok().Text("local"), nil
+	})
+	return startServer(t, front)
+}
+
+func TestForwardConnect(t *testing.T) {
+	// Destino: eco TCP que devuelve lo recibido
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	destination := ln.Addr().String()
+
+	front := newForwardFront(t, NewForwardProxy("127.0.0.1:*"))
+	u, _ := url.Parse(front)
+
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Los primeros bytes del túnel llegan junto con la solicitud
+	fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\nping", destination, destination)
+	reader := bufio.NewReader(conn)
+
+	status, _ := reader.ReadString('\n')
+	if status != "HTTP/1.1 200 Connection Established\r\n" {
+		t.Fatalf("Expected 200 Connection Established, not %q", status)
+	}
+	reader.ReadString('\n')
+
+	echo := make([]byte, 4)
+	if _, err := io.ReadFull(reader, echo); err != nil || string(echo) != "ping" {
+		t.Errorf("Expected ping through the tunnel, not %q (%v)", echo, err)
+	}
+
+	fmt.Fprint(conn, "pong")
+	if _, err := io.ReadFull(reader, echo); err != nil || string(echo) != "pong" {
+		t.Errorf("Expected pong through the tunnel, not %q (%v)", echo, err)
+	}
+}
+
+func TestForwardConnectRejected(t *testing.T) {
+	front := newForwardFront(t, NewForwardProxy("*.example.com:443"))
+
+	response, _ := roundTrip(t, front, "CONNECT 127.0.0.1:22 HTTP/1.1\r\nHost: 127.0.0.1:22\r\n\r\n")
+	if response.StatusCode != 403 {
+		t.Errorf("Expected 403 for a destination outside the allowlist, not %d", response.StatusCode)
+	}
+
+	// Puerto cerrado en un destino permitido → 502
+	front = newForwardFront(t, NewForwardProxy("127.0.0.1:*"))
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := ln.Addr().String()
+	ln.Close()
+
+	response, _ = roundTrip(t, front, "CONNECT "+closed+" HTTP/1.1\r\nHost: "+closed+"\r\n\r\n")
+	if response.StatusCode != 502 {
+		t.Errorf("Expected 502 for an unreachable destination, not %d", response.StatusCode)
+	}
+}
+
+func TestForwardAbsoluteForm(t *testing.T) {
+	upstream := newUpstream(t, "origin")
+	target, _ := url.Parse(upstream)
+	front := newForwardFront(t, NewForwardProxy("127.0.0.1:*"))
+
+	response, body := roundTrip(t, front, "GET "+upstream+"/items?x=1 HTTP/1.1\r\nHost: "+target.Host+"\r\nProxy-Connection: keep-alive\r\nConnection: close\r\n\r\n")
+	if response.StatusCode != 200 {
+		t.Fatalf("Expected 200, not %d", response.StatusCode)
+	}
+	if !strings.HasPrefix(body, "origin /items?x=1 host="+target.Host) {
+		t.Errorf("Expected request forwarded in origin-form, not %q", body)
+	}
+
+	// Las rutas propias siguen funcionando con targets relativos
+	_, body = roundTrip(t, front, "GET /local HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n")
+	if body != "local" {
+		t.Errorf("Expected local route, not %q", body)
+	}
+
+	response, _ = roundTrip(t, front, "GET https://example.com/ HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+	if response.StatusCode != 400 {
+		t.Errorf("Expected 400 for a non-http target, not %d", response.StatusCode)
+	}
+}
@@ -0,0 +1,354 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// Cabeceras de un solo salto (RFC 9110, sección 7.6.1): no se reenvían.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"TE",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Error devuelto cuando ningún upstream está disponible.
+var ErrNoUpstream = errors.New("no healthy upstream")
+
+// Estrategia de reparto de solicitudes entre upstreams.
+type Balancing int
+
+const (
+	RoundRobin       Balancing = iota // Turnos en orden
+	LeastConnections                  // El upstream con menos solicitudes en curso
+)
+
+// Upstream es un servidor al que se reenvían solicitudes.
+type Upstream struct {
+	URL *url.URL // Dirección base (ej. http://10.0.0.2:9000/api)
+
+	active    int64 // Solicitudes en curso
+	mu        sync.Mutex
+	failures  int       // Fallos consecutivos
+	downUntil time.Time // Excluido del reparto hasta este momento
+}
+
+// Indica si el upstream puede recibir solicitudes.
+func (upstream *Upstream) available(now time.Time) bool {
+	upstream.mu.Lock()
+	defer upstream.mu.Unlock()
+	return !now.Before(upstream.downUntil)
+}
+
+// Dirección "host:puerto" para conectarse al upstream.
+func (upstream *Upstream) address() string {
+	if upstream.URL.Port() != "" {
+		return upstream.URL.Host
+	}
+	return net.JoinHostPort(upstream.URL.Hostname(), "80")
+}
+
+// ReverseProxy reenvía solicitudes a uno o varios upstreams HTTP/1.1.
+// Los upstreams que fallan MaxFails veces seguidas (error de conexión o
+// respuesta 5xx) se excluyen durante FailTimeout (comprobación de salud
+// pasiva). Se registra con StreamBody
+// para que los cuerpos fluyan en ambos sentidos sin cargarse en memoria:
+//
+//	api, _ := proxy.New("http://10.0.0.2:9000", "http://10.0.0.3:9000")
+//	api.StripPrefix = "/api"
+//	server.Get("/api", api.Handle).StreamBody()
+type ReverseProxy struct {
+	Upstreams     []*Upstream
+	Balancing     Balancing
+	StripPrefix   string        // Prefijo que se quita de la ruta antes de reenviarla
+	DialTimeout   time.Duration // Tiempo máximo para conectar con un upstream
+	HeaderTimeout time.Duration // Tiempo máximo de espera de la cabecera de la respuesta
+	MaxFails      int           // Fallos seguidos para excluir un upstream
+	FailTimeout   time.Duration // Tiempo que un upstream queda excluido
+
+	next uint64 // Turno para RoundRobin
+}
+
+// Crea un ReverseProxy con reparto por turnos hacia las URL dadas.
+func New(targets ...string) (*ReverseProxy, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("no upstreams")
+	}
+
+	proxy := &ReverseProxy{
+		DialTimeout:   5 * time.Second,
+		HeaderTimeout: 30 * time.Second,
+		MaxFails:      3,
+		FailTimeout:   10 * time.Second,
+	}
+
+	for _, target := range targets {
+		u, err := url.Parse(strings.TrimSpace(target))
+		if err != nil || u.Scheme != "http" || u.Host == "" {
+			return nil, fmt.Errorf("invalid upstream url %q", target)
+		}
+		proxy.Upstreams = append(proxy.Upstreams, &Upstream{URL: u})
+	}
+
+	return proxy, nil
+}
+
+// Elige un upstream disponible distinto de los ya intentados.
+func (proxy *ReverseProxy) pick(tried map[*Upstream]bool) *Upstream {
+	now := time.Now()
+	candidates := make([]*Upstream, 0, len(proxy.Upstreams))
+	for _, upstream := range proxy.Upstreams {
+		if !tried[upstream] && upstream.available(now) {
+			candidates = append(candidates, upstream)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if proxy.Balancing == LeastConnections {
+		best := candidates[0]
+		for _, upstream := range candidates[1:] {
+			if atomic.LoadInt64(&upstream.active) < atomic.LoadInt64(&best.active) {
+				best = upstream
+			}
+		}
+		return best
+	}
+
+	turn := atomic.AddUint64(&proxy.next, 1) - 1
+	return candidates[turn%uint64(len(candidates))]
+}
+
+// Registra el resultado de una solicitud para la comprobación de salud pasiva.
+func (proxy *ReverseProxy) report(upstream *Upstream, err error) {
+	upstream.mu.Lock()
+	defer upstream.mu.Unlock()
+
+	if err == nil {
+		upstream.failures = 0
+		return
+	}
+
+	upstream.failures++
+	if proxy.MaxFails > 0 && upstream.failures >= proxy.MaxFails {
+		upstream.downUntil = time.Now().Add(proxy.FailTimeout)
+		upstream.failures = 0
+		slog.Warn("Upstream marked down", "upstream", upstream.URL.String(), "until", upstream.downUntil, "error", err)
+	}
+}
+
+// Handle reenvía la solicitud a un upstream y devuelve su respuesta.
+// Responde 502 si el upstream falla y 503 si no hay ninguno disponible.
+func (proxy *ReverseProxy) Handle(request *core.HttpRequest) (*core.HttpResponse, error) {
+	tried := make(map[*Upstream]bool)
+	for {
+		upstream := proxy.pick(tried)
+		if upstream == nil {
+			return core.NewHttpResponse(503, "Service Unavailable", "").Text(ErrNoUpstream.Error()), nil
+		}
+		tried[upstream] = true
+
+		conn, err := net.DialTimeout("tcp", upstream.address(), proxy.DialTimeout)
+		if err != nil {
+			// El cuerpo todavía no se envió: se puede reintentar con otro upstream
+			slog.Warn("Upstream dial failed", "upstream", upstream.URL.String(), "error", err)
+			proxy.report(upstream, err)
+			continue
+		}
+
+		response, err := proxy.roundTrip(upstream, conn, request)
+		if err != nil {
+			conn.Close()
+			slog.Warn("Upstream request failed", "upstream", upstream.URL.String(), "error", err)
+			proxy.report(upstream, err)
+			return core.NewHttpResponse(502, "Bad Gateway", "").Text("bad gateway"), nil
+		}
+
+		// Un 5xx cuenta como fallo para la comprobación de salud, pero la
+		// solicitud no se reintenta: el upstream pudo haberla procesado.
+		if response.StatusCode >= 500 {
+			proxy.report(upstream, fmt.Errorf("upstream responded %d %s", response.StatusCode, response.StatusText))
+		} else {
+			proxy.report(upstream, nil)
+		}
+		return response, nil
+	}
+}
+
+// Envía la solicitud por la conexión y lee la cabecera de la respuesta.
+// El cuerpo de la respuesta se transmite al cliente y la conexión se cierra al terminar.
+func (proxy *ReverseProxy) roundTrip(upstream *Upstream, conn net.Conn, request *core.HttpRequest) (*core.HttpResponse, error) {
+	atomic.AddInt64(&upstream.active, 1)
+	release := sync.OnceFunc(func() {
+		atomic.AddInt64(&upstream.active, -1)
+		conn.Close()
+	})
+
+	if err := proxy.writeRequest(conn, upstream, request); err != nil {
+		release()
+		return nil, err
+	}
+
+	if proxy.HeaderTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(proxy.HeaderTimeout))
+	}
+	response, err := core.ReadResponse(bufio.NewReader(conn), request.Method)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	removeHopByHop(response.Headers)
+	response.Version = ""
+
+	if response.BodyReader == nil {
+		release()
+	} else {
+		response.BodyReader = &upstreamBody{Reader: response.BodyReader, release: release}
+	}
+
+	return response, nil
+}
+
+// Escribe la solicitud reenviada: ruta reescrita, cabeceras sin las de un
+// solo salto, X-Forwarded-* y el cuerpo en streaming.
+func (proxy *ReverseProxy) writeRequest(conn net.Conn, upstream *Upstream, request *core.HttpRequest) error {
+	headers := make(map[string]string, len(request.Headers)+4)
+	for key, value := range request.Headers {
+		headers[key] = value
+	}
+	removeHopByHop(headers)
+	// El 100 Continue ya lo envía este servidor al leer el cuerpo del cliente;
+	// el del upstream se confundiría con la respuesta final.
+	core.DeleteHeader(headers, "Expect")
+
+	// Se añade la IP de la conexión a la cadena recibida solo si viene de un
+	// proxy de confianza; si no, la cadena del cliente podría estar falsificada.
+	peer, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		peer = request.RemoteAddr
+	}
+	forwardedFor := peer
+	if previous := request.Header("X-Forwarded-For"); previous != "" && request.RealIP != "" {
+		forwardedFor = previous + ", " + peer
+	}
+	replaced := map[string]string{
+		"X-Forwarded-For":   forwardedFor,
+		"X-Forwarded-Proto": request.Scheme,
+		"Host":              upstream.URL.Host,
+		"Connection":        "close",
+	}
+	if request.Host != "" {
+		replaced["X-Forwarded-Host"] = request.Host
+	}
+
+	// Cuerpo: longitud conocida o chunked
+	body := request.BodyReader
+	if body == nil && len(request.BodyBytes()) > 0 {
+		body = bytes.NewReader(request.BodyBytes())
+		replaced["Content-Length"] = strconv.Itoa(len(request.BodyBytes()))
+	}
+	_, hasLength := core.FindHeader(headers, "Content-Length")
+	chunked := body != nil && !hasLength && replaced["Content-Length"] == ""
+	if chunked {
+		replaced["Transfer-Encoding"] = "chunked"
+	}
+
+	for name, value := range replaced {
+		core.DeleteHeader(headers, name)
+		headers[name] = value
+	}
+
+	var head bytes.Buffer
+	fmt.Fprintf(&head, "%s %s HTTP/1.1\r\n", request.Method, proxy.targetURI(upstream, request.Target))
+	for key, value := range headers {
+		fmt.Fprintf(&head, "%s: %s\r\n", key, value)
+	}
+	head.WriteString("\r\n")
+
+	if _, err := conn.Write(head.Bytes()); err != nil {
+		return err
+	}
+	if body == nil {
+		return nil
+	}
+
+	if !chunked {
+		_, err := io.Copy(conn, body)
+		return err
+	}
+
+	writer := core.NewChunkedWriter(conn)
+	if _, err := io.Copy(writer, body); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// Construye la ruta para el upstream: quita StripPrefix y antepone la ruta base del upstream.
+func (proxy *ReverseProxy) targetURI(upstream *Upstream, target *url.URL) string {
+	path := target.EscapedPath()
+	if proxy.StripPrefix != "" {
+		path = strings.TrimPrefix(path, proxy.StripPrefix)
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+
+	base := strings.TrimSuffix(upstream.URL.EscapedPath(), "/")
+	uri := base + path
+	if uri == "" {
+		// Target absoluto sin ruta ("http://example.com")
+		uri = "/"
+	}
+	if target.RawQuery != "" {
+		uri += "?" + target.RawQuery
+	}
+	return uri
+}
+
+// Cuerpo de una respuesta del upstream. Al cerrarse libera la conexión.
+type upstreamBody struct {
+	io.Reader
+	release func()
+}
+
+func (body *upstreamBody) Close() error {
+	body.release()
+	return nil
+}
+
+// Elimina las cabeceras de un solo salto y las que enumera Connection.
+func removeHopByHop(headers map[string]string) {
+	if key, ok := core.FindHeader(headers, "Connection"); ok {
+		for _, name := range strings.Split(headers[key], ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				core.DeleteHeader(headers, name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		core.DeleteHeader(headers, name)
+	}
+}
@@ -0,0 +1,321 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// startServer inicia un servidor en un puerto efímero y devuelve su URL.
+func startServer(t *testing.T, server *core.HttpServer) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() { ln.Close() })
+	return "http://" + ln.Addr().String()
+}
+
+// newUpstream crea un upstream que responde con su nombre, la ruta y algunas cabeceras recibidas.
+func newUpstream(t *testing.T, name string) string {
+	server := core.NewHttpServer()
+	echo := func(req *core.HttpRequest) (*core.HttpResponse, error) {
+		body := []byte{}
+		if req.BodyReader != nil {
+			body, _ = io.ReadAll(req.BodyReader)
+		}
+		return core.Ok().
+			SetHeader("X-Upstream", name).
+			SetHeader("Keep-Alive", "timeout=5").
+			Text(fmt.Sprintf("%s %s?%s host=%s xff=%s proto=%s conn=%s te=%s body=%s",
+				name, req.Target.Path, req.Target.RawQuery, req.Header("Host"), req.Header("X-Forwarded-For"),
+				req.Header("X-Forwarded-Proto"), req.Header("Connection"), req.Header("Transfer-Encoding"), body)), nil
+	}
+	for _, path := range []string{"/", "/items", "/upload"} {
+		server.Get(path, echo).StreamBody()
+		server.Head(path, echo).StreamBody()
+		server.Post(path, echo).StreamBody()
+	}
+	return startServer(t, server)
+}
+
+// roundTrip envía una solicitud cruda y devuelve la respuesta leída con core.ReadResponse.
+func roundTrip(t *testing.T, address, raw string) (*core.HttpResponse, string) {
+	t.Helper()
+	u, _ := url.Parse(address)
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprint(conn, raw)
+	response, err := core.ReadResponse(bufio.NewReader(conn), strings.Fields(raw)[0])
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	body := ""
+	if response.BodyReader != nil {
+		data, _ := io.ReadAll(response.BodyReader)
+		body = string(data)
+	}
+	return response, body
+}
+
+// newFront crea el servidor que reenvía "/api" al proxy dado.
+func newFront(t *testing.T, proxy *ReverseProxy) string {
+	proxy.StripPrefix = "/api"
+	front := core.NewHttpServer()
+	front.Get("/api", proxy.Handle).StreamBody()
+	front.Post("/api", proxy.Handle).StreamBody()
+	front.Head("/api", proxy.Handle).StreamBody()
+	return startServer(t, front)
+}
+
+func TestReverseProxyForwards(t *testing.T) {
+	upstream := newUpstream(t, "a")
+	proxy, err := New(upstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	front := newFront(t, proxy)
+
+	response, body := roundTrip(t, front, "GET /api/items?x=1 HTTP/1.1\r\nHost: public.test\r\nConnection: close\r\nX-Forwarded-For: 6.6.6.6\r\n\r\n")
+	if response.StatusCode != 200 || response.Headers["X-Upstream"] != "a" {
+		t.Fatalf("Expected 200 from upstream a, not %d %v", response.StatusCode, response.Headers)
+	}
+
+	upstreamHost := strings.TrimPrefix(upstream, "http://")
+	want := "a /items?x=1 host=" + upstreamHost + " xff=127.0.0.1 proto=http conn=close te= body="
+	if body != want {
+		t.Errorf("Expected %q, not %q", want, body)
+	}
+
+	// Las cabeceras de un solo salto de la respuesta no se reenvían
+	if _, ok := response.Headers["Keep-Alive"]; ok {
+		t.Errorf("Expected hop-by-hop header to be removed, not %v", response.Headers)
+	}
+}
+
+func TestReverseProxyStreamsBodies(t *testing.T) {
+	proxy, _ := New(newUpstream(t, "a"))
+	front := newFront(t, proxy)
+
+	// Cuerpo chunked de entrada, reenviado en chunked
+	raw := "POST /api/upload HTTP/1.1\r\nHost: x\r\nConnection: close\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n6\r\n world\r\n0\r\n\r\n"
+	response, body := roundTrip(t, front, raw)
+	if response.StatusCode != 200 || !strings.HasSuffix(body, "te=chunked body=hello world") {
+		t.Errorf("Expected chunked body forwarded, not %d %q", response.StatusCode, body)
+	}
+
+	// Cuerpo con Content-Length
+	raw = "POST /api/upload HTTP/1.1\r\nHost: x\r\nConnection: close\r\nContent-Length: 5\r\n\r\nhello"
+	_, body = roundTrip(t, front, raw)
+	if !strings.HasSuffix(body, "te= body=hello") {
+		t.Errorf("Expected fixed-length body forwarded, not %q", body)
+	}
+
+	// HEAD conserva el Content-Length del upstream sin cuerpo
+	response, _ = roundTrip(t, front, "HEAD /api/ HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n")
+	if response.StatusCode != 200 || response.BodyLength() <= 0 {
+		t.Errorf("Expected upstream Content-Length on HEAD, not %d", response.BodyLength())
+	}
+}
+
+// newContinueUpstream crea un upstream que envía 100 Continue antes de leer
+// el cuerpo y responde con el cuerpo y la cabecera Expect recibidos.
+func newContinueUpstream(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		request, err := core.ReadRequestHead(reader)
+		if err != nil {
+			return
+		}
+		fmt.Fprint(conn, "HTTP/1.1 100 Continue\r\n\r\n")
+		if err := core.ParseBody(request, reader); err != nil {
+			return
+		}
+		body := fmt.Sprintf("body=%s expect=%s", request.Body, request.Header("Expect"))
+		fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+	}()
+
+	return "http://" + ln.Addr().String()
+}
+
+func TestReverseProxyExpectContinue(t *testing.T) {
+	// Arrange
+	proxy, _ := New(newContinueUpstream(t))
+	front := newFront(t, proxy)
+
+	// Act
+	raw := "POST /api/upload HTTP/1.1\r\nHost: x\r\nConnection: close\r\nExpect: 100-continue\r\nContent-Length: 5\r\n\r\nhello"
+	response, body := roundTrip(t, front, raw)
+
+	// Assert: el 100 del upstream no se toma como respuesta final
+	if response.StatusCode != 200 || body != "body=hello expect=" {
+		t.Errorf("Expected 200 with the body and no Expect upstream, not %d %q", response.StatusCode, body)
+	}
+}
+
+func TestReverseProxyRoundRobin(t *testing.T) {
+	proxy, _ := New(newUpstream(t, "a"), newUpstream(t, "b"))
+	front := newFront(t, proxy)
+
+	seen := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		response, _ := roundTrip(t, front, "GET /api/ HTTP/1.0\r\n\r\n")
+		seen = append(seen, response.Headers["X-Upstream"])
+	}
+	if strings.Join(seen, "") != "abab" {
+		t.Errorf("Expected abab, not %v", seen)
+	}
+}
+
+func TestReverseProxyLeastConnections(t *testing.T) {
+	proxy, _ := New("http://127.0.0.1:1", "http://127.0.0.1:2", "http://127.0.0.1:3")
+	proxy.Balancing = LeastConnections
+	proxy.Upstreams[0].active = 3
+	proxy.Upstreams[1].active = 1
+	proxy.Upstreams[2].active = 2
+
+	if upstream := proxy.pick(map[*Upstream]bool{}); upstream != proxy.Upstreams[1] {
+		t.Errorf("Expected the upstream with fewest connections, not %v", upstream.URL)
+	}
+	if upstream := proxy.pick(map[*Upstream]bool{proxy.Upstreams[1]: true}); upstream != proxy.Upstreams[2] {
+		t.Errorf("Expected the next least loaded upstream, not %v", upstream.URL)
+	}
+}
+
+func TestReverseProxyPassiveHealthChecks(t *testing.T) {
+	// Un puerto sin servidor
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	dead := "http://" + ln.Addr().String()
+	ln.Close()
+
+	proxy, _ := New(dead, newUpstream(t, "b"))
+	proxy.MaxFails = 2
+	front := newFront(t, proxy)
+
+	// Los fallos de conexión se reintentan con el siguiente upstream
+	for i := 0; i < 3; i++ {
+		response, _ := roundTrip(t, front, "GET /api/ HTTP/1.0\r\n\r\n")
+		if response.StatusCode != 200 || response.Headers["X-Upstream"] != "b" {
+			t.Fatalf("Expected 200 from b, not %d", response.StatusCode)
+		}
+	}
+	if proxy.Upstreams[0].available(time.Now()) {
+		t.Errorf("Expected dead upstream to be marked down")
+	}
+
+	// Sin upstreams disponibles: 503
+	onlyDead, _ := New(dead)
+	onlyDead.MaxFails = 1
+	response, _ := roundTrip(t, newFront(t, onlyDead), "GET /api/ HTTP/1.0\r\n\r\n")
+	if response.StatusCode != 503 {
+		t.Errorf("Expected 503, not %d", response.StatusCode)
+	}
+}
+
+func TestReverseProxyBadGateway(t *testing.T) {
+	// Upstream que responde algo que no es HTTP
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			bufio.NewReader(conn).ReadString('\n')
+			fmt.Fprint(conn, "garbage\r\n\r\n")
+			conn.Close()
+		}
+	}()
+
+	proxy, _ := New("http://" + ln.Addr().String())
+	response, _ := roundTrip(t, newFront(t, proxy), "GET /api/ HTTP/1.0\r\n\r\n")
+	if response.StatusCode != 502 {
+		t.Errorf("Expected 502, not %d", response.StatusCode)
+	}
+}
+
+func TestReverseProxyServerErrorsCountAsFailures(t *testing.T) {
+	// Upstream que siempre responde 503
+	failing := core.NewHttpServer()
+	failing.Get("/", func(req *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.NewHttpResponse(503, "Service Unavailable", "").Text("busy"), nil
+	})
+	proxy, _ := New(startServer(t, failing), newUpstream(t, "b"))
+	proxy.MaxFails = 2
+	front := newFront(t, proxy)
+
+	// El 503 llega al cliente sin reintentarse, pero cuenta como fallo
+	for i, expected := range []int{503, 200, 503} {
+		response, _ := roundTrip(t, front, "GET /api/ HTTP/1.0\r\n\r\n")
+		if response.StatusCode != expected {
+			t.Fatalf("Expected %d in request %d, not %d", expected, i, response.StatusCode)
+		}
+	}
+	if proxy.Upstreams[0].available(time.Now()) {
+		t.Errorf("Expected upstream answering 5xx to be marked down")
+	}
+}
+
+func TestReverseProxySetCookies(t *testing.T) {
+	// Upstream con varias Set-Cookie, una con comas en Expires
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == "\r\n" {
+					break
+				}
+			}
+			fmt.Fprint(conn, "HTTP/1.1 200 OK\r\n"+
+				"Set-Cookie: a=1; Expires=Wed, 21 Oct 2026 07:28:00 GMT\r\n"+
+				"Set-Cookie: b=2\r\n"+
+				"Content-Length: 2\r\n\r\nok")
+			conn.Close()
+		}
+	}()
+
+	proxy, _ := New("http://" + ln.Addr().String())
+	response, body := roundTrip(t, newFront(t, proxy), "GET /api/ HTTP/1.0\r\n\r\n")
+
+	expected := []string{"a=1; Expires=Wed, 21 Oct 2026 07:28:00 GMT", "b=2"}
+	if strings.Join(response.SetCookies, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected cookies %q, not %q", expected, response.SetCookies)
+	}
+	if body != "ok" {
+		t.Errorf("Expected ok, not %q", body)
+	}
+}
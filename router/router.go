@@ -14,29 +14,50 @@ type Route struct {
 	Method string
 	Path   string
 	Handle Handle
+	Roles  []string // Roles aceptados (basta con uno)
+	Scopes []string // Permisos exigidos (todos)
+}
+
+// RequireRoles exige que el principal tenga al menos uno de los roles.
+func (rt *Route) RequireRoles(roles ...string) *Route {
+	rt.Roles = append(rt.Roles, roles...)
+	return rt
+}
+
+// RequireScopes exige que el principal tenga todos los permisos.
+func (rt *Route) RequireScopes(scopes ...string) *Route {
+	rt.Scopes = append(rt.Scopes, scopes...)
+	return rt
 }
 
 // Router mantiene la lista de rutas.
 type Router struct {
-	routes []Route
+	routes []*Route
 }
 
 // New crea un Router vacío.
 func New() *Router {
-	return &Router{routes: make([]Route, 0)}
+	return &Router{routes: make([]*Route, 0)}
 }
 
 // Get registra una ruta GET.
-func (r *Router) Get(path string, h Handle) {
-	r.routes = append(r.routes, Route{"GET", path, h})
+func (r *Router) Get(path string, h Handle) *Route {
+	return r.add("GET", path, h)
 }
 
 // Post, Delete… (idéntico a Get, cambiando Method)
-func (r *Router) Post(path string, h Handle) {
-	r.routes = append(r.routes, Route{"POST", path, h})
+func (r *Router) Post(path string, h Handle) *Route {
+	return r.add("POST", path, h)
+}
+func (r *Router) Delete(path string, h Handle) *Route {
+	return r.add("DELETE", path, h)
 }
-func (r *Router) Delete(path string, h Handle) {
-	r.routes = append(r.routes, Route{"DELETE", path, h})
+
+// add registra una ruta y la devuelve para configurar sus permisos.
+func (r *Router) add(method, path string, h Handle) *Route {
+	rt := &Route{Method: method, Path: path, Handle: h}
+	r.routes = append(r.routes, rt)
+	return rt
 }
 
 // match comprueba coincidencia exacta o prefijo (si path termina en '/').
@@ -54,6 +75,9 @@ func match(reqPath, routePath string) bool {
 func (r *Router) Handle(req *core.HttpRequest) (*core.HttpResponse, error) {
 	for _, rt := range r.routes {
 		if req.Method == rt.Method && match(req.Target.Path, rt.Path) {
+			if denied := core.Authorize(req, rt.Roles, rt.Scopes); denied != nil {
+				return denied, nil
+			}
 			return rt.Handle(req)
 		}
 	}
@@ -153,3 +153,28 @@ func TestDeleteAndPostRoutes(t *testing.T) {
 		t.Errorf("Esperaba 'POST'; obtuve %q", resPost.Body)
 	}
 }
+
+func TestRouteRequireRoles(t *testing.T) {
+	r := New()
+	r.Delete("/item", func(req *core.HttpRequest) (*core.HttpResponse, error) {
+		return core.Ok().Text("DEL"), nil
+	}).RequireRoles("admin")
+
+	req := makeReq("DELETE", "/item")
+	res, _ := r.Handle(req)
+	if res.StatusCode != 401 {
+		t.Errorf("Esperaba 401 sin principal; obtuve %d", res.StatusCode)
+	}
+
+	req.Principal = &core.Principal{Name: "ci", Roles: []string{"user"}}
+	res, _ = r.Handle(req)
+	if res.StatusCode != 403 {
+		t.Errorf("Esperaba 403 sin rol admin; obtuve %d", res.StatusCode)
+	}
+
+	req.Principal.Roles = append(req.Principal.Roles, "admin")
+	res, _ = r.Handle(req)
+	if res.Body != "DEL" {
+		t.Errorf("Esperaba 'DEL' con rol admin; obtuve %q", res.Body)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Crea un archivo en el directorio actual.
@@ -23,15 +24,10 @@ func CreateFile(filename string, content string, repeat int) error {
 		return fmt.Errorf("repeat must be greater than 0")
 	}
 	
-	// Obtener el directorio actual
-	wd, _ := os.Getwd()
-
-	// Construir la ruta completa del archivo
-	path := filepath.Join(wd, filename)
-
-	// Verificar si la ruta es absoluta
-	if !filepath.IsAbs(path) || !strings.HasPrefix(path, wd) {
-		return fmt.Errorf("path is not absolute: %s", path)
+	// Construir y validar la ruta completa del archivo
+	path, err := filePath(filename)
+	if err != nil {
+		return err
 	}
 
 	// Verificar si el archivo ya existe
@@ -40,7 +36,7 @@ func CreateFile(filename string, content string, repeat int) error {
 	}
 
 	// Crear todos los directorios necesarios en la ruta
-	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
 	if err != nil {
 		return err
 	}
@@ -65,12 +61,9 @@ func CreateFile(filename string, content string, repeat int) error {
 	return nil
 }
 
-// Elimina un archivo en el directorio actual.
-// - Solo puede eliminar archivos en el directorio actual.
-// - No puede eliminar un archivo si no existe.
-// - No puede eliminar directorios no vacíos.
-// - Elimina el archivo con el nombre especificado.
-func DeleteFile(filename string) error {
+// Construye la ruta absoluta de un archivo dentro del directorio actual.
+// Devuelve error si la ruta queda fuera del directorio actual.
+func filePath(filename string) (string, error) {
 	// Obtener el directorio de trabajo actual
 	wd, _ := os.Getwd()
 
@@ -79,7 +72,22 @@ func DeleteFile(filename string) error {
 
 	// Verificar si la ruta es absoluta
 	if !filepath.IsAbs(path) || !strings.HasPrefix(path, wd) {
-		return fmt.Errorf("path is not absolute: %s", path)
+		return "", fmt.Errorf("path is not absolute: %s", path)
+	}
+
+	return path, nil
+}
+
+// Elimina un archivo en el directorio actual.
+// - Solo puede eliminar archivos en el directorio actual.
+// - No puede eliminar un archivo si no existe.
+// - No puede eliminar directorios no vacíos.
+// - Elimina el archivo con el nombre especificado.
+func DeleteFile(filename string) error {
+	// Construir y validar la ruta completa del archivo
+	path, err := filePath(filename)
+	if err != nil {
+		return err
 	}
 
 	// Verificar si el archivo existe
@@ -88,7 +96,7 @@ func DeleteFile(filename string) error {
 	}
 
 	// Eliminar el archivo
-	err := os.Remove(path)
+	err = os.Remove(path)
 	if err != nil {
 		return err
 	}
@@ -141,6 +149,9 @@ func CreateFileHandler(request *core.HttpRequest) (*core.HttpResponse, error) {
 
 // Maneja las solicitudes HTTP para eliminar archivos.
 // Extrae el parámetro 'name' de la consulta y valida el parámetro.
+// Respeta If-Match e If-Unmodified-Since con un ETag calculado como en
+// core.ServeContent (fecha y tamaño del archivo del directorio actual), para no
+// eliminar un archivo que cambió desde que el cliente lo leyó.
 // Devuelve una respuesta HTTP indicando éxito o error.
 func DeleteFileHandler(request *core.HttpRequest) (*core.HttpResponse, error) {
 	// Obtener el parámetro 'name' de la consulta
@@ -149,6 +160,17 @@ func DeleteFileHandler(request *core.HttpRequest) (*core.HttpResponse, error) {
 		return core.BadRequest().Text("name is required"), nil
 	}
 
+	// Evaluar las precondiciones con el estado actual del archivo
+	if path, err := filePath(name); err == nil {
+		etag, modTime := "", time.Time{}
+		if info, err := os.Stat(path); err == nil {
+			etag, modTime = core.FileETag(info.ModTime(), info.Size()), info.ModTime()
+		}
+		if conditional := core.CheckPreconditions(request, etag, modTime); conditional != nil {
+			return conditional, nil
+		}
+	}
+
 	// Llamar a la función para eliminar el archivo
 	err := DeleteFile(name)
 	if err != nil {
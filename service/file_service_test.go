@@ -127,3 +127,37 @@ func TestDeleteFileHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteFileHandlerIfMatch(t *testing.T) {
+	name := "temp/ifmatch.txt"
+	Clean(t, name)
+	defer Clean(t, name)
+
+	if err := CreateFile(name, "A", 3); err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	info, _ := os.Stat(name)
+	etag := core.FileETag(info.ModTime(), info.Size())
+
+	target, _ := url.Parse("/deletefile?name=" + name)
+
+	// Un ETag que no coincide no debe eliminar el archivo
+	request := core.NewHttpRequest("DELETE", target, map[string]string{"If-Match": `"stale"`}, "")
+	response, _ := DeleteFileHandler(request)
+	if response.StatusCode != 412 {
+		t.Fatalf("Expected status code to be 412, not %d", response.StatusCode)
+	}
+	if _, err := os.Stat(name); err != nil {
+		t.Fatalf("Expected file to still exist, %v", err)
+	}
+
+	// El ETag actual permite eliminarlo
+	request = core.NewHttpRequest("DELETE", target, map[string]string{"If-Match": etag}, "")
+	response, _ = DeleteFileHandler(request)
+	if response.StatusCode != 200 {
+		t.Fatalf("Expected status code to be 200, not %d", response.StatusCode)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("Expected file to be deleted")
+	}
+}
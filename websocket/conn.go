@@ -0,0 +1,375 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Códigos de operación de las tramas (RFC 6455, sección 5.2).
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+// Códigos de cierre (RFC 6455, sección 7.4.1).
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005 // Nunca se envía: indica un cierre sin código
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// Error devuelto al escribir en una conexión que ya envió la trama de cierre.
+var ErrClosed = errors.New("websocket connection closed")
+
+// CloseError indica que la conexión se cerró con el código y el motivo dados,
+// ya sea por el otro extremo o por un error de protocolo.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (err *CloseError) Error() string {
+	return fmt.Sprintf("websocket closed: %d %s", err.Code, err.Reason)
+}
+
+// Conn es una conexión WebSocket. ReadMessage debe llamarse desde una sola
+// goroutine; los métodos de escritura pueden usarse concurrentemente.
+type Conn struct {
+	Subprotocol  string            // Subprotocolo negociado (vacío si ninguno)
+	FragmentSize int               // Si es mayor que 0, los mensajes se envían en fragmentos de este tamaño
+	PongHandler  func(data []byte) // Se llama al recibir un pong
+
+	conn    net.Conn
+	reader  *bufio.Reader
+	client  bool  // El cliente enmascara sus tramas y el servidor no
+	maxSize int64 // Tamaño máximo de un mensaje recibido
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+func newConn(conn net.Conn, reader *bufio.Reader, client bool, maxSize int64) *Conn {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+	return &Conn{conn: conn, reader: reader, client: client, maxSize: maxSize}
+}
+
+// Dirección remota de la conexión.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Establece el plazo máximo para la siguiente lectura.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// Una trama recibida.
+type frame struct {
+	fin     bool
+	opcode  int
+	payload []byte
+}
+
+// Lee una trama. budget es el máximo de bytes de datos aceptados.
+func (c *Conn) readFrame(budget int64) (*frame, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return nil, err
+	}
+
+	f := &frame{fin: header[0]&0x80 != 0, opcode: int(header[0] & 0x0F)}
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7F)
+
+	if header[0]&0x70 != 0 {
+		return nil, &CloseError{CloseProtocolError, "reserved bits set"}
+	}
+	if masked == c.client {
+		return nil, &CloseError{CloseProtocolError, "invalid frame masking"}
+	}
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, extended); err != nil {
+			return nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, extended); err != nil {
+			return nil, err
+		}
+		if extended[0]&0x80 != 0 {
+			return nil, &CloseError{CloseProtocolError, "invalid frame length"}
+		}
+		length = int64(binary.BigEndian.Uint64(extended))
+	}
+
+	if f.opcode >= CloseMessage {
+		// Las tramas de control no se fragmentan y llevan como mucho 125 bytes
+		if !f.fin || length > 125 {
+			return nil, &CloseError{CloseProtocolError, "invalid control frame"}
+		}
+	} else if length > budget {
+		return nil, &CloseError{CloseMessageTooBig, "message too big"}
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, f.payload); err != nil {
+		return nil, err
+	}
+	if masked {
+		for i := range f.payload {
+			f.payload[i] ^= mask[i%4]
+		}
+	}
+
+	return f, nil
+}
+
+// ReadMessage lee el siguiente mensaje completo (uniendo sus fragmentos) y
+// devuelve su tipo (TextMessage o BinaryMessage). Responde automáticamente a
+// los ping y al cierre. Si la conexión se cierra devuelve un *CloseError.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	opcode := continuationFrame
+	message := make([]byte, 0)
+
+	for {
+		f, err := c.readFrame(c.maxSize - int64(len(message)))
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+
+		switch f.opcode {
+		case PingMessage:
+			if err := c.writeControl(PongMessage, f.payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.PongHandler != nil {
+				c.PongHandler(f.payload)
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.closeReceived(f.payload)
+		case continuationFrame:
+			if opcode == continuationFrame {
+				return 0, nil, c.fail(&CloseError{CloseProtocolError, "unexpected continuation frame"})
+			}
+		case TextMessage, BinaryMessage:
+			if opcode != continuationFrame {
+				return 0, nil, c.fail(&CloseError{CloseProtocolError, "expected continuation frame"})
+			}
+			opcode = f.opcode
+		default:
+			return 0, nil, c.fail(&CloseError{CloseProtocolError, "unknown opcode"})
+		}
+
+		message = append(message, f.payload...)
+		if !f.fin {
+			continue
+		}
+
+		if opcode == TextMessage && !utf8.Valid(message) {
+			return 0, nil, c.fail(&CloseError{CloseInvalidPayload, "invalid utf-8"})
+		}
+		return opcode, message, nil
+	}
+}
+
+// Ante un error de protocolo envía la trama de cierre con su código.
+func (c *Conn) fail(err error) error {
+	var closeErr *CloseError
+	if errors.As(err, &closeErr) {
+		c.Close(closeErr.Code, closeErr.Reason)
+	}
+	return err
+}
+
+// Procesa una trama de cierre recibida: valida el código y responde con el mismo.
+func (c *Conn) closeReceived(payload []byte) error {
+	if len(payload) == 0 {
+		c.Close(CloseNormal, "")
+		return &CloseError{CloseNoStatus, ""}
+	}
+
+	if len(payload) == 1 {
+		return c.fail(&CloseError{CloseProtocolError, "invalid close payload"})
+	}
+
+	code := int(binary.BigEndian.Uint16(payload))
+	reason := payload[2:]
+	if !validCloseCode(code) {
+		return c.fail(&CloseError{CloseProtocolError, "invalid close code"})
+	}
+	if !utf8.Valid(reason) {
+		return c.fail(&CloseError{CloseInvalidPayload, "invalid utf-8"})
+	}
+
+	c.Close(code, "")
+	return &CloseError{code, string(reason)}
+}
+
+// Indica si el código puede enviarse en una trama de cierre.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// Escribe una trama. Debe llamarse con writeMu tomado.
+func (c *Conn) writeFrame(fin bool, opcode int, payload []byte) error {
+	header := make([]byte, 2, 14)
+	header[0] = byte(opcode)
+	if fin {
+		header[0] |= 0x80
+	}
+
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	// Las tramas del cliente van enmascaradas con una clave aleatoria
+	if c.client {
+		header[1] |= 0x80
+		var mask [4]byte
+		rand.Read(mask[:])
+		header = append(header, mask[:]...)
+		masked := make([]byte, length)
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+
+	buffers := net.Buffers{header, payload}
+	_, err := buffers.WriteTo(c.conn)
+	return err
+}
+
+// Escribe una trama de control.
+func (c *Conn) writeControl(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrClosed
+	}
+	return c.writeFrame(true, opcode, payload)
+}
+
+// WriteMessage envía un mensaje de texto o binario, fragmentado si FragmentSize > 0.
+func (c *Conn) WriteMessage(opcode int, data []byte) error {
+	if opcode != TextMessage && opcode != BinaryMessage {
+		return fmt.Errorf("invalid message type %d", opcode)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrClosed
+	}
+
+	size := c.FragmentSize
+	if size <= 0 || len(data) <= size {
+		return c.writeFrame(true, opcode, data)
+	}
+
+	for start := 0; start < len(data); start += size {
+		end := min(start+size, len(data))
+		frameOpcode := opcode
+		if start > 0 {
+			frameOpcode = continuationFrame
+		}
+		if err := c.writeFrame(end == len(data), frameOpcode, data[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Envía un mensaje de texto.
+func (c *Conn) WriteText(text string) error {
+	return c.WriteMessage(TextMessage, []byte(text))
+}
+
+// Envía un valor como mensaje de texto JSON.
+func (c *Conn) WriteJSON(value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// Envía un ping; el otro extremo debe responder con un pong.
+func (c *Conn) Ping(data []byte) error {
+	return c.writeControl(PingMessage, data)
+}
+
+// Envía la trama de cierre con el código y el motivo dados. Después no se
+// puede escribir más; la conexión TCP se cierra al terminar el manejador.
+func (c *Conn) Close(code int, reason string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return nil
+	}
+	c.closeSent = true
+
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	return c.writeFrame(true, CloseMessage, append(payload, reason...))
+}
+
+// Cierra la conexión TCP, enviando antes un cierre normal si no se envió ninguno.
+func (c *Conn) closeNow() {
+	c.Close(CloseNormal, "")
+	c.conn.Close()
+}
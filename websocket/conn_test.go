@@ -0,0 +1,121 @@
+package websocket
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"testing"
+)
+
+// newPair crea una conexión de servidor y otra de cliente unidas por TCP local.
+// A diferencia de net.Pipe, las escrituras no esperan a que el otro extremo lea.
+func newPair(t *testing.T, maxSize int64) (*Conn, *Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	conn2, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn1, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn1.Close()
+		conn2.Close()
+	})
+
+	server := newConn(conn1, bufio.NewReader(conn1), false, maxSize)
+	client := newConn(conn2, bufio.NewReader(conn2), true, maxSize)
+	return server, client
+}
+
+func TestProtocolErrors(t *testing.T) {
+	cases := []struct {
+		send func(client *Conn)
+		code int
+	}{
+		// Trama sin máscara desde el cliente
+		{func(client *Conn) {
+			client.client = false
+			client.writeFrame(true, TextMessage, []byte("x"))
+		}, CloseProtocolError},
+		// Bits reservados
+		{func(client *Conn) { client.writeFrame(true, TextMessage|0x40, []byte("x")) }, CloseProtocolError},
+		// Continuación sin mensaje iniciado
+		{func(client *Conn) { client.writeFrame(true, continuationFrame, []byte("x")) }, CloseProtocolError},
+		// Nuevo mensaje en medio de uno fragmentado
+		{func(client *Conn) {
+			client.writeFrame(false, TextMessage, []byte("a"))
+			client.writeFrame(true, TextMessage, []byte("b"))
+		}, CloseProtocolError},
+		// Trama de control fragmentada
+		{func(client *Conn) { client.writeFrame(false, PingMessage, nil) }, CloseProtocolError},
+		// Texto que no es UTF-8
+		{func(client *Conn) { client.writeFrame(true, TextMessage, []byte{0xff, 0xfe}) }, CloseInvalidPayload},
+		// Mensaje demasiado grande, aunque esté fragmentado
+		{func(client *Conn) {
+			client.writeFrame(false, BinaryMessage, make([]byte, 10))
+			client.writeFrame(true, continuationFrame, make([]byte, 10))
+		}, CloseMessageTooBig},
+		// Código de cierre inválido
+		{func(client *Conn) { client.writeFrame(true, CloseMessage, []byte{0x03, 0xed}) }, CloseProtocolError},
+		// Opcode desconocido
+		{func(client *Conn) { client.writeFrame(true, 3, nil) }, CloseProtocolError},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("TestProtocolErrors %d", i), func(t *testing.T) {
+			server, client := newPair(t, 16)
+
+			sent := make(chan struct{})
+			go func() {
+				defer close(sent)
+				c.send(client)
+			}()
+			_, _, err := server.ReadMessage()
+			<-sent
+
+			closeErr, ok := err.(*CloseError)
+			if !ok || closeErr.Code != c.code {
+				t.Fatalf("Expected close code %d, not %v", c.code, err)
+			}
+
+			// El servidor envió la trama de cierre con ese código
+			client.client = true
+			_, _, err = client.ReadMessage()
+			if closeErr, ok := err.(*CloseError); !ok || closeErr.Code != c.code {
+				t.Errorf("Expected close frame %d sent to client, not %v", c.code, err)
+			}
+		})
+	}
+}
+
+func TestFrameLengths(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 65535, 65536} {
+		t.Run(fmt.Sprintf("TestFrameLengths %d", size), func(t *testing.T) {
+			server, client := newPair(t, 1<<20)
+
+			go client.WriteMessage(BinaryMessage, make([]byte, size))
+			_, data, err := server.ReadMessage()
+			if err != nil || len(data) != size {
+				t.Errorf("Expected %d bytes, not %d (%v)", size, len(data), err)
+			}
+		})
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	server, client := newPair(t, 16)
+
+	go client.ReadMessage()
+	server.Close(CloseNormal, "done")
+	if err := server.WriteText("late"); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, not %v", err)
+	}
+}
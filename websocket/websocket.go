@@ -0,0 +1,120 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+// GUID que se concatena a Sec-WebSocket-Key para calcular Sec-WebSocket-Accept (RFC 6455, sección 1.3).
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Tamaño máximo por defecto de un mensaje (1 MiB).
+const DefaultMaxMessageSize = 1 << 20
+
+// Upgrader valida el handshake de apertura y convierte la conexión en un WebSocket.
+//
+//	upgrader := websocket.NewUpgrader()
+//	server.Get("/ws/echo", upgrader.Handler(func(conn *websocket.Conn) { ... }))
+type Upgrader struct {
+	MaxMessageSize int64                                // Tamaño máximo de un mensaje completo (1009 si se supera)
+	Subprotocols   []string                             // Subprotocolos admitidos, por orden de preferencia
+	CheckOrigin    func(request *core.HttpRequest) bool // Valida la cabecera Origin (nil acepta cualquiera)
+}
+
+// Crea un Upgrader con el tamaño máximo de mensaje por defecto.
+func NewUpgrader() *Upgrader {
+	return &Upgrader{MaxMessageSize: DefaultMaxMessageSize}
+}
+
+// Calcula Sec-WebSocket-Accept a partir de Sec-WebSocket-Key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Indica si la lista separada por comas contiene el token (sin distinguir mayúsculas).
+func hasToken(list, token string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Elige el primer subprotocolo admitido que el cliente ofrece.
+func (upgrader *Upgrader) selectSubprotocol(request *core.HttpRequest) string {
+	offered := request.Header("Sec-WebSocket-Protocol")
+	for _, protocol := range upgrader.Subprotocols {
+		if hasToken(offered, protocol) {
+			return protocol
+		}
+	}
+	return ""
+}
+
+// Upgrade valida el handshake de la solicitud. Si es correcto devuelve la
+// respuesta 101 y, una vez enviada, ejecuta handler con la conexión WebSocket;
+// la conexión se cierra cuando handler termina. Si no, devuelve 400, 403 o 426.
+func (upgrader *Upgrader) Upgrade(request *core.HttpRequest, handler func(conn *Conn)) *core.HttpResponse {
+	if request.Method != "GET" || request.Version != "HTTP/1.1" {
+		return core.BadRequest().Text("websocket handshake requires GET and HTTP/1.1")
+	}
+	if !hasToken(request.Header("Upgrade"), "websocket") || !hasToken(request.Header("Connection"), "upgrade") {
+		return core.NewHttpResponse(426, "Upgrade Required", "").
+			SetHeader("Upgrade", "websocket").
+			SetHeader("Connection", "Upgrade").
+			Text("websocket upgrade required")
+	}
+	if request.Header("Sec-WebSocket-Version") != "13" {
+		return core.NewHttpResponse(426, "Upgrade Required", "").
+			SetHeader("Sec-WebSocket-Version", "13").
+			Text("unsupported websocket version")
+	}
+
+	key := strings.TrimSpace(request.Header("Sec-WebSocket-Key"))
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return core.BadRequest().Text("invalid sec-websocket-key")
+	}
+
+	if upgrader.CheckOrigin != nil && !upgrader.CheckOrigin(request) {
+		slog.Warn("WebSocket origin rejected", "origin", request.Header("Origin"), "path", request.Target.Path)
+		return core.Forbidden().Text("origin not allowed")
+	}
+
+	response := core.NewHttpResponse(101, "Switching Protocols", "").
+		SetHeader("Upgrade", "websocket").
+		SetHeader("Connection", "Upgrade").
+		SetHeader("Sec-WebSocket-Accept", acceptKey(key))
+
+	subprotocol := upgrader.selectSubprotocol(request)
+	if subprotocol != "" {
+		response.SetHeader("Sec-WebSocket-Protocol", subprotocol)
+	}
+
+	response.Upgrade = func(netConn net.Conn, reader *bufio.Reader) {
+		conn := newConn(netConn, reader, false, upgrader.MaxMessageSize)
+		conn.Subprotocol = subprotocol
+		defer conn.closeNow()
+
+		slog.Info("WebSocket opened", "address", request.RemoteAddr, "path", request.Target.Path)
+		handler(conn)
+		slog.Info("WebSocket closed", "address", request.RemoteAddr, "path", request.Target.Path)
+	}
+
+	return response
+}
+
+// Handler devuelve un core.Handle que acepta WebSockets en la ruta y los
+// atiende con handler.
+func (upgrader *Upgrader) Handler(handler func(conn *Conn)) core.Handle {
+	return func(request *core.HttpRequest) (*core.HttpResponse, error) {
+		return upgrader.Upgrade(request, handler), nil
+	}
+}
@@ -0,0 +1,154 @@
+package websocket
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/KateGF/Http-Server-Project-SO/core"
+)
+
+const testKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// handshake abre una conexión TCP local con el servidor, envía la solicitud
+// de apertura y devuelve el código de estado, las cabeceras y el cliente.
+func handshake(t *testing.T, server *core.HttpServer, headers string) (int, map[string]string, *Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() { ln.Close() })
+
+	conn1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn1.Close() })
+	fmt.Fprintf(conn1, "GET /ws HTTP/1.1\r\nHost: x\r\n%s\r\n", headers)
+
+	reader := bufio.NewReader(conn1)
+	status, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read status: %v", err)
+	}
+	var code int
+	fmt.Sscanf(status, "HTTP/1.1 %d", &code)
+
+	received := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil || line == "\r\n" {
+			break
+		}
+		key, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+		received[key] = value
+	}
+
+	return code, received, newConn(conn1, reader, true, DefaultMaxMessageSize)
+}
+
+const upgradeHeaders = "Upgrade: websocket\r\nConnection: keep-alive, Upgrade\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: " + testKey + "\r\n"
+
+func newEchoServer(upgrader *Upgrader) *core.HttpServer {
+	server := core.NewHttpServer()
+	server.Get("/ws", upgrader.Handler(func(conn *Conn) {
+		for {
+			opcode, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(opcode, data)
+		}
+	}))
+	return server
+}
+
+func TestAcceptKey(t *testing.T) {
+	// Ejemplo de RFC 6455, sección 1.3
+	if got := acceptKey(testKey); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Expected s3pPLMBiTxaQ9kYGzzhZRbK+xOo=, not %s", got)
+	}
+}
+
+func TestHandshakeValidation(t *testing.T) {
+	server := newEchoServer(NewUpgrader())
+
+	cases := []struct {
+		headers string
+		code    int
+	}{
+		{upgradeHeaders, 101},
+		{"Connection: Upgrade\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: " + testKey + "\r\n", 426},
+		{"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 8\r\nSec-WebSocket-Key: " + testKey + "\r\n", 426},
+		{"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: short\r\n", 400},
+	}
+	for i, c := range cases {
+		code, headers, _ := handshake(t, server, c.headers)
+		if code != c.code {
+			t.Errorf("case %d: Expected %d, not %d", i, c.code, code)
+		}
+		if code == 101 && (headers["Sec-WebSocket-Accept"] != acceptKey(testKey) || headers["Upgrade"] != "websocket") {
+			t.Errorf("case %d: Unexpected handshake headers %v", i, headers)
+		}
+	}
+}
+
+func TestHandshakeOriginAndSubprotocol(t *testing.T) {
+	upgrader := NewUpgrader()
+	upgrader.Subprotocols = []string{"status.v2", "status.v1"}
+	upgrader.CheckOrigin = func(request *core.HttpRequest) bool {
+		return request.Header("Origin") == "https://panel.test"
+	}
+	server := newEchoServer(upgrader)
+
+	code, headers, _ := handshake(t, server, upgradeHeaders+"Origin: https://panel.test\r\nSec-WebSocket-Protocol: status.v1, status.v2\r\n")
+	if code != 101 || headers["Sec-WebSocket-Protocol"] != "status.v2" {
+		t.Errorf("Expected 101 with status.v2, not %d %v", code, headers)
+	}
+
+	code, _, _ = handshake(t, server, upgradeHeaders+"Origin: https://evil.test\r\n")
+	if code != 403 {
+		t.Errorf("Expected 403 for disallowed origin, not %d", code)
+	}
+}
+
+func TestEchoThroughServer(t *testing.T) {
+	server := newEchoServer(NewUpgrader())
+	_, _, client := handshake(t, server, upgradeHeaders)
+
+	// Mensaje simple
+	client.WriteText("hola")
+	opcode, data, err := client.ReadMessage()
+	if err != nil || opcode != TextMessage || string(data) != "hola" {
+		t.Fatalf("Expected text hola, not %d %q %v", opcode, data, err)
+	}
+
+	// Mensaje fragmentado
+	client.FragmentSize = 3
+	client.WriteMessage(BinaryMessage, []byte("0123456789"))
+	opcode, data, _ = client.ReadMessage()
+	if opcode != BinaryMessage || string(data) != "0123456789" {
+		t.Errorf("Expected reassembled binary message, not %d %q", opcode, data)
+	}
+
+	// Ping → pong
+	pong := make(chan string, 1)
+	client.PongHandler = func(data []byte) { pong <- string(data) }
+	client.Ping([]byte("p"))
+	client.WriteText("after ping")
+	client.ReadMessage()
+	if got := <-pong; got != "p" {
+		t.Errorf("Expected pong p, not %q", got)
+	}
+
+	// Cierre iniciado por el cliente: el servidor responde con el mismo código
+	client.Close(CloseGoingAway, "bye")
+	_, _, err = client.ReadMessage()
+	if closeErr, ok := err.(*CloseError); !ok || closeErr.Code != CloseGoingAway {
+		t.Errorf("Expected close 1001, not %v", err)
+	}
+}